@@ -15,13 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/pet": {
-            "put": {
+        "/adoption": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "Admins see all applications, other users only their own. Newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -29,30 +30,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pet"
+                    "adoption"
                 ],
-                "summary": "Update an existing pet",
-                "operationId": "3updatePet",
+                "summary": "Returns adoption applications",
+                "operationId": "1listApplications",
                 "parameters": [
                     {
-                        "description": "Pet object that needs to be added to the store",
-                        "name": "object",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Pet"
-                        }
+                        "type": "integer",
+                        "description": "Applications for this pet",
+                        "name": "petId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Applications of this user",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "submitted",
+                            "approved",
+                            "rejected",
+                            "withdrawn"
+                        ],
+                        "type": "string",
+                        "description": "Applications with this status",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Pet"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AdoptionApplication"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "applications of another user",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
                         }
                     }
-                },
-                "x-sort": 3
+                }
             },
             "post": {
                 "security": [
@@ -60,6 +84,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "The applicant is the user from the JWT. Only available pets accept applications, one submitted application per user and pet",
                 "consumes": [
                     "application/json"
                 ],
@@ -67,18 +92,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pet"
+                    "adoption"
                 ],
-                "summary": "Add a new pet to the store",
-                "operationId": "2addPet",
+                "summary": "Applies for a pet",
+                "operationId": "2applyForPet",
                 "parameters": [
                     {
-                        "description": "Pet object that needs to be added to the store",
+                        "description": "Application, only petId and message are used",
                         "name": "object",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Pet"
+                            "$ref": "#/definitions/models.AdoptionApplication"
                         }
                     }
                 ],
@@ -86,21 +111,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Pet"
+                            "$ref": "#/definitions/models.AdoptionApplication"
+                        }
+                    },
+                    "409": {
+                        "description": "pet is not available or already applied for",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
                         }
                     }
-                },
-                "x-sort": 2
+                }
             }
         },
-        "/pet/findByStatus": {
+        "/adoption/{applicationId}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Multiple status values can be provided with comma separated strings",
                 "consumes": [
                     "application/json"
                 ],
@@ -108,25 +137,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pet"
+                    "adoption"
                 ],
-                "summary": "Finds Pets by status",
-                "operationId": "4findPetsByStatus",
+                "summary": "Find adoption application by ID",
+                "operationId": "3getApplicationById",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "enum": [
-                                "available",
-                                "pending",
-                                "sold"
-                            ],
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Status values that need to be considered for filter",
-                        "name": "status",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "ID of application to return",
+                        "name": "applicationId",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -134,23 +154,32 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Pet"
-                            }
+                            "$ref": "#/definitions/models.AdoptionApplication"
+                        }
+                    },
+                    "403": {
+                        "description": "application of another user",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
                         }
                     }
-                },
-                "x-sort": 4
+                }
             }
         },
-        "/pet/findByTags": {
-            "get": {
+        "/adoption/{applicationId}/approve": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "Admin only. The pet becomes pending, an order is placed for it and the other submitted applications for the pet are rejected",
                 "consumes": [
                     "application/json"
                 ],
@@ -158,45 +187,57 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pet"
+                    "adoption"
                 ],
-                "summary": "Finds Pets by tags",
-                "operationId": "5findPetsByTags",
-                "deprecated": true,
+                "summary": "Approves an adoption application",
+                "operationId": "4approveApplication",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Tags to filter by",
-                        "name": "tags",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "ID of application to approve",
+                        "name": "applicationId",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Review notes",
+                        "name": "object",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.AdoptionReview"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Pet"
-                            }
+                            "$ref": "#/definitions/models.AdoptionApplication"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "application is closed or pet is not available",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
                         }
                     }
-                },
-                "x-sort": 5
+                }
             }
         },
-        "/pet/{petId}": {
-            "get": {
+        "/adoption/{applicationId}/reject": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "Admin only",
                 "consumes": [
                     "application/json"
                 ],
@@ -204,78 +245,101 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pet"
+                    "adoption"
                 ],
-                "summary": "Find pet by ID",
-                "operationId": "6getPetById",
+                "summary": "Rejects an adoption application",
+                "operationId": "5rejectApplication",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID of pet to return",
-                        "name": "petId",
+                        "description": "ID of application to reject",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Review notes",
+                        "name": "object",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.AdoptionReview"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Pet"
+                            "$ref": "#/definitions/models.AdoptionApplication"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "application is closed",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
                         }
                     }
-                },
-                "x-sort": 6
-            },
+                }
+            }
+        },
+        "/adoption/{applicationId}/withdraw": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "Only the applicant can withdraw a submitted application",
                 "consumes": [
-                    "application/x-www-form-urlencoded"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "pet"
+                    "adoption"
                 ],
-                "summary": "Updates a pet in the store with form data",
-                "operationId": "7updatePetWithForm",
+                "summary": "Withdraws an adoption application",
+                "operationId": "6withdrawApplication",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID of pet that needs to be updated",
-                        "name": "petId",
+                        "description": "ID of application to withdraw",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Updated name of the pet",
-                        "name": "name",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Updated status of the pet",
-                        "name": "status",
-                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AdoptionApplication"
+                        }
+                    },
+                    "403": {
+                        "description": "application of another user",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "application is closed",
                         "schema": {
                             "$ref": "#/definitions/responder.Response"
                         }
                     }
-                },
-                "x-sort": 7
-            },
-            "delete": {
+                }
+            }
+        },
+        "/category": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -288,89 +352,80 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pet"
-                ],
-                "summary": "Deletes a pet",
-                "operationId": "8deletePet",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Pet id to delete",
-                        "name": "petId",
-                        "in": "path",
-                        "required": true
-                    }
+                    "category"
                 ],
+                "summary": "Returns all categories",
+                "operationId": "1listCategories",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responder.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Category"
+                            }
                         }
                     }
-                },
-                "x-sort": 8
-            }
-        },
-        "/pet/{petId}/uploadImage": {
+                }
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "Admin only",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "pet"
+                    "category"
                 ],
-                "summary": "uploads an image",
-                "operationId": "1uploadFile",
+                "summary": "Create a new category",
+                "operationId": "3createCategory",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID of pet to update",
-                        "name": "petId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Additional data to pass to server",
-                        "name": "additionalMetadata",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "file",
-                        "description": "file to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "description": "Category to create, id is ignored",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/responder.Response"
                         }
                     }
-                },
-                "x-sort": 1
+                }
             }
         },
-        "/store/inventory": {
+        "/category/{categoryId}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns a map of status codes to quantities",
                 "consumes": [
                     "application/json"
                 ],
@@ -378,25 +433,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "store"
+                    "category"
+                ],
+                "summary": "Find category by ID",
+                "operationId": "2getCategoryById",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of category to return",
+                        "name": "categoryId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Returns pet inventories by status",
-                "operationId": "1getInventory",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
                         }
                     }
                 }
-            }
-        },
-        "/store/order": {
-            "post": {
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only",
                 "consumes": [
                     "application/json"
                 ],
@@ -404,18 +475,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "store"
+                    "category"
                 ],
-                "summary": "Place an order for a pet",
-                "operationId": "2placeOrder",
+                "summary": "Rename a category",
+                "operationId": "4updateCategory",
                 "parameters": [
                     {
-                        "description": "order placed for purchasing the pet",
+                        "type": "integer",
+                        "description": "ID of category to update",
+                        "name": "categoryId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated category, id is taken from the path",
                         "name": "object",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Category"
                         }
                     }
                 ],
@@ -423,15 +501,36 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
                         }
                     }
                 }
-            }
-        },
-        "/store/order/{orderId}": {
-            "get": {
-                "description": "For valid response try integer IDs with value \u003e= 1 and \u003c= 10. Other values will generated exceptions",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. A category that is still referenced by pets can not be deleted",
                 "consumes": [
                     "application/json"
                 ],
@@ -439,15 +538,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "store"
+                    "category"
                 ],
-                "summary": "Find purchase order by ID",
-                "operationId": "3getOrderById",
+                "summary": "Deletes a category",
+                "operationId": "5deleteCategory",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID of pet that needs to be fetched",
-                        "name": "orderId",
+                        "description": "ID of category to delete",
+                        "name": "categoryId",
                         "in": "path",
                         "required": true
                     }
@@ -456,13 +555,38 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "For valid response try integer IDs with positive integer value. Negative or non-integer values will generate API errors",
+            }
+        },
+        "/pet": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cursor based pagination: pass pagination.nextCursor from the previous page as cursor, keeping the same sort and filters. Deleted pets are listed only when status=deleted is requested. Links to the first and the next page are also returned in the Link header",
                 "consumes": [
                     "application/json"
                 ],
@@ -470,32 +594,96 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "store"
+                    "pet"
                 ],
-                "summary": "Delete purchase order by ID",
-                "operationId": "4deleteOrder",
+                "summary": "Lists pets page by page",
+                "operationId": "10listPets",
                 "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Status values to filter by",
+                        "name": "status",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "ID of pet that needs to be deleted",
-                        "name": "orderId",
-                        "in": "path",
-                        "required": true
+                        "description": "Category ID to filter by",
+                        "name": "categoryId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Tag names, a pet must have all of them",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name prefix",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "name",
+                            "-name",
+                            "status",
+                            "-status"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field, prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page to return",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responder.Response"
+                            "$ref": "#/definitions/models.PetPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "first and next page links"
+                            }
                         }
                     }
-                }
-            }
-        },
-        "/user": {
-            "post": {
-                "description": "This can only be done by the logged in user.",
+                },
+                "x-sort": 10
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "consumes": [
                     "application/json"
                 ],
@@ -503,33 +691,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Create user",
-                "operationId": "8createUser",
+                "summary": "Update an existing pet",
+                "operationId": "3updatePet",
                 "parameters": [
                     {
-                        "description": "Created user object",
+                        "description": "Pet object that needs to be added to the store",
                         "name": "object",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.Pet"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from getPetById, the update fails with 412 if the pet has changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Pet"
+                        }
+                    },
+                    "409": {
+                        "description": "illegal status transition or pet was changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "pet was modified",
                         "schema": {
                             "$ref": "#/definitions/responder.Response"
                         }
                     }
-                }
-            }
-        },
-        "/user/createWithArray": {
+                },
+                "x-sort": 3
+            },
             "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Repeating the request with the same Idempotency-Key returns the saved response instead of adding the pet again",
                 "consumes": [
                     "application/json"
                 ],
@@ -537,73 +748,117 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Creates list of users with given input array",
-                "operationId": "7createUsersWithArrayInput",
+                "summary": "Add a new pet to the store",
+                "operationId": "2addPet",
                 "parameters": [
                     {
-                        "description": "List of user object",
+                        "description": "Pet object that needs to be added to the store",
                         "name": "object",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.User"
-                            }
+                            "$ref": "#/definitions/models.Pet"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, retries must reuse it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Pet"
+                        }
+                    },
+                    "409": {
+                        "description": "request with this key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "key was used with a different request",
                         "schema": {
                             "$ref": "#/definitions/responder.Response"
                         }
                     }
-                }
+                },
+                "x-sort": 2
             }
         },
-        "/user/createWithList": {
-            "post": {
+        "/pet/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Streams pets with categories, tags and photo URLs ordered by ID. Without status all pets except deleted are exported",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Creates list of users with given input array",
-                "operationId": "1createUsersWithListInput",
+                "summary": "Exports pets as CSV or NDJSON",
+                "operationId": "26exportPets",
                 "parameters": [
                     {
-                        "description": "List of user object",
-                        "name": "object",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.User"
-                            }
-                        }
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "ndjson",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "available",
+                                "pending",
+                                "sold",
+                                "deleted"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "Status values, comma separated",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responder.Response"
+                            "type": "file"
                         }
                     }
-                }
+                },
+                "x-sort": 26
             }
         },
-        "/user/login": {
+        "/pet/findByStatus": {
             "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Multiple status values can be provided with comma separated strings",
                 "consumes": [
                     "application/json"
                 ],
@@ -611,20 +866,2313 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Logs user into the system",
-                "operationId": "5loginUser",
+                "summary": "Finds Pets by status",
+                "operationId": "4findPetsByStatus",
                 "parameters": [
                     {
-                        "type": "string",
-                        "default": "admin",
-                        "description": "The user name for login",
-                        "name": "username",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "available",
+                                "pending",
+                                "sold"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "Status values that need to be considered for filter",
+                        "name": "status",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Pet"
+                            }
+                        }
+                    }
+                },
+                "x-sort": 4
+            }
+        },
+        "/pet/findByTags": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Tags can be provided by name, by ID or both. With match=any a pet needs at least one of the tags, with match=all it needs every one of them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Finds Pets by tags",
+                "operationId": "5findPetsByTags",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Tag names to filter by",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Tag IDs to filter by",
+                        "name": "tagIds",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Match mode",
+                        "name": "match",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Pet"
+                            }
+                        }
+                    }
+                },
+                "x-sort": 5
+            }
+        },
+        "/pet/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. The file is read as a stream, each row is added like POST /pet and independently of the others. CSV needs a header with at least the name column, lists (tag_ids, photo_urls) are separated by |. The export file can be imported back. With dryRun rows are only validated. The report counts all rows and lists the first 100 rejected ones",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Imports pets from CSV or NDJSON",
+                "operationId": "25importPets",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Validate rows without adding pets",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PetImportReport"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 25
+            }
+        },
+        "/pet/medical/overdue": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Vaccinations whose follow-up date is before the given date and that were not repeated since (a later vaccination with the same title). Deleted pets are skipped. Most overdue first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Finds pets with overdue vaccinations",
+                "operationId": "21findOverdueVaccinations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date to check against, YYYY-MM-DD (today by default)",
+                        "name": "date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OverdueVaccination"
+                            }
+                        }
+                    }
+                },
+                "x-sort": 21
+            }
+        },
+        "/pet/search": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Searches words as prefixes in pet name, category name and tag names, deleted pets are skipped. Results are ordered by relevance, matches are wrapped in \u003cmark\u003e in highlight (name) and snippet (best matching field). Answers 503 when the server is built without SQLite FTS5",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Full-text search over pets",
+                "operationId": "11searchPets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "golden puppy",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PetSearchResult"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 11
+            }
+        },
+        "/pet/{petId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Find pet by ID",
+                "operationId": "6getPetById",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet to return",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Pet"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the pet, pass it in If-Match to update"
+                            }
+                        }
+                    }
+                },
+                "x-sort": 6
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Updates a pet in the store with form data",
+                "operationId": "7updatePetWithForm",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet that needs to be updated",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Updated name of the pet",
+                        "name": "name",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "available",
+                            "pending",
+                            "sold",
+                            "deleted"
+                        ],
+                        "type": "string",
+                        "description": "Updated status of the pet",
+                        "name": "status",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from getPetById, the update fails with 412 if the pet has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "illegal status transition or pet was changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "pet was modified",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 7
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Deletes a pet",
+                "operationId": "8deletePet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Pet id to delete",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "illegal status transition or pet was changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 8
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "application/merge-patch+json (RFC 7386): a partial pet, fields set to null are cleared. application/json-patch+json (RFC 6902): a list of operations, e.g. [{\"op\":\"add\",\"path\":\"/tags/-\",\"value\":{\"id\":2}}]. id, photos and version are read-only. A failed test operation answers 409",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Partially updates a pet",
+                "operationId": "15patchPet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet to update",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "JSON Patch operations or a merge patch object",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/jsonpatch.Operation"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from getPetById, the update fails with 412 if the pet has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Pet"
+                        }
+                    },
+                    "409": {
+                        "description": "illegal status transition, failed test operation or pet was changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "pet was modified",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "415": {
+                        "description": "unsupported patch format",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 15
+            }
+        },
+        "/pet/{petId}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Every change of the pet with old and new field values, the user from the JWT and the request ID, oldest first. The history is kept after the pet is purged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Returns the change history of a pet",
+                "operationId": "12getPetHistory",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PetHistoryEntry"
+                            }
+                        }
+                    }
+                },
+                "x-sort": 12
+            }
+        },
+        "/pet/{petId}/medical": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Vaccinations, vet visits and treatments of the pet, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Returns the medical records of a pet",
+                "operationId": "16listMedicalRecords",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PetMedicalRecord"
+                            }
+                        }
+                    }
+                },
+                "x-sort": 16
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "kind is vaccination, vetVisit or treatment. dueDate (the follow-up vaccination) is allowed for vaccinations only and must be after date",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Adds a medical record to a pet",
+                "operationId": "17addMedicalRecord",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Medical record",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PetMedicalRecord"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PetMedicalRecord"
+                        }
+                    },
+                    "409": {
+                        "description": "pet is deleted",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 17
+            }
+        },
+        "/pet/{petId}/medical/{recordId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Find a medical record of a pet by ID",
+                "operationId": "18getMedicalRecord",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of medical record",
+                        "name": "recordId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PetMedicalRecord"
+                        }
+                    },
+                    "404": {
+                        "description": "record not found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 18
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Updates a medical record of a pet",
+                "operationId": "19updateMedicalRecord",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of medical record",
+                        "name": "recordId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Medical record",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PetMedicalRecord"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PetMedicalRecord"
+                        }
+                    },
+                    "404": {
+                        "description": "record not found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "pet is deleted",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 19
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Deletes a medical record of a pet",
+                "operationId": "20deleteMedicalRecord",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of medical record",
+                        "name": "recordId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "record not found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 20
+            }
+        },
+        "/pet/{petId}/photos/{photoId}": {
+            "get": {
+                "description": "No authorization, so the photo URL can be used in an img tag. Supports Range requests and conditional requests with If-None-Match / If-Modified-Since",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Downloads an uploaded pet photo",
+                "operationId": "9getPetPhoto",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of photo to return",
+                        "name": "photoId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Return the resized variant with this maximum side instead of the original, the original itself if it is not larger",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "checksum of the photo"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "upload time of the photo"
+                            }
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    }
+                },
+                "x-sort": 9
+            }
+        },
+        "/pet/{petId}/purge": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. Removes the pet with its photos and tags, and the stored files no other pet uses. The pet history is kept and ends with a purge entry, adoption applications are kept and the submitted ones are rejected. The pet must be deleted first and must not be referenced by orders",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Permanently removes a deleted pet",
+                "operationId": "14purgePet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet to purge",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "pet is not deleted or has orders",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 14
+            }
+        },
+        "/pet/{petId}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the pet to the status it had before deletion (taken from the pet history, available if unknown). A reserved pet becomes available, as deletion drops its order reservation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Restores a deleted pet",
+                "operationId": "13restorePet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet to restore",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Pet"
+                        }
+                    },
+                    "409": {
+                        "description": "pet is not deleted or was changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 13
+            }
+        },
+        "/pet/{petId}/uploadImage": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Accepts JPEG, PNG and GIF images (detected by content) of at most 50 megapixels. EXIF/GPS and other metadata is removed before the image is stored",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "uploads an image",
+                "operationId": "1uploadFile",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet to update",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Additional data to pass to server",
+                        "name": "additionalMetadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "image dimensions are too large",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 1
+            }
+        },
+        "/pet/{petId}/waitlist": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admins see the whole waitlist, other users only their own entry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Returns the waitlist of a pet",
+                "operationId": "22listWaitlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PetWaitlistEntry"
+                            }
+                        }
+                    }
+                },
+                "x-sort": 22
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "The user from the JWT is notified (log, webhook or email, depending on the server settings) when the pet becomes available again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Joins the waitlist of a pending pet",
+                "operationId": "23joinWaitlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PetWaitlistEntry"
+                        }
+                    },
+                    "409": {
+                        "description": "pet is not pending",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 23
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "Leaves the waitlist of a pet",
+                "operationId": "24leaveWaitlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "not on the waitlist",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                },
+                "x-sort": 24
+            }
+        },
+        "/store/accessories": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Lists accessories with prices and stock",
+                "operationId": "16listAccessories",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Accessory"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/store/cart": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Prices are current, they are fixed when the cart is checked out",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Returns the cart of the current user",
+                "operationId": "10getCart",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Cart"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Removes all items from the cart",
+                "operationId": "14clearCart",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/cart/checkout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "In one transaction all pets are reserved (pending), accessories are taken from stock, the order is created with line items and total at current prices and the cart is emptied. If any item cannot be bought nothing changes. Repeating the request with the same Idempotency-Key returns the saved response instead of placing a second order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Places one order for everything in the cart",
+                "operationId": "15checkoutCart",
+                "parameters": [
+                    {
+                        "description": "Checkout details",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CartCheckout"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, retries must reuse it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "409": {
+                        "description": "pet is not available, accessory is out of stock or a request with this key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "key was used with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/cart/items": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Set either petId or accessoryId. A pet must be available and have a price, it is added once and reserved only on checkout. Quantity of an accessory already in the cart is increased, up to 99 in total",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Adds a pet or an accessory to the cart",
+                "operationId": "11addCartItem",
+                "parameters": [
+                    {
+                        "description": "Cart item",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CartItem"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Cart"
+                        }
+                    },
+                    "409": {
+                        "description": "pet is not available or already in the cart",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/cart/items/{itemId}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Changes quantity of an accessory in the cart",
+                "operationId": "12updateCartItem",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of cart item",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Only quantity is used",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CartItem"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Cart"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Removes an item from the cart",
+                "operationId": "13deleteCartItem",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of cart item",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Cart"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/inventory": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a map of status codes to quantities",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Returns pet inventories by status",
+                "operationId": "1getInventory",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/store/order": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "The order is placed and the pet becomes pending in one transaction. Only available pets can be ordered. The quantity is 1 or omitted. The order belongs to the user from the token. Repeating the request with the same Idempotency-Key returns the saved response instead of placing a second order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Place an order for a pet",
+                "operationId": "2placeOrder",
+                "parameters": [
+                    {
+                        "description": "order placed for purchasing the pet",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, retries must reuse it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "403": {
+                        "description": "token has no user id",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "pet is not available, was reserved by another order or a request with this key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "key was used with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/order/{orderId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Users can see only their own orders, admins can see any order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Find purchase order by ID",
+                "operationId": "3getOrderById",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet that needs to be fetched",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "403": {
+                        "description": "order belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Orders are not removed, the order is cancelled like POST /store/order/{orderId}/cancel",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Delete purchase order by ID",
+                "operationId": "4deleteOrder",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet that needs to be deleted",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "order belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "order cannot be cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/order/{orderId}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. placed -\u003e approved",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Approves a placed order",
+                "operationId": "5approveOrder",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of order to approve",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "illegal status transition",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/order/{orderId}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Only placed and approved orders can be cancelled, by their owner or an admin. Reserved pets become available again, accessories return to stock",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Cancels an order",
+                "operationId": "8cancelOrder",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of order to cancel",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "403": {
+                        "description": "order belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "illegal status transition",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/order/{orderId}/deliver": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. shipped -\u003e delivered, the order becomes complete",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Marks a shipped order as delivered",
+                "operationId": "7deliverOrder",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of order to deliver",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "illegal status transition",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/order/{orderId}/ship": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. approved -\u003e shipped",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Ships an approved order",
+                "operationId": "6shipOrder",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of order to ship",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "illegal status transition",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/orders": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Newest orders first. Users see only their own orders, admins see all orders or the orders of userId. Pass pagination.nextCursor from the previous page as cursor, keeping the same filters. Links to the first and the next page are also returned in the Link header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Lists orders page by page",
+                "operationId": "9listOrders",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Status values to filter by",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Ship date from, inclusive",
+                        "name": "shipFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Ship date to, inclusive",
+                        "name": "shipTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Owner ID, admin only",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page to return",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "first and next page links"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "orders of another user",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "petCount is the number of pets (except deleted ones) that use the tag",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Returns all tags",
+                "operationId": "1listTags",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TagUsage"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Create a new tag",
+                "operationId": "3createTag",
+                "parameters": [
+                    {
+                        "description": "Tag to create, id is ignored",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag/{tagId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Find tag by ID",
+                "operationId": "2getTagById",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of tag to return",
+                        "name": "tagId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagUsage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Rename a tag",
+                "operationId": "4renameTag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of tag to rename",
+                        "name": "tagId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tag with the new name, id is taken from the path",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. The tag is removed from all pets that use it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Deletes a tag",
+                "operationId": "6deleteTag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of tag to delete",
+                        "name": "tagId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag/{tagId}/merge/{targetId}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. All pets tagged with tagId get targetId instead, then tagId is deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Merge one tag into another",
+                "operationId": "5mergeTags",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of tag to merge and delete",
+                        "name": "tagId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of tag that remains",
+                        "name": "targetId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagUsage"
+                        }
+                    },
+                    "403": {
+                        "description": "admin role required",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "post": {
+                "description": "This can only be done by the logged in user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Create user",
+                "operationId": "8createUser",
+                "parameters": [
+                    {
+                        "description": "Created user object",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/createWithArray": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Creates list of users with given input array",
+                "operationId": "7createUsersWithArrayInput",
+                "parameters": [
+                    {
+                        "description": "List of user object",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/createWithList": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Creates list of users with given input array",
+                "operationId": "1createUsersWithListInput",
+                "parameters": [
+                    {
+                        "description": "List of user object",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responder.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Logs user into the system",
+                "operationId": "5loginUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "admin",
+                        "description": "The user name for login",
+                        "name": "username",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
                         "type": "string",
                         "default": "admin",
                         "description": "The password for login in clear text",
@@ -781,54 +3329,360 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "jsonpatch.Operation": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "add",
+                        "remove",
+                        "replace",
+                        "move",
+                        "copy",
+                        "test"
+                    ],
+                    "example": "add"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/tags/-"
+                },
+                "value": {
+                    "type": "object"
+                }
+            }
+        },
+        "models.Accessory": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Leash"
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "stock": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "models.AdoptionApplication": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "message": {
+                    "type": "string",
+                    "maxLength": 4000,
+                    "example": "We have a big garden"
+                },
+                "orderId": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "petId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reviewNotes": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "Home visit passed"
+                },
+                "reviewedAt": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "reviewedBy": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "admin"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "submitted",
+                        "approved",
+                        "rejected",
+                        "withdrawn"
+                    ],
+                    "readOnly": true,
+                    "example": "submitted"
+                },
+                "username": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "admin"
+                }
+            }
+        },
+        "models.AdoptionReview": {
+            "type": "object",
+            "properties": {
+                "notes": {
+                    "type": "string",
+                    "maxLength": 4000,
+                    "example": "Home visit passed"
+                }
+            }
+        },
+        "models.Cart": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CartItem"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 51400
+                }
+            }
+        },
+        "models.CartCheckout": {
+            "type": "object",
+            "properties": {
+                "shipDate": {
+                    "type": "string",
+                    "example": "2022-01-01T06:29:51.438Z"
+                }
+            }
+        },
+        "models.CartItem": {
+            "type": "object",
+            "properties": {
+                "accessoryId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "Leash"
+                },
+                "petId": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                },
+                "subtotal": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1500
+                },
+                "unitPrice": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1500
+                }
+            }
+        },
         "models.Category": {
             "type": "object",
             "properties": {
                 "id": {
                     "type": "integer",
-                    "example": 4
+                    "example": 4
+                },
+                "name": {
+                    "type": "string",
+                    "example": "rabbit"
+                }
+            }
+        },
+        "models.Order": {
+            "type": "object",
+            "properties": {
+                "complete": {
+                    "type": "boolean",
+                    "readOnly": true,
+                    "example": false
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderItem"
+                    },
+                    "readOnly": true
+                },
+                "petId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "shipDate": {
+                    "type": "string",
+                    "example": "2022-01-01T06:29:51.438Z"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "placed",
+                        "approved",
+                        "shipped",
+                        "delivered",
+                        "cancelled"
+                    ],
+                    "example": "placed"
+                },
+                "total": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 51400
+                },
+                "userId": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                }
+            }
+        },
+        "models.OrderItem": {
+            "type": "object",
+            "properties": {
+                "accessoryId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
                 },
                 "name": {
                     "type": "string",
-                    "example": "rabbit"
+                    "example": "Leash"
+                },
+                "petId": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "subtotal": {
+                    "type": "integer",
+                    "example": 3000
+                },
+                "unitPrice": {
+                    "type": "integer",
+                    "example": 1500
                 }
             }
         },
-        "models.Order": {
+        "models.OrderPage": {
             "type": "object",
             "properties": {
-                "complete": {
-                    "type": "boolean",
-                    "example": true
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
                 },
-                "id": {
+                "pagination": {
+                    "$ref": "#/definitions/models.Pagination"
+                }
+            }
+        },
+        "models.OverdueVaccination": {
+            "type": "object",
+            "properties": {
+                "daysOverdue": {
                     "type": "integer",
-                    "example": 1
+                    "example": 14
                 },
                 "petId": {
                     "type": "integer",
                     "example": 1
                 },
-                "quantity": {
+                "petName": {
+                    "type": "string",
+                    "example": "Daisy"
+                },
+                "record": {
+                    "$ref": "#/definitions/models.PetMedicalRecord"
+                }
+            }
+        },
+        "models.Pagination": {
+            "type": "object",
+            "properties": {
+                "hasMore": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "limit": {
                     "type": "integer",
-                    "example": 10
+                    "example": 20
                 },
-                "shipDate": {
+                "nextCursor": {
                     "type": "string",
-                    "example": "2022-01-01T06:29:51.438Z"
+                    "example": "eyJzIjoiLW5hbWUiLCJ2IjoiRGFpc3kiLCJpZCI6MX0"
                 },
-                "status": {
+                "sort": {
                     "type": "string",
-                    "example": "placed"
+                    "example": "-name"
                 }
             }
         },
         "models.Pet": {
             "type": "object",
             "properties": {
+                "birthDate": {
+                    "type": "string",
+                    "format": "date",
+                    "example": "2021-05-14"
+                },
+                "breed": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "beagle"
+                },
                 "category": {
                     "$ref": "#/definitions/models.Category"
                 },
+                "description": {
+                    "type": "string",
+                    "maxLength": 4000,
+                    "example": "Loves long walks"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
@@ -843,8 +3697,34 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "photos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PetPhoto"
+                    },
+                    "readOnly": true
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 49900
+                },
+                "sex": {
+                    "type": "string",
+                    "enum": [
+                        "male",
+                        "female"
+                    ],
+                    "example": "female"
+                },
                 "status": {
                     "type": "string",
+                    "enum": [
+                        "available",
+                        "pending",
+                        "sold",
+                        "deleted"
+                    ],
                     "example": "available"
                 },
                 "tags": {
@@ -852,6 +3732,313 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/models.Tag"
                     }
+                },
+                "version": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "weight": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 12.5
+                }
+            }
+        },
+        "models.PetFieldChange": {
+            "type": "object",
+            "properties": {
+                "new": {},
+                "old": {}
+            }
+        },
+        "models.PetHistoryEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "restore",
+                        "uploadPhoto",
+                        "import",
+                        "purge",
+                        "mergeTag",
+                        "deleteTag",
+                        "addMedicalRecord",
+                        "updateMedicalRecord",
+                        "deleteMedicalRecord"
+                    ],
+                    "example": "update"
+                },
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.PetFieldChange"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "petId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "requestId": {
+                    "type": "string",
+                    "example": "host/AbCdEf1234-000001"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "admin"
+                }
+            }
+        },
+        "models.PetImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "category 7 not found"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Daisy"
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.PetImportReport": {
+            "type": "object",
+            "properties": {
+                "dryRun": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "error": {
+                    "type": "string",
+                    "example": "line 4: extraneous or missing \" in quoted-field"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PetImportError"
+                    }
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "truncated": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.PetMedicalRecord": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "createdBy": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "admin"
+                },
+                "date": {
+                    "type": "string",
+                    "format": "date",
+                    "example": "2024-03-01"
+                },
+                "dueDate": {
+                    "type": "string",
+                    "format": "date",
+                    "example": "2025-03-01"
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "vaccination",
+                        "vetVisit",
+                        "treatment"
+                    ],
+                    "example": "vaccination"
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 4000,
+                    "example": "Nobivac, batch 1234"
+                },
+                "petId": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Rabies"
+                },
+                "vet": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Dr. Smith"
+                }
+            }
+        },
+        "models.PetPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Pet"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/models.Pagination"
+                }
+            }
+        },
+        "models.PetPhoto": {
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "fileName": {
+                    "type": "string",
+                    "example": "daisy.jpg"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "mimeType": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "petId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 48213
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/v2/pet/1/photos/3"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PetPhotoVariant"
+                    }
+                }
+            }
+        },
+        "models.PetPhotoVariant": {
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "type": "string",
+                    "example": "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 96
+                },
+                "maxSize": {
+                    "type": "integer",
+                    "example": 128
+                },
+                "mimeType": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 5120
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/v2/pet/1/photos/3?size=128"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 128
+                }
+            }
+        },
+        "models.PetSearchResult": {
+            "type": "object",
+            "properties": {
+                "highlight": {
+                    "type": "string",
+                    "example": "\u003cmark\u003eGolden\u003c/mark\u003e Boy"
+                },
+                "pet": {
+                    "$ref": "#/definitions/models.Pet"
+                },
+                "rank": {
+                    "type": "number",
+                    "example": -3.7
+                },
+                "snippet": {
+                    "type": "string",
+                    "example": "dog \u003cmark\u003epuppy\u003c/mark\u003e kennel"
+                }
+            }
+        },
+        "models.PetWaitlistEntry": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "notifiedAt": {
+                    "type": "string"
+                },
+                "petId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "username": {
+                    "type": "string",
+                    "example": "admin"
                 }
             }
         },
@@ -868,6 +4055,23 @@ const docTemplate = `{
                 }
             }
         },
+        "models.TagUsage": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "name": {
+                    "type": "string",
+                    "example": "gift"
+                },
+                "petCount": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
         "models.User": {
             "type": "object",
             "properties": {
@@ -895,6 +4099,11 @@ const docTemplate = `{
                     "type": "string",
                     "example": "8-999-666-99-66"
                 },
+                "role": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "user"
+                },
                 "userStatus": {
                     "type": "integer",
                     "example": 1
@@ -942,6 +4151,18 @@ const docTemplate = `{
         {
             "description": "Operations about users",
             "name": "user"
+        },
+        {
+            "description": "Pet categories",
+            "name": "category"
+        },
+        {
+            "description": "Pet tags vocabulary",
+            "name": "tag"
+        },
+        {
+            "description": "Adoption applications for pets",
+            "name": "adoption"
         }
     ]
 }`
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS pet_waitlist
+(
+    id SERIAL PRIMARY KEY,
+    pet_id INT NOT NULL REFERENCES pets(id),
+    username VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    notified_at TIMESTAMP,
+    UNIQUE (pet_id, username)
+);
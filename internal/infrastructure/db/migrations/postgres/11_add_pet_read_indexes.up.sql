@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS pet_photos_pet_id_idx ON pet_photos (pet_id, id);
+CREATE INDEX IF NOT EXISTS tag_pets_pet_id_idx ON tag_pets (pet_id, id);
+CREATE INDEX IF NOT EXISTS tag_pets_tag_id_idx ON tag_pets (tag_id);
+CREATE INDEX IF NOT EXISTS pets_status_idx ON pets (status);
@@ -0,0 +1,5 @@
+-- раньше удаление заказа записывало статус deleted, теперь заказ отменяется
+UPDATE orders SET status = 'cancelled' WHERE status = 'deleted';
+
+-- заказ завершен, только когда доставлен
+UPDATE orders SET complete = (status = 'delivered');
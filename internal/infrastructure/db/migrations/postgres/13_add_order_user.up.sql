@@ -0,0 +1,4 @@
+-- заказы, оформленные до этой миграции, остаются без владельца и видны только администраторам
+ALTER TABLE orders ADD COLUMN user_id INT REFERENCES users(id);
+
+CREATE INDEX IF NOT EXISTS orders_user_id_idx ON orders (user_id, id);
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS accessories
+(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    price BIGINT NOT NULL,
+    stock INT NOT NULL DEFAULT 0
+);
+
+INSERT INTO accessories (name, price, stock) VALUES
+('Leash', 1500, 50),
+('Collar', 900, 50),
+('Food bowl', 700, 100),
+('Pet carrier', 4500, 10);
+
+-- у пользователя одна корзина; питомец попадает в нее один раз, аксессуар - одной строкой с количеством
+CREATE TABLE IF NOT EXISTS cart_items
+(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    pet_id INT REFERENCES pets(id),
+    accessory_id INT REFERENCES accessories(id),
+    quantity INT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, pet_id),
+    UNIQUE (user_id, accessory_id)
+);
+
+-- позиции заказа хранят название и цену на момент оформления
+CREATE TABLE IF NOT EXISTS order_items
+(
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES orders(id),
+    pet_id INT REFERENCES pets(id),
+    accessory_id INT REFERENCES accessories(id),
+    name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    unit_price BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items (order_id, id);
+
+-- сумма заказа из корзины; у заказов одного питомца ее нет
+ALTER TABLE orders ADD COLUMN total BIGINT;
@@ -0,0 +1,2 @@
+-- история остается после окончательного удаления питомца, поэтому pet_id больше не ссылается на pets
+ALTER TABLE pet_history DROP CONSTRAINT IF EXISTS pet_history_pet_id_fkey;
@@ -0,0 +1,2 @@
+-- заявки остаются после окончательного удаления питомца, поэтому pet_id больше не ссылается на pets
+ALTER TABLE adoption_applications DROP CONSTRAINT IF EXISTS adoption_applications_pet_id_fkey;
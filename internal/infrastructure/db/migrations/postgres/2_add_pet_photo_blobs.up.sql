@@ -0,0 +1,8 @@
+ALTER TABLE pet_photos ADD COLUMN file_name VARCHAR(255);
+ALTER TABLE pet_photos ADD COLUMN blob_key VARCHAR(255);
+ALTER TABLE pet_photos ADD COLUMN size BIGINT;
+ALTER TABLE pet_photos ADD COLUMN mime_type VARCHAR(255);
+ALTER TABLE pet_photos ADD COLUMN checksum VARCHAR(64);
+ALTER TABLE pet_photos ADD COLUMN created_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS pet_photos_blob_key_idx ON pet_photos (blob_key);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS pet_photo_variants
+(
+    id SERIAL PRIMARY KEY,
+    photo_id INT NOT NULL REFERENCES pet_photos(id),
+    max_size INT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    blob_key VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    mime_type VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS pet_photo_variants_photo_id_max_size_idx ON pet_photo_variants (photo_id, max_size);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS pet_history
+(
+    id SERIAL PRIMARY KEY,
+    pet_id INT NOT NULL REFERENCES pets(id),
+    action VARCHAR(32) NOT NULL,
+    changes TEXT NOT NULL,
+    username VARCHAR(255),
+    request_id VARCHAR(255),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pet_history_pet_id_idx ON pet_history (pet_id, id);
@@ -0,0 +1,6 @@
+ALTER TABLE pets ADD COLUMN breed VARCHAR(100);
+ALTER TABLE pets ADD COLUMN birth_date VARCHAR(10);
+ALTER TABLE pets ADD COLUMN sex VARCHAR(16);
+ALTER TABLE pets ADD COLUMN weight DOUBLE PRECISION;
+ALTER TABLE pets ADD COLUMN description TEXT;
+ALTER TABLE pets ADD COLUMN price BIGINT;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS pet_medical_records
+(
+    id SERIAL PRIMARY KEY,
+    pet_id INT NOT NULL REFERENCES pets(id),
+    kind VARCHAR(32) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    notes TEXT,
+    vet VARCHAR(200),
+    date VARCHAR(10) NOT NULL,
+    due_date VARCHAR(10),
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pet_medical_records_pet_id_idx ON pet_medical_records (pet_id, date);
+CREATE INDEX IF NOT EXISTS pet_medical_records_due_date_idx ON pet_medical_records (kind, due_date);
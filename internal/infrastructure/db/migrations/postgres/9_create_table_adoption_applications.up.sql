@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS adoption_applications
+(
+    id SERIAL PRIMARY KEY,
+    pet_id INT NOT NULL REFERENCES pets(id),
+    username VARCHAR(255) NOT NULL,
+    message TEXT,
+    status VARCHAR(32) NOT NULL,
+    review_notes TEXT,
+    reviewed_by VARCHAR(255),
+    reviewed_at TIMESTAMP,
+    order_id INT REFERENCES orders(id),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS adoption_applications_pet_id_idx ON adoption_applications (pet_id, status);
+CREATE INDEX IF NOT EXISTS adoption_applications_username_idx ON adoption_applications (username, status);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS pet_waitlist
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pet_id INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    notified_at DATETIME,
+    FOREIGN KEY (pet_id) REFERENCES pets(id),
+    UNIQUE (pet_id, username)
+);
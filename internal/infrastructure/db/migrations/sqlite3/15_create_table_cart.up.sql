@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS accessories
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    stock INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO accessories (name, price, stock) VALUES
+('Leash', 1500, 50),
+('Collar', 900, 50),
+('Food bowl', 700, 100),
+('Pet carrier', 4500, 10);
+
+-- у пользователя одна корзина; питомец попадает в нее один раз, аксессуар - одной строкой с количеством
+CREATE TABLE IF NOT EXISTS cart_items
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    pet_id INTEGER,
+    accessory_id INTEGER,
+    quantity INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (pet_id) REFERENCES pets(id),
+    FOREIGN KEY (accessory_id) REFERENCES accessories(id),
+    UNIQUE (user_id, pet_id),
+    UNIQUE (user_id, accessory_id)
+);
+
+-- позиции заказа хранят название и цену на момент оформления
+CREATE TABLE IF NOT EXISTS order_items
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL,
+    pet_id INTEGER,
+    accessory_id INTEGER,
+    name TEXT NOT NULL,
+    quantity INTEGER NOT NULL,
+    unit_price INTEGER NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id),
+    FOREIGN KEY (pet_id) REFERENCES pets(id),
+    FOREIGN KEY (accessory_id) REFERENCES accessories(id)
+);
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items (order_id, id);
+
+-- сумма заказа из корзины; у заказов одного питомца ее нет
+ALTER TABLE orders ADD COLUMN total INTEGER;
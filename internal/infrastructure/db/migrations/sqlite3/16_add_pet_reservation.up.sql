@@ -0,0 +1,11 @@
+-- заказ, который забронировал питомца: отмена или доставка другого заказа на того же питомца его не трогает
+ALTER TABLE pets ADD COLUMN reserved_by_order INTEGER REFERENCES orders(id);
+
+-- брони, сделанные до этой миграции, отдаем последнему незавершенному заказу на питомца
+UPDATE pets SET reserved_by_order = (
+    SELECT MAX(orders.id) FROM orders
+    WHERE orders.pet_id = pets.id AND orders.status IN ('placed', 'approved', 'shipped')
+)
+WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS pets_reserved_by_order_idx ON pets (reserved_by_order);
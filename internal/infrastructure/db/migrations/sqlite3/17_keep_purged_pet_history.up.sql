@@ -0,0 +1,21 @@
+-- история остается после окончательного удаления питомца, поэтому pet_id больше не ссылается на pets;
+-- SQLite не умеет удалять ограничения, таблица пересоздается
+CREATE TABLE pet_history_new
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pet_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    username TEXT,
+    request_id TEXT,
+    created_at DATETIME NOT NULL
+);
+
+INSERT INTO pet_history_new (id, pet_id, action, changes, username, request_id, created_at)
+SELECT id, pet_id, action, changes, username, request_id, created_at FROM pet_history;
+
+DROP TABLE pet_history;
+
+ALTER TABLE pet_history_new RENAME TO pet_history;
+
+CREATE INDEX IF NOT EXISTS pet_history_pet_id_idx ON pet_history (pet_id, id);
@@ -0,0 +1,26 @@
+-- заявки остаются после окончательного удаления питомца, поэтому pet_id больше не ссылается на pets;
+-- SQLite не умеет удалять ограничения, таблица пересоздается
+CREATE TABLE adoption_applications_new
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pet_id INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    message TEXT,
+    status TEXT NOT NULL,
+    review_notes TEXT,
+    reviewed_by TEXT,
+    reviewed_at DATETIME,
+    order_id INTEGER,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+
+INSERT INTO adoption_applications_new (id, pet_id, username, message, status, review_notes, reviewed_by, reviewed_at, order_id, created_at)
+SELECT id, pet_id, username, message, status, review_notes, reviewed_by, reviewed_at, order_id, created_at FROM adoption_applications;
+
+DROP TABLE adoption_applications;
+
+ALTER TABLE adoption_applications_new RENAME TO adoption_applications;
+
+CREATE INDEX IF NOT EXISTS adoption_applications_pet_id_idx ON adoption_applications (pet_id, status);
+CREATE INDEX IF NOT EXISTS adoption_applications_username_idx ON adoption_applications (username, status);
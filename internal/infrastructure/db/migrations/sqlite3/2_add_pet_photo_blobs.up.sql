@@ -0,0 +1,8 @@
+ALTER TABLE pet_photos ADD COLUMN file_name TEXT;
+ALTER TABLE pet_photos ADD COLUMN blob_key TEXT;
+ALTER TABLE pet_photos ADD COLUMN size INTEGER;
+ALTER TABLE pet_photos ADD COLUMN mime_type TEXT;
+ALTER TABLE pet_photos ADD COLUMN checksum TEXT;
+ALTER TABLE pet_photos ADD COLUMN created_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS pet_photos_blob_key_idx ON pet_photos (blob_key);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS pet_photo_variants
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    photo_id INTEGER NOT NULL,
+    max_size INTEGER NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    blob_key TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    mime_type TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    FOREIGN KEY (photo_id) REFERENCES pet_photos(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS pet_photo_variants_photo_id_max_size_idx ON pet_photo_variants (photo_id, max_size);
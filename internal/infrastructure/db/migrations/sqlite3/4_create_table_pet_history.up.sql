@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS pet_history
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pet_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    username TEXT,
+    request_id TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (pet_id) REFERENCES pets(id)
+);
+
+CREATE INDEX IF NOT EXISTS pet_history_pet_id_idx ON pet_history (pet_id, id);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+
+UPDATE users SET role = 'admin' WHERE username = 'admin';
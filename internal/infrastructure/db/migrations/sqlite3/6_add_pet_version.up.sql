@@ -0,0 +1 @@
+ALTER TABLE pets ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+ALTER TABLE pets ADD COLUMN breed TEXT;
+ALTER TABLE pets ADD COLUMN birth_date TEXT;
+ALTER TABLE pets ADD COLUMN sex TEXT;
+ALTER TABLE pets ADD COLUMN weight REAL;
+ALTER TABLE pets ADD COLUMN description TEXT;
+ALTER TABLE pets ADD COLUMN price INTEGER;
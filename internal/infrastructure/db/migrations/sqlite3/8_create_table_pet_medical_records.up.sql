@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS pet_medical_records
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pet_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    title TEXT NOT NULL,
+    notes TEXT,
+    vet TEXT,
+    date TEXT NOT NULL,
+    due_date TEXT,
+    created_by TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (pet_id) REFERENCES pets(id)
+);
+
+CREATE INDEX IF NOT EXISTS pet_medical_records_pet_id_idx ON pet_medical_records (pet_id, date);
+CREATE INDEX IF NOT EXISTS pet_medical_records_due_date_idx ON pet_medical_records (kind, due_date);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS adoption_applications
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pet_id INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    message TEXT,
+    status TEXT NOT NULL,
+    review_notes TEXT,
+    reviewed_by TEXT,
+    reviewed_at DATETIME,
+    order_id INTEGER,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (pet_id) REFERENCES pets(id),
+    FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+
+CREATE INDEX IF NOT EXISTS adoption_applications_pet_id_idx ON adoption_applications (pet_id, status);
+CREATE INDEX IF NOT EXISTS adoption_applications_username_idx ON adoption_applications (username, status);
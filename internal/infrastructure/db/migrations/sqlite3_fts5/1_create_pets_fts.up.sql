@@ -0,0 +1,74 @@
+-- Полнотекстовый индекс питомцев: имя, имя категории и имена тегов.
+-- rowid индекса совпадает с pets.id, строки пересобираются триггерами.
+CREATE VIRTUAL TABLE IF NOT EXISTS pets_fts USING fts5
+(
+    name,
+    category,
+    tags,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIEW IF NOT EXISTS pets_fts_source AS
+SELECT
+    pets.id AS id,
+    pets.name AS name,
+    COALESCE(categories.name, '') AS category,
+    COALESCE((
+        SELECT group_concat(tags.name, ' ')
+        FROM tag_pets
+        JOIN tags ON tag_pets.tag_id = tags.id
+        WHERE tag_pets.pet_id = pets.id
+    ), '') AS tags
+FROM pets
+LEFT JOIN categories ON pets.category_id = categories.id;
+
+INSERT INTO pets_fts (rowid, name, category, tags)
+SELECT id, name, category, tags FROM pets_fts_source;
+
+CREATE TRIGGER IF NOT EXISTS pets_fts_pets_insert AFTER INSERT ON pets
+BEGIN
+    INSERT INTO pets_fts (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pets_fts_source WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS pets_fts_pets_update AFTER UPDATE OF name, category_id ON pets
+BEGIN
+    DELETE FROM pets_fts WHERE rowid = OLD.id;
+    INSERT INTO pets_fts (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pets_fts_source WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS pets_fts_pets_delete AFTER DELETE ON pets
+BEGIN
+    DELETE FROM pets_fts WHERE rowid = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS pets_fts_categories_update AFTER UPDATE OF name ON categories
+BEGIN
+    DELETE FROM pets_fts WHERE rowid IN (SELECT id FROM pets WHERE category_id = NEW.id);
+    INSERT INTO pets_fts (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pets_fts_source
+    WHERE id IN (SELECT id FROM pets WHERE category_id = NEW.id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS pets_fts_tags_update AFTER UPDATE OF name ON tags
+BEGIN
+    DELETE FROM pets_fts WHERE rowid IN (SELECT pet_id FROM tag_pets WHERE tag_id = NEW.id);
+    INSERT INTO pets_fts (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pets_fts_source
+    WHERE id IN (SELECT pet_id FROM tag_pets WHERE tag_id = NEW.id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS pets_fts_tag_pets_insert AFTER INSERT ON tag_pets
+BEGIN
+    DELETE FROM pets_fts WHERE rowid = NEW.pet_id;
+    INSERT INTO pets_fts (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pets_fts_source WHERE id = NEW.pet_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS pets_fts_tag_pets_delete AFTER DELETE ON tag_pets
+BEGIN
+    DELETE FROM pets_fts WHERE rowid = OLD.pet_id;
+    INSERT INTO pets_fts (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pets_fts_source WHERE id = OLD.pet_id;
+END;
@@ -2,6 +2,8 @@ package db
 
 import (
 	"database/sql"
+	"errors"
+	"log"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
@@ -9,12 +11,19 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrFTS5Required - база уже с полнотекстовым индексом, а SQLite собран без FTS5:
+// триггеры индекса не дали бы записать ни одного питомца.
+var ErrFTS5Required = errors.New("database has the pet search index, but sqlite3 is built without FTS5: build with -tags sqlite_fts5")
+
 type DataBaseSqlite struct {
 	DB *sql.DB
 }
 
 func NewDataBaseSqlite(path string) (*DataBaseSqlite, error) {
-	db, err := sql.Open("sqlite3", path)
+	// транзакции сразу берут блокировку записи (BEGIN IMMEDIATE), иначе из двух транзакций,
+	// прочитавших одну строку, вторая не смогла бы записать и сразу получила бы SQLITE_BUSY;
+	// busy_timeout - сколько миллисекунд ждать, пока блокировку отпустят
+	db, err := sql.Open("sqlite3", path+"?_txlock=immediate&_busy_timeout=5000")
 	if err != nil {
 		return nil, err
 	}
@@ -48,5 +57,63 @@ func (d *DataBaseSqlite) Migrate() error {
 		return err
 	}
 
+	return d.migrateFTS5()
+}
+
+// migrateFTS5 создает полнотекстовый индекс питомцев. FTS5 есть в SQLite,
+// только если драйвер собран с тегом sqlite_fts5, поэтому эти миграции
+// отделены от основных и ведут свою таблицу версий. Сборка без FTS5 не
+// запускается на базе, где индекс уже создан (ErrFTS5Required).
+func (d *DataBaseSqlite) migrateFTS5() error {
+	enabled, err := d.FTS5Enabled()
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		var indexed bool
+		err = d.DB.QueryRow(
+			"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE name IN ('schema_migrations_fts5', 'pets_fts'))",
+		).Scan(&indexed)
+		if err != nil {
+			return err
+		}
+
+		if indexed {
+			return ErrFTS5Required
+		}
+
+		log.Println("sqlite3 is built without FTS5, pet search is disabled")
+		return nil
+	}
+
+	driver, err := sqlite3.WithInstance(d.DB, &sqlite3.Config{
+		MigrationsTable: "schema_migrations_fts5",
+	})
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(
+		"file://internal/infrastructure/db/migrations/sqlite3_fts5",
+		"sqlite3",
+		driver)
+	if err != nil {
+		return err
+	}
+
+	err = m.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+
 	return nil
 }
+
+// FTS5Enabled сообщает, собран ли SQLite с поддержкой FTS5.
+func (d *DataBaseSqlite) FTS5Enabled() (bool, error) {
+	var enabled bool
+	err := d.DB.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled)
+
+	return enabled, err
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Conn - общее у *sql.DB и *sql.Tx: репозиторий выполняет запросы через него
+// и в транзакции, и без нее.
+type Conn interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// txState - транзакция, начатая InTx, и счетчик точек сохранения вложенных BeginTx.
+type txState struct {
+	tx         *sql.Tx
+	savepoints int
+}
+
+// Tx - транзакция репозитория. Если ctx уже несет транзакцию InTx, Tx выполняет
+// запросы в ней и ограничен точкой сохранения: Rollback откатывает только свои
+// изменения, а Commit оставляет их до фиксации внешней транзакции.
+type Tx struct {
+	*sql.Tx
+	savepoint string
+	done      bool
+}
+
+// BeginTx начинает транзакцию в sqlDB или точку сохранения в транзакции из ctx.
+func BeginTx(ctx context.Context, sqlDB *sql.DB) (*Tx, error) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		tx, err := sqlDB.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Tx{Tx: tx}, nil
+	}
+
+	state.savepoints++
+	savepoint := fmt.Sprintf("sp%d", state.savepoints)
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: state.tx, savepoint: savepoint}, nil
+}
+
+func (tx *Tx) Commit() error {
+	if tx.savepoint == "" {
+		return tx.Tx.Commit()
+	}
+
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+
+	_, err := tx.Tx.Exec("RELEASE SAVEPOINT " + tx.savepoint)
+
+	return err
+}
+
+// Rollback после Commit, как у sql.Tx, возвращает sql.ErrTxDone и ничего не откатывает,
+// поэтому его можно отложить через defer.
+func (tx *Tx) Rollback() error {
+	if tx.savepoint == "" {
+		return tx.Tx.Rollback()
+	}
+
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+
+	_, err := tx.Tx.Exec("ROLLBACK TO SAVEPOINT " + tx.savepoint)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Tx.Exec("RELEASE SAVEPOINT " + tx.savepoint)
+
+	return err
+}
+
+// InTx выполняет fn в одной транзакции: запросы репозиториев с контекстом fn
+// (BeginTx, ConnFrom) идут в нее, поэтому изменения разных репозиториев
+// фиксируются вместе. Ошибка fn откатывает все.
+func InTx(ctx context.Context, sqlDB *sql.DB, fn func(ctx context.Context) error) error {
+	tx, err := BeginTx(ctx, sqlDB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		state = &txState{tx: tx.Tx}
+		ctx = context.WithValue(ctx, txKey{}, state)
+	}
+
+	if err = fn(ctx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConnFrom возвращает транзакцию InTx из ctx или, если ее нет, sqlDB.
+func ConnFrom(ctx context.Context, sqlDB *sql.DB) Conn {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+
+	return sqlDB
+}
@@ -0,0 +1,130 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const keysTable = "idempotency_keys"
+
+// Record - запрос с ключом идемпотентности и сохраненный ответ на него.
+// StatusCode 0 означает, что запрос еще выполняется.
+type Record struct {
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// Store хранит ключи идемпотентности. Ключ уникален в пределах scope
+// (пользователь и маршрут), поэтому разные клиенты могут прислать одинаковые ключи.
+type Store interface {
+	// Reserve занимает ключ под новый запрос. Если ключ уже занят, возвращает
+	// сохраненную запись и false.
+	Reserve(ctx context.Context, scope, key, fingerprint string) (Record, bool, error)
+	// Complete сохраняет ответ на запрос, занявший ключ.
+	Complete(ctx context.Context, scope, key string, record Record) error
+	// Release освобождает ключ, чтобы запрос можно было повторить.
+	Release(ctx context.Context, scope, key string) error
+}
+
+// SQLStore хранит ключи в таблице idempotency_keys. Ключи старше ttl
+// удаляются, и их можно использовать снова.
+type SQLStore struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+func NewSQLStore(db *sql.DB, ttl time.Duration) *SQLStore {
+	return &SQLStore{
+		db:  db,
+		ttl: ttl,
+	}
+}
+
+func (s *SQLStore) Reserve(ctx context.Context, scope, key, fingerprint string) (Record, bool, error) {
+	now := time.Now().UTC()
+
+	_, err := sq.Delete(keysTable).
+		Where(sq.Lt{"created_at": now.Add(-s.ttl)}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	// из одновременных запросов с одним ключом вставка удается только одному
+	res, err := sq.Insert(keysTable).
+		Columns("scope", "idempotency_key", "fingerprint", "created_at").
+		Values(scope, key, fingerprint, now).
+		Suffix("ON CONFLICT (scope, idempotency_key) DO NOTHING").
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	if inserted == 1 {
+		return Record{Fingerprint: fingerprint, CreatedAt: now}, true, nil
+	}
+
+	var record Record
+	var header sql.NullString
+
+	err = sq.Select("fingerprint", "status_code", "header", "body", "created_at").
+		From(keysTable).
+		Where(sq.Eq{"scope": scope, "idempotency_key": key}).
+		RunWith(s.db).
+		QueryRowContext(ctx).
+		Scan(&record.Fingerprint, &record.StatusCode, &header, &record.Body, &record.CreatedAt)
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	if header.Valid {
+		if err = json.Unmarshal([]byte(header.String), &record.Header); err != nil {
+			return Record{}, false, err
+		}
+	}
+
+	return record, false, nil
+}
+
+func (s *SQLStore) Complete(ctx context.Context, scope, key string, record Record) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = sq.Update(keysTable).
+		SetMap(map[string]interface{}{
+			"status_code": record.StatusCode,
+			"header":      string(header),
+			"body":        record.Body,
+		}).
+		Where(sq.Eq{"scope": scope, "idempotency_key": key}).
+		RunWith(s.db).
+		ExecContext(ctx)
+
+	return err
+}
+
+func (s *SQLStore) Release(ctx context.Context, scope, key string) error {
+	_, err := sq.Delete(keysTable).
+		Where(sq.Eq{"scope": scope, "idempotency_key": key}).
+		RunWith(s.db).
+		ExecContext(ctx)
+
+	return err
+}
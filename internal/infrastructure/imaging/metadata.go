@@ -0,0 +1,287 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var errCorruptImage = errors.New("corrupt image")
+
+// AllowedTypes - типы изображений, которые принимаются к загрузке.
+var AllowedTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// Sniff определяет тип по содержимому (а не по имени или заголовкам клиента)
+// и проверяет его по списку разрешенных.
+func Sniff(data []byte) (string, error) {
+	mimeType := http.DetectContentType(data)
+
+	for _, allowed := range AllowedTypes {
+		if mimeType == allowed {
+			return mimeType, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, mimeType)
+}
+
+// StripMetadata удаляет из изображения метаданные (EXIF с GPS-координатами, XMP, IPTC,
+// текстовые комментарии), не перекодируя сами пиксели. Из EXIF остается только
+// ориентация: без нее снимок с телефона показывался бы повернутым.
+func StripMetadata(data []byte, mimeType string) ([]byte, error) {
+	switch mimeType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/gif":
+		// в GIF нет EXIF, а комментарии не несут координат
+		return data, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errCorruptImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	pos := 2
+	for pos < len(data) {
+		if data[pos] != 0xFF {
+			return nil, errCorruptImage
+		}
+
+		// байты-заполнители 0xFF перед маркером
+		for pos+1 < len(data) && data[pos+1] == 0xFF {
+			pos++
+		}
+		if pos+1 >= len(data) {
+			return nil, errCorruptImage
+		}
+
+		marker := data[pos+1]
+
+		switch {
+		case marker == 0xD9:
+			// EOI
+			out.Write(data[pos : pos+2])
+			return out.Bytes(), nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// маркеры без длины
+			out.Write(data[pos : pos+2])
+			pos += 2
+			continue
+		}
+
+		if pos+4 > len(data) {
+			return nil, errCorruptImage
+		}
+
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:pos+4]))
+		if end > len(data) {
+			return nil, errCorruptImage
+		}
+
+		if marker == 0xDA {
+			// SOS: дальше идут сжатые данные, метаданных после них нет
+			out.Write(data[pos:])
+			return out.Bytes(), nil
+		}
+
+		// APP1 - EXIF/XMP, APP13 - IPTC, COM - комментарий
+		switch marker {
+		case 0xE1:
+			if orientation := exifOrientation(data[pos+4 : end]); orientation > 1 {
+				out.Write(orientationSegment(orientation))
+			}
+		case 0xED, 0xFE:
+		default:
+			out.Write(data[pos:end])
+		}
+
+		pos = end
+	}
+
+	return out.Bytes(), nil
+}
+
+const orientationTag = 0x0112
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifOrientation возвращает ориентацию (1-8) из EXIF в сегменте APP1 или 0,
+// если сегмент не EXIF или ориентации в нем нет.
+func exifOrientation(segment []byte) int {
+	if !bytes.HasPrefix(segment, exifHeader) {
+		return 0
+	}
+
+	tiff := segment[len(exifHeader):]
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) != orientationTag {
+			continue
+		}
+
+		// SHORT хранится в начале поля значения
+		orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+		if orientation < 1 || orientation > 8 {
+			return 0
+		}
+
+		return orientation
+	}
+
+	return 0
+}
+
+// orientationSegment строит APP1 с EXIF, в котором есть только ориентация.
+func orientationSegment(orientation int) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	// IFD0 из одной записи: тег, тип SHORT, одно значение, следующего IFD нет
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, uint16(orientationTag))
+	binary.Write(&tiff, binary.BigEndian, uint16(3))
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, uint16(orientation))
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	segment = append(segment, exifHeader...)
+	segment = append(segment, tiff.Bytes()...)
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(segment)-2))
+
+	return segment
+}
+
+// jpegOrientation ищет ориентацию EXIF в заголовках JPEG до начала сжатых данных.
+func jpegOrientation(data []byte) int {
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:pos+4]))
+		if end > len(data) {
+			break
+		}
+
+		if marker == 0xE1 {
+			if orientation := exifOrientation(data[pos+4 : end]); orientation > 0 {
+				return orientation
+			}
+		}
+
+		pos = end
+	}
+
+	return 1
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errCorruptImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, errCorruptImage
+		}
+
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+
+		// длина + тип + данные + CRC
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errCorruptImage
+		}
+
+		switch chunkType {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out.Write(data[pos:end])
+		}
+
+		pos = end
+
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// SanitizeFileName оставляет от присланного клиентом имени только базовое имя
+// из безопасных символов, чтобы его можно было хранить и отдавать в заголовках.
+func SanitizeFileName(name string) string {
+	// клиенты на Windows присылают путь с обратными слешами
+	if i := bytes.LastIndexAny([]byte(name), `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var b bytes.Buffer
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('_')
+		}
+	}
+
+	sanitized := bytes.Trim(b.Bytes(), "._")
+	if len(sanitized) > 100 {
+		sanitized = sanitized[len(sanitized)-100:]
+	}
+
+	if len(sanitized) == 0 {
+		return "photo"
+	}
+
+	return string(sanitized)
+}
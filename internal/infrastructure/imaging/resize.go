@@ -0,0 +1,244 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultVariantSizes возвращает размеры (по большей стороне) уменьшенных копий
+// изображений. Каждый вызов возвращает новый срез, чтобы его нельзя было изменить для всех.
+func DefaultVariantSizes() []int {
+	return []int{128, 512}
+}
+
+// MaxPixels - предел площади изображения (ширина x высота). Декодированная картинка
+// занимает 4 байта на пиксель, и маленький файл с огромными размерами без этой
+// проверки исчерпал бы память при построении уменьшенных копий.
+const MaxPixels = 50_000_000
+
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+var ErrTooManyPixels = errors.New("image dimensions are too large")
+
+// ParseSizes разбирает список размеров вида "128,512" и возвращает их по возрастанию
+// без повторов: на один размер хранится одна копия. Пустая строка дает размеры по умолчанию.
+func ParseSizes(value string) ([]int, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultVariantSizes(), nil
+	}
+
+	var sizes []int
+	for _, part := range strings.Split(value, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid image variant size %q", part)
+		}
+		sizes = append(sizes, size)
+	}
+
+	sort.Ints(sizes)
+
+	unique := sizes[:1]
+	for _, size := range sizes[1:] {
+		if size != unique[len(unique)-1] {
+			unique = append(unique, size)
+		}
+	}
+
+	return unique, nil
+}
+
+// CheckDimensions читает только заголовок изображения и проверяет, что его площадь
+// не больше MaxPixels.
+func CheckDimensions(r io.Reader, mimeType string) error {
+	var (
+		config image.Config
+		err    error
+	)
+
+	switch mimeType {
+	case "image/jpeg":
+		config, err = jpeg.DecodeConfig(r)
+	case "image/png":
+		config, err = png.DecodeConfig(r)
+	case "image/gif":
+		config, err = gif.DecodeConfig(r)
+	default:
+		return ErrUnsupportedFormat
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return fmt.Errorf("%w: %dx%d, at most %d pixels", ErrTooManyPixels, config.Width, config.Height, MaxPixels)
+	}
+
+	return nil
+}
+
+// Decode декодирует JPEG, PNG или GIF (первый кадр). JPEG поворачивается по
+// ориентации из EXIF: копии кодируются без метаданных и должны выглядеть как оригинал.
+func Decode(r io.Reader, mimeType string) (image.Image, error) {
+	switch mimeType {
+	case "image/jpeg":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		return orient(img, jpegOrientation(data)), nil
+	case "image/png":
+		return png.Decode(r)
+	case "image/gif":
+		return gif.Decode(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// orient поворачивает и отражает изображение так, как его нужно показать при
+// ориентации EXIF orientation (1 - как есть).
+func orient(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// при 5-8 ширина и высота меняются местами
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
+
+// Encode кодирует уменьшенную копию. JPEG остается JPEG, остальное сохраняется в PNG,
+// так как перекодирование в палитру GIF заметно портит картинку.
+func Encode(img image.Image, mimeType string) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if mimeType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), "image/png", nil
+}
+
+// Fit возвращает размеры, вписанные в квадрат maxSize x maxSize с сохранением пропорций.
+func Fit(width, height, maxSize int) (int, int) {
+	if width >= height {
+		h := height * maxSize / width
+		if h < 1 {
+			h = 1
+		}
+		return maxSize, h
+	}
+
+	w := width * maxSize / height
+	if w < 1 {
+		w = 1
+	}
+	return w, maxSize
+}
+
+// Resize уменьшает изображение до width x height усреднением по площади (box filter).
+// Для уменьшения фотографий это дает результат без ступенек и муара.
+func Resize(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+
+	rgba, ok := src.(*image.RGBA)
+	if !ok || bounds.Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	}
+
+	srcW, srcH := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcH / height
+		y1 := (y + 1) * srcH / height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := x * srcW / width
+			x1 := (x + 1) * srcW / width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := sy*rgba.Stride + x0*4
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(rgba.Pix[offset])
+					g += uint64(rgba.Pix[offset+1])
+					b += uint64(rgba.Pix[offset+2])
+					a += uint64(rgba.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,444 @@
+// Package jsonpatch применяет к JSON-документам изменения в форматах
+// JSON Merge Patch (RFC 7386) и JSON Patch (RFC 6902).
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch - патч не разбирается или не применим к документу.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed - операция test не совпала с документом.
+	ErrTestFailed = errors.New("patch test operation failed")
+)
+
+// Patcher - изменение, которое можно применить к JSON-документу.
+type Patcher interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// MergePatch - документ JSON Merge Patch: поля патча заменяют поля документа,
+// null удаляет поле, вложенные объекты сливаются рекурсивно.
+type MergePatch []byte
+
+func (p MergePatch) Apply(doc []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := decode(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergePatch(target, patch))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+// Operation - одна операция JSON Patch.
+type Operation struct {
+	Op    string          `json:"op" example:"add" enums:"add,remove,replace,move,copy,test"`
+	Path  string          `json:"path" example:"/tags/-"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+// Patch - документ JSON Patch, операции применяются по порядку и все вместе:
+// если одна не удалась, документ не меняется.
+type Patch []Operation
+
+// DecodePatch разбирает документ JSON Patch и проверяет обязательные поля операций.
+func DecodePatch(data []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range patch {
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d (%s) has no value", ErrInvalidPatch, i, op.Op)
+			}
+		case "move", "copy":
+			if _, err := parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d (%s): %v", ErrInvalidPatch, i, op.Op, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d has unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+
+		if _, err := parsePointer(op.Path); err != nil {
+			return nil, fmt.Errorf("%w: operation %d (%s): %v", ErrInvalidPatch, i, op.Op, err)
+		}
+	}
+
+	return patch, nil
+}
+
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range p {
+		root, err = op.apply(root)
+		if err != nil {
+			if errors.Is(err, ErrTestFailed) {
+				return nil, fmt.Errorf("%w: operation %d at %q", ErrTestFailed, i, op.Path)
+			}
+			return nil, fmt.Errorf("%w: operation %d (%s %q): %v", ErrInvalidPatch, i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+func (op Operation) apply(root interface{}) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			return add(root, path, value)
+		case "replace":
+			return replace(root, path, value)
+		}
+
+		current, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+
+	case "remove":
+		return remove(root, path)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			return add(root, path, deepCopy(value))
+		}
+
+		if isPrefix(from, path) && len(from) < len(path) {
+			return nil, errors.New("cannot move a value into itself")
+		}
+
+		root, err = remove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	}
+
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// add вставляет значение: в объект - по ключу, в массив - перед индексом или в конец по "-".
+func add(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(root, path, func(parent interface{}, key string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case map[string]interface{}:
+			parent[key] = value
+			return parent, nil
+		case []interface{}:
+			index := len(parent)
+			if key != "-" {
+				var err error
+				index, err = arrayIndex(key, len(parent)+1)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			result := make([]interface{}, 0, len(parent)+1)
+			result = append(result, parent[:index]...)
+			result = append(result, value)
+			return append(result, parent[index:]...), nil
+		}
+
+		return nil, fmt.Errorf("cannot add %q to a scalar value", key)
+	})
+}
+
+func remove(root interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot remove the whole document")
+	}
+
+	return update(root, path, func(parent interface{}, key string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := parent[key]; !ok {
+				return nil, fmt.Errorf("member %q not found", key)
+			}
+			delete(parent, key)
+			return parent, nil
+		case []interface{}:
+			index, err := arrayIndex(key, len(parent))
+			if err != nil {
+				return nil, err
+			}
+
+			result := make([]interface{}, 0, len(parent)-1)
+			result = append(result, parent[:index]...)
+			return append(result, parent[index+1:]...), nil
+		}
+
+		return nil, fmt.Errorf("cannot remove %q from a scalar value", key)
+	})
+}
+
+func replace(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(root, path, func(parent interface{}, key string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := parent[key]; !ok {
+				return nil, fmt.Errorf("member %q not found", key)
+			}
+			parent[key] = value
+			return parent, nil
+		case []interface{}:
+			index, err := arrayIndex(key, len(parent))
+			if err != nil {
+				return nil, err
+			}
+			parent[index] = value
+			return parent, nil
+		}
+
+		return nil, fmt.Errorf("cannot replace %q in a scalar value", key)
+	})
+}
+
+// update спускается по пути до родителя последнего элемента, меняет его через fn
+// и возвращает новый корень: вставка и удаление в массиве создают новый срез.
+func update(node interface{}, path []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+
+	child, err := get(node, path[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = update(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := node.(type) {
+	case map[string]interface{}:
+		node[path[0]] = child
+	case []interface{}:
+		index, _ := arrayIndex(path[0], len(node))
+		node[index] = child
+	}
+
+	return node, nil
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, key := range path {
+		switch current := node.(type) {
+		case map[string]interface{}:
+			value, ok := current[key]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", key)
+			}
+			node = value
+		case []interface{}:
+			index, err := arrayIndex(key, len(current))
+			if err != nil {
+				return nil, err
+			}
+			node = current[index]
+		default:
+			return nil, fmt.Errorf("member %q not found in a scalar value", key)
+		}
+	}
+
+	return node, nil
+}
+
+// parsePointer разбирает JSON Pointer (RFC 6901) на ключи.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("pointer %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex разбирает индекс массива, допустимый диапазон - [0, limit).
+func arrayIndex(key string, limit int) (int, error) {
+	if key == "" || (len(key) > 1 && key[0] == '0') || strings.TrimLeft(key, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", key)
+	}
+
+	index, err := strconv.Atoi(key)
+	if err != nil || index >= limit {
+		return 0, fmt.Errorf("array index %q out of range", key)
+	}
+
+	return index, nil
+}
+
+func isPrefix(prefix []string, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equal сравнивает значения по правилам JSON: числа - по значению, объекты - без учета порядка ключей.
+func equal(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	default:
+		return a == b
+	}
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			result[key] = deepCopy(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = deepCopy(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// decode разбирает JSON, сохраняя числа как json.Number, чтобы не терять точность.
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
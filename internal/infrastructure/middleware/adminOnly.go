@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"app/internal/infrastructure/responder"
+	"app/internal/models"
+	"errors"
+	"net/http"
+)
+
+// AdminOnly пропускает только запросы с ролью admin в JWT.
+// Подключается после Verifier и Authenticator.
+func AdminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if RoleFromContext(r.Context()) != models.UserRoleAdmin {
+			responder.NewResponder().ErrorForbidden(w, errors.New("admin role required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
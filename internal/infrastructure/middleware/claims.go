@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-chi/jwtauth"
+)
+
+// UserNameFromContext возвращает имя пользователя из JWT запроса
+// или пустую строку, если токена нет.
+func UserNameFromContext(ctx context.Context) string {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return ""
+	}
+
+	userName, _ := claims["username"].(string)
+
+	return userName
+}
+
+// RoleFromContext возвращает роль пользователя из JWT запроса. Токены,
+// выданные до появления ролей, роли не содержат.
+func RoleFromContext(ctx context.Context) string {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return ""
+	}
+
+	role, _ := claims["role"].(string)
+
+	return role
+}
+
+// UserIDFromContext возвращает ID пользователя из subject JWT запроса или 0,
+// если токена нет или он выдан до того, как в токен стали записывать ID.
+func UserIDFromContext(ctx context.Context) int {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return 0
+	}
+
+	subject, _ := claims["sub"].(string)
+	id, _ := strconv.Atoi(subject)
+
+	return id
+}
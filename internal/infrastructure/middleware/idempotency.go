@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"app/internal/infrastructure/idempotency"
+	"app/internal/infrastructure/responder"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+const (
+	// IdempotencyKeyHeader - заголовок, которым клиент помечает повторы одного запроса.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	maxIdempotencyKeyLength = 255
+
+	// maxIdempotentBodySize - ограничение тела запроса с ключом: тело читается целиком ради отпечатка.
+	maxIdempotentBodySize = 1 << 20
+)
+
+// Idempotency повторяет сохраненный ответ на запрос с уже использованным заголовком
+// Idempotency-Key вместо того, чтобы выполнить запрос еще раз. Ключ с другим телом
+// запроса отклоняется (422), ключ запроса, который еще выполняется, - тоже (409).
+// Сохраняются только успешные (2xx) ответы: после любого другого ключ освобождается
+// и запрос можно повторить. Запросы без заголовка проходят как есть.
+// Ключи хранятся по пользователю из JWT, поэтому middleware подключается только
+// к маршрутам с JWT и после Verifier: у анонимных запросов ключи были бы общими.
+func Idempotency(store idempotency.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			respond := responder.NewResponder()
+
+			if len(key) > maxIdempotencyKeyLength {
+				respond.ErrorBadRequest(w, fmt.Errorf("%s is longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				respond.ErrorRequestEntityTooLarge(w, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := fmt.Sprintf("%d %s %s", UserIDFromContext(r.Context()), r.Method, r.URL.Path)
+
+			fp := fingerprint(r, body)
+
+			record, reserved, err := store.Reserve(r.Context(), scope, key, fp)
+			if err != nil {
+				respond.ErrorServiceUnavailable(w, err)
+				return
+			}
+
+			if !reserved {
+				switch {
+				case record.Fingerprint != fp:
+					respond.ErrorUnprocessableEntity(w, fmt.Errorf("%s was already used with a different request", IdempotencyKeyHeader))
+				case record.StatusCode == 0:
+					respond.ErrorConflict(w, errors.New("a request with this idempotency key is still in progress"))
+				default:
+					replay(w, record)
+				}
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w}
+
+			// если обработчик упал, ключ освобождается, иначе повтор навсегда получал бы 409
+			defer func() {
+				if p := recover(); p != nil {
+					release(store, scope, key)
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.status() < http.StatusOK || rec.status() >= http.StatusMultipleChoices {
+				release(store, scope, key)
+				return
+			}
+
+			// клиент мог уже отключиться, но ответ нужно сохранить для его повтора
+			err = store.Complete(context.Background(), scope, key, idempotency.Record{
+				StatusCode: rec.status(),
+				Header:     rec.Header().Clone(),
+				Body:       rec.body.Bytes(),
+			})
+			if err != nil {
+				// ответ уже отправлен; без сохраненного ответа повтор получит 409, пока ключ не истечет
+				log.Printf("idempotency key %q: save response: %v", key, err)
+			}
+		})
+	}
+}
+
+// fingerprint - отпечаток запроса: метод, путь с параметрами и тело.
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", r.Method, r.URL.RequestURI())
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replay отправляет сохраненный ответ и помечает его заголовком Idempotent-Replayed.
+func replay(w http.ResponseWriter, record idempotency.Record) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.StatusCode)
+
+	if _, err := w.Write(record.Body); err != nil {
+		log.Printf("response writer error on write: %v", err)
+	}
+}
+
+func release(store idempotency.Store, scope, key string) {
+	if err := store.Release(context.Background(), scope, key); err != nil {
+		log.Printf("idempotency key %q: release: %v", key, err)
+	}
+}
+
+// responseRecorder передает ответ клиенту и запоминает его копию.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if rr.statusCode == 0 {
+		rr.statusCode = statusCode
+	}
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	if rr.statusCode == 0 {
+		rr.statusCode = http.StatusOK
+	}
+	rr.body.Write(p)
+
+	return rr.ResponseWriter.Write(p)
+}
+
+func (rr *responseRecorder) status() int {
+	if rr.statusCode == 0 {
+		return http.StatusOK
+	}
+
+	return rr.statusCode
+}
@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Событие: питомец снова в продаже.
+const EventPetAvailable = "pet.available"
+
+// sendTimeout - ограничение времени отправки одного сообщения в один канал.
+const sendTimeout = 10 * time.Second
+
+// Message - уведомление пользователю. Канал сам выбирает, что ему нужно:
+// письмо - адрес и текст, webhook - событие и данные.
+type Message struct {
+	Event    string      `json:"event"`
+	UserName string      `json:"username"`
+	Email    string      `json:"email,omitempty"`
+	Subject  string      `json:"subject"`
+	Text     string      `json:"text"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// Sink - канал доставки уведомлений: лог, webhook, почта.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config - настройки каналов. Sinks - список через запятую: log, webhook, smtp.
+type Config struct {
+	Sinks      string
+	WebhookURL string
+	SMTPAddr   string
+	SMTPFrom   string
+}
+
+// NewSinks создает каналы по настройкам. Без списка уведомления только пишутся в лог.
+func NewSinks(cfg Config) ([]Sink, error) {
+	names := strings.TrimSpace(cfg.Sinks)
+	if names == "" {
+		names = "log"
+	}
+
+	var sinks []Sink
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "log":
+			sinks = append(sinks, NewLogSink())
+		case "webhook":
+			if cfg.WebhookURL == "" {
+				return nil, fmt.Errorf("webhook notification sink requires a URL")
+			}
+			sinks = append(sinks, NewWebhookSink(cfg.WebhookURL))
+		case "smtp":
+			if cfg.SMTPAddr == "" || cfg.SMTPFrom == "" {
+				return nil, fmt.Errorf("smtp notification sink requires an address and a sender")
+			}
+			sinks = append(sinks, NewSMTPSink(cfg.SMTPAddr, cfg.SMTPFrom))
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown notification sink %q", name)
+		}
+	}
+
+	return sinks, nil
+}
+
+// Dispatcher рассылает уведомления во все каналы в фоне, чтобы медленный
+// webhook или почтовый сервер не задерживали запрос, который изменил питомца.
+type Dispatcher struct {
+	sinks []Sink
+	queue chan Message
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+func NewDispatcher(sinks []Sink, queueSize int) *Dispatcher {
+	d := &Dispatcher{
+		sinks: sinks,
+		queue: make(chan Message, queueSize),
+	}
+
+	d.wg.Add(1)
+	go d.run()
+
+	return d
+}
+
+// Notify ставит уведомление в очередь. Если очередь переполнена, уведомление
+// теряется (с записью в лог): изменение питомца важнее доставки.
+func (d *Dispatcher) Notify(msg Message) {
+	select {
+	case d.queue <- msg:
+	default:
+		log.Printf("notify: queue is full, %s for %s dropped", msg.Event, msg.UserName)
+	}
+}
+
+// Close дожидается отправки уже поставленных в очередь уведомлений.
+func (d *Dispatcher) Close() {
+	d.once.Do(func() {
+		close(d.queue)
+	})
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+
+	for msg := range d.queue {
+		for _, sink := range d.sinks {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			err := sink.Send(ctx, msg)
+			cancel()
+			if err != nil {
+				log.Printf("notify: %s: send %s to %s: %v", sink.Name(), msg.Event, msg.UserName, err)
+			}
+		}
+	}
+}
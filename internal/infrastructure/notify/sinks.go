@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+)
+
+// LogSink пишет уведомления в лог сервера.
+type LogSink struct{}
+
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Send(ctx context.Context, msg Message) error {
+	log.Printf("notify: %s for %s: %s", msg.Event, msg.UserName, msg.Subject)
+	return nil
+}
+
+// WebhookSink отправляет уведомление POST-запросом с JSON-телом.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{},
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+// SMTPSink отправляет письмо через SMTP без авторизации - рассчитан на локальный
+// почтовый сервер или его заглушку (например, MailHog).
+type SMTPSink struct {
+	addr string
+	from string
+}
+
+func NewSMTPSink(addr string, from string) *SMTPSink {
+	return &SMTPSink{
+		addr: addr,
+		from: from,
+	}
+}
+
+func (s *SMTPSink) Name() string {
+	return "smtp"
+}
+
+// Send пропускает пользователей без адреса: письмо им отправить некуда.
+func (s *SMTPSink) Send(ctx context.Context, msg Message) error {
+	if msg.Email == "" {
+		return nil
+	}
+
+	// адреса попадают в заголовки, поэтому переводы строк в них недопустимы
+	if strings.ContainsAny(msg.Email+s.from, "\r\n") {
+		return fmt.Errorf("invalid email address %q", msg.Email)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", s.from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.Email)
+	fmt.Fprintf(&body, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Subject))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	body.WriteString(msg.Text)
+	body.WriteString("\r\n")
+
+	return s.sendMail(ctx, msg.Email, body.String())
+}
+
+// sendMail - smtp.SendMail с учетом ctx: без него зависший сервер задержал бы всю очередь.
+func (s *SMTPSink) sendMail(ctx context.Context, to string, body string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err = client.Mail(s.from); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
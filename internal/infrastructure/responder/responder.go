@@ -15,6 +15,13 @@ type Response struct {
 type Responder interface {
 	ErrorBadRequest(w http.ResponseWriter, err error)
 	ErrorNotFound(w http.ResponseWriter, err error)
+	ErrorForbidden(w http.ResponseWriter, err error)
+	ErrorConflict(w http.ResponseWriter, err error)
+	ErrorPreconditionFailed(w http.ResponseWriter, err error)
+	ErrorRequestEntityTooLarge(w http.ResponseWriter, err error)
+	ErrorUnsupportedMediaType(w http.ResponseWriter, err error)
+	ErrorUnprocessableEntity(w http.ResponseWriter, err error)
+	ErrorServiceUnavailable(w http.ResponseWriter, err error)
 	Success(w http.ResponseWriter, message string)
 }
 
@@ -56,6 +63,118 @@ func (r *Respond) ErrorNotFound(w http.ResponseWriter, err error) {
 	}
 }
 
+func (r *Respond) ErrorForbidden(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(Response{
+		Code:    http.StatusForbidden,
+		Type:    "unknown",
+		Message: err.Error(),
+	}); err != nil {
+		log.Printf("response writer error on write: %v", err.Error())
+	}
+}
+
+func (r *Respond) ErrorConflict(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(Response{
+		Code:    http.StatusConflict,
+		Type:    "unknown",
+		Message: err.Error(),
+	}); err != nil {
+		log.Printf("response writer error on write: %v", err.Error())
+	}
+}
+
+func (r *Respond) ErrorPreconditionFailed(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusPreconditionFailed)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(Response{
+		Code:    http.StatusPreconditionFailed,
+		Type:    "unknown",
+		Message: err.Error(),
+	}); err != nil {
+		log.Printf("response writer error on write: %v", err.Error())
+	}
+}
+
+func (r *Respond) ErrorRequestEntityTooLarge(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(Response{
+		Code:    http.StatusRequestEntityTooLarge,
+		Type:    "unknown",
+		Message: err.Error(),
+	}); err != nil {
+		log.Printf("response writer error on write: %v", err.Error())
+	}
+}
+
+func (r *Respond) ErrorUnsupportedMediaType(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusUnsupportedMediaType)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(Response{
+		Code:    http.StatusUnsupportedMediaType,
+		Type:    "unknown",
+		Message: err.Error(),
+	}); err != nil {
+		log.Printf("response writer error on write: %v", err.Error())
+	}
+}
+
+func (r *Respond) ErrorUnprocessableEntity(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(Response{
+		Code:    http.StatusUnprocessableEntity,
+		Type:    "unknown",
+		Message: err.Error(),
+	}); err != nil {
+		log.Printf("response writer error on write: %v", err.Error())
+	}
+}
+
+func (r *Respond) ErrorServiceUnavailable(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(Response{
+		Code:    http.StatusServiceUnavailable,
+		Type:    "unknown",
+		Message: err.Error(),
+	}); err != nil {
+		log.Printf("response writer error on write: %v", err.Error())
+	}
+}
+
 func (r *Respond) Success(w http.ResponseWriter, message string) {
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
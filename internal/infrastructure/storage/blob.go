@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore хранит содержимое загруженных файлов по ключу.
+// Реализации: локальная файловая система, в дальнейшем S3-совместимое хранилище.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (*Blob, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Blob - открытое на чтение содержимое из хранилища.
+type Blob struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+// ContentKey строит ключ, адресуемый по содержимому (sha256 в hex).
+// Одинаковые файлы получают одинаковый ключ и хранятся один раз.
+func ContentKey(checksum string) string {
+	if len(checksum) < 4 {
+		return "sha256/" + checksum
+	}
+
+	return "sha256/" + checksum[:2] + "/" + checksum[2:4] + "/" + checksum
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Статусы заявки на усыновление питомца. Заявка рассматривается один раз:
+// из submitted она переходит в approved, rejected или withdrawn (отозвана заявителем).
+const (
+	AdoptionStatusSubmitted = "submitted"
+	AdoptionStatusApproved  = "approved"
+	AdoptionStatusRejected  = "rejected"
+	AdoptionStatusWithdrawn = "withdrawn"
+)
+
+// AdoptionApplication - заявка пользователя на питомца. При одобрении питомец
+// бронируется (pending), а для заявителя создается заказ.
+type AdoptionApplication struct {
+	ID          int        `json:"id" example:"1" readonly:"true"`
+	PetID       int        `json:"petId" example:"1"`
+	UserName    string     `json:"username" example:"admin" readonly:"true"`
+	Message     string     `json:"message,omitempty" example:"We have a big garden" maxLength:"4000"`
+	Status      string     `json:"status" example:"submitted" enums:"submitted,approved,rejected,withdrawn" readonly:"true"`
+	ReviewNotes string     `json:"reviewNotes,omitempty" example:"Home visit passed" readonly:"true"`
+	ReviewedBy  string     `json:"reviewedBy,omitempty" example:"admin" readonly:"true"`
+	ReviewedAt  *time.Time `json:"reviewedAt,omitempty" readonly:"true"`
+	OrderID     int        `json:"orderId,omitempty" example:"1" readonly:"true"`
+	CreatedAt   time.Time  `json:"createdAt" readonly:"true"`
+}
+
+// AdoptionReview - решение сотрудника по заявке.
+type AdoptionReview struct {
+	Notes string `json:"notes" example:"Home visit passed" maxLength:"4000"`
+}
+
+// AdoptionFilter - фильтр списка заявок, пустые поля не ограничивают выборку.
+type AdoptionFilter struct {
+	PetID    int
+	UserName string
+	Status   string
+}
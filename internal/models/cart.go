@@ -0,0 +1,45 @@
+package models
+
+// Accessory - товар для питомцев из каталога магазина. Цены, как и у питомцев, в
+// минимальных единицах валюты (центах).
+type Accessory struct {
+	ID    int    `json:"id" example:"1"`
+	Name  string `json:"name" example:"Leash"`
+	Price int64  `json:"price" example:"1500"`
+	Stock int    `json:"stock" example:"50"`
+}
+
+// CartItem - позиция корзины: питомец (он всегда в одном экземпляре) или аксессуар.
+// Задается ровно одно из PetID и AccessoryID. Название и цена - текущие, итог
+// фиксируется при оформлении заказа.
+type CartItem struct {
+	ID          int    `json:"id" example:"1" readonly:"true"`
+	PetID       int    `json:"petId,omitempty" example:"2"`
+	AccessoryID int    `json:"accessoryId,omitempty" example:"1"`
+	Name        string `json:"name" example:"Leash" readonly:"true"`
+	Quantity    int    `json:"quantity" example:"1" minimum:"1"`
+	UnitPrice   int64  `json:"unitPrice" example:"1500" readonly:"true"`
+	Subtotal    int64  `json:"subtotal" example:"1500" readonly:"true"`
+}
+
+// Cart - корзина текущего пользователя.
+type Cart struct {
+	Items []CartItem `json:"items"`
+	Total int64      `json:"total" example:"51400"`
+}
+
+// CartCheckout - параметры оформления корзины в заказ.
+type CartCheckout struct {
+	ShipDate string `json:"shipDate" example:"2022-01-01T06:29:51.438Z"`
+}
+
+// OrderItem - позиция заказа из корзины с названием и ценой на момент оформления.
+type OrderItem struct {
+	ID          int    `json:"id" example:"1"`
+	PetID       int    `json:"petId,omitempty" example:"2"`
+	AccessoryID int    `json:"accessoryId,omitempty" example:"1"`
+	Name        string `json:"name" example:"Leash"`
+	Quantity    int    `json:"quantity" example:"2"`
+	UnitPrice   int64  `json:"unitPrice" example:"1500"`
+	Subtotal    int64  `json:"subtotal" example:"3000"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSearchUnavailable - полнотекстовый индекс не создан, потому что SQLite собран без FTS5.
+var ErrSearchUnavailable = errors.New("full-text search is not available")
+
+// ErrVersionMismatch - ресурс изменился после того, как клиент получил его версию (HTTP 412).
+var ErrVersionMismatch = errors.New("resource was modified, reload it and retry")
+
+// ErrForbidden - у пользователя из JWT нет доступа к ресурсу (HTTP 403).
+var ErrForbidden = errors.New("access denied")
+
+// ConflictError - операция противоречит текущему состоянию ресурса (HTTP 409).
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+func NewConflictError(message string) error {
+	return &ConflictError{Message: message}
+}
+
+// StatusTransitionError - недопустимая смена статуса сущности, например
+// питомца из sold в available (HTTP 409).
+type StatusTransitionError struct {
+	Entity string
+	From   string
+	To     string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("%s status cannot change from %q to %q", e.Entity, e.From, e.To)
+}
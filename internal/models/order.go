@@ -1,10 +1,43 @@
 package models
 
+// Статусы заказа. Допустимые переходы между ними проверяет store/service:
+// placed -> approved -> shipped -> delivered, отменить можно до отправки.
+const (
+	OrderStatusPlaced    = "placed"
+	OrderStatusApproved  = "approved"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+)
+
+// Order - заказ питомца. Complete выставляется сервисом: заказ завершен, когда доставлен.
+// UserID - владелец заказа, берется из JWT того, кто оформил заказ. Заказ из корзины
+// вместо PetID содержит позиции Items и сумму Total, Quantity - число единиц во всех позициях.
 type Order struct {
-	ID       int    `json:"id" db:"id" example:"1"`
-	PetID    int    `json:"petId" db:"pet_id" example:"1"`
-	Quantity int    `json:"quantity" db:"quantity" example:"10"`
-	ShipDate string `json:"shipDate" db:"ship_date" example:"2022-01-01T06:29:51.438Z"`
-	Status   string `json:"status" db:"status" example:"placed"`
-	Complete bool   `json:"complete" db:"complete" example:"true"`
+	ID       int         `json:"id" db:"id" example:"1"`
+	PetID    int         `json:"petId,omitempty" db:"pet_id" example:"1"`
+	UserID   int         `json:"userId,omitempty" db:"user_id" example:"1" readonly:"true"`
+	Quantity int         `json:"quantity" db:"quantity" example:"10"`
+	ShipDate string      `json:"shipDate" db:"ship_date" example:"2022-01-01T06:29:51.438Z"`
+	Status   string      `json:"status" db:"status" example:"placed" enums:"placed,approved,shipped,delivered,cancelled"`
+	Complete bool        `json:"complete" db:"complete" example:"false" readonly:"true"`
+	Items    []OrderItem `json:"items,omitempty" readonly:"true"`
+	Total    int64       `json:"total,omitempty" db:"total" example:"51400" readonly:"true"`
+}
+
+// OrderListQuery - фильтры и страница списка заказов. ShipFrom и ShipTo - даты
+// YYYY-MM-DD, обе границы включаются. Нулевой UserID - заказы всех пользователей.
+type OrderListQuery struct {
+	UserID   int
+	Status   []string
+	ShipFrom string
+	ShipTo   string
+	Limit    int
+	Cursor   string
+}
+
+// OrderPage - страница списка заказов, новые заказы первыми.
+type OrderPage struct {
+	Items      []Order    `json:"items"`
+	Pagination Pagination `json:"pagination"`
 }
@@ -0,0 +1,9 @@
+package models
+
+// Pagination - метаданные страницы при постраничной выборке по курсору.
+type Pagination struct {
+	Limit      int    `json:"limit" example:"20"`
+	Sort       string `json:"sort" example:"-name"`
+	NextCursor string `json:"nextCursor,omitempty" example:"eyJzIjoiLW5hbWUiLCJ2IjoiRGFpc3kiLCJpZCI6MX0"`
+	HasMore    bool   `json:"hasMore" example:"true"`
+}
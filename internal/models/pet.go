@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Category struct {
 	ID   int    `json:"id" example:"4"`
 	Name string `json:"name" example:"rabbit"`
@@ -11,10 +13,122 @@ type Tag struct {
 }
 
 type Pet struct {
-	ID        int      `json:"id" example:"1"`
-	Category  Category `json:"category"`
-	Name      string   `json:"name" example:"Daisy"`
-	PhotoUrls []string `json:"photoUrls"`
-	Tags      []Tag    `json:"tags"`
-	Status    string   `json:"status" example:"available"`
+	ID          int        `json:"id" example:"1"`
+	Category    Category   `json:"category"`
+	Name        string     `json:"name" example:"Daisy"`
+	PhotoUrls   []string   `json:"photoUrls"`
+	Tags        []Tag      `json:"tags"`
+	Status      string     `json:"status" example:"available" enums:"available,pending,sold,deleted"`
+	Breed       string     `json:"breed,omitempty" example:"beagle" maxLength:"100"`
+	BirthDate   string     `json:"birthDate,omitempty" example:"2021-05-14" format:"date"`
+	Sex         string     `json:"sex,omitempty" example:"female" enums:"male,female"`
+	Weight      *float64   `json:"weight,omitempty" example:"12.5" minimum:"0"`
+	Description string     `json:"description,omitempty" example:"Loves long walks" maxLength:"4000"`
+	Price       *int64     `json:"price,omitempty" example:"49900" minimum:"0"`
+	Photos      []PetPhoto `json:"photos,omitempty" readonly:"true"`
+	Version     int        `json:"version" example:"1" readonly:"true"`
+}
+
+// Пол питомца.
+const (
+	PetSexMale   = "male"
+	PetSexFemale = "female"
+)
+
+// Статусы питомца. Допустимые переходы между ними проверяет pet/service.
+const (
+	PetStatusAvailable = "available"
+	PetStatusPending   = "pending"
+	PetStatusSold      = "sold"
+	PetStatusDeleted   = "deleted"
+)
+
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// PetTagFilter описывает поиск питомцев по тегам: по именам и/или ID,
+// с совпадением хотя бы одного (any) или всех (all) тегов.
+type PetTagFilter struct {
+	Names []string
+	IDs   []int
+	Match string
+}
+
+// PetPhoto - загруженное фото питомца, содержимое которого лежит в хранилище файлов.
+type PetPhoto struct {
+	ID        int               `json:"id" example:"3"`
+	PetID     int               `json:"petId" example:"1"`
+	URL       string            `json:"url" example:"/v2/pet/1/photos/3"`
+	FileName  string            `json:"fileName" example:"daisy.jpg"`
+	BlobKey   string            `json:"-"`
+	Size      int64             `json:"size" example:"48213"`
+	MimeType  string            `json:"mimeType" example:"image/jpeg"`
+	Checksum  string            `json:"checksum" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Variants  []PetPhotoVariant `json:"variants,omitempty"`
+}
+
+// PetPhotoVariant - уменьшенная копия фото, вписанная в квадрат MaxSize x MaxSize.
+type PetPhotoVariant struct {
+	MaxSize  int    `json:"maxSize" example:"128"`
+	Width    int    `json:"width" example:"128"`
+	Height   int    `json:"height" example:"96"`
+	URL      string `json:"url" example:"/v2/pet/1/photos/3?size=128"`
+	BlobKey  string `json:"-"`
+	Size     int64  `json:"size" example:"5120"`
+	MimeType string `json:"mimeType" example:"image/jpeg"`
+	Checksum string `json:"checksum" example:"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"`
+}
+
+// TagUsage - тег с количеством использующих его питомцев (без удаленных).
+type TagUsage struct {
+	ID       int    `json:"id" example:"3"`
+	Name     string `json:"name" example:"gift"`
+	PetCount int    `json:"petCount" example:"5"`
+}
+
+const (
+	PetSortID     = "id"
+	PetSortName   = "name"
+	PetSortStatus = "status"
+)
+
+// PetListQuery - параметры постраничного списка питомцев. Все фильтры
+// объединяются через AND, удаленные питомцы попадают в список, только
+// если явно запрошен статус deleted.
+type PetListQuery struct {
+	Status     []string
+	CategoryID int
+	Tags       []string
+	NamePrefix string
+	Sort       string
+	Desc       bool
+	Limit      int
+	Cursor     string
+}
+
+// PetCursor - позиция последнего питомца предыдущей страницы: значение
+// поля сортировки и ID, который разрешает совпадения этого значения.
+type PetCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    int    `json:"id"`
+}
+
+// PetPage - страница списка питомцев.
+type PetPage struct {
+	Items      []Pet      `json:"items"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// PetSearchResult - найденный полнотекстовым поиском питомец. Rank - оценка bm25,
+// чем она меньше, тем выше релевантность. В Highlight и Snippet совпадения
+// обрамлены тегом <mark>.
+type PetSearchResult struct {
+	Pet       Pet     `json:"pet"`
+	Rank      float64 `json:"rank" example:"-3.7"`
+	Highlight string  `json:"highlight" example:"<mark>Golden</mark> Boy"`
+	Snippet   string  `json:"snippet" example:"dog <mark>puppy</mark> kennel"`
 }
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Действия, которые попадают в историю изменений питомца.
+const (
+	PetActionCreate      = "create"
+	PetActionUpdate      = "update"
+	PetActionDelete      = "delete"
+	PetActionRestore     = "restore"
+	PetActionUploadPhoto = "uploadPhoto"
+	PetActionImport      = "import"
+	PetActionPurge       = "purge"
+
+	PetActionMergeTag  = "mergeTag"
+	PetActionDeleteTag = "deleteTag"
+
+	PetActionAddMedicalRecord    = "addMedicalRecord"
+	PetActionUpdateMedicalRecord = "updateMedicalRecord"
+	PetActionDeleteMedicalRecord = "deleteMedicalRecord"
+)
+
+// PetFieldChange - старое и новое значение одного поля питомца.
+type PetFieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// PetHistoryEntry - запись журнала изменений питомца: кто, когда и в рамках
+// какого запроса изменил поля.
+type PetHistoryEntry struct {
+	ID        int                       `json:"id" example:"12"`
+	PetID     int                       `json:"petId" example:"1"`
+	Action    string                    `json:"action" example:"update" enums:"create,update,delete,restore,uploadPhoto,import,purge,mergeTag,deleteTag,addMedicalRecord,updateMedicalRecord,deleteMedicalRecord"`
+	Changes   map[string]PetFieldChange `json:"changes"`
+	UserName  string                    `json:"username" example:"admin"`
+	RequestID string                    `json:"requestId" example:"host/AbCdEf1234-000001"`
+	CreatedAt time.Time                 `json:"createdAt"`
+}
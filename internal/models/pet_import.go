@@ -0,0 +1,30 @@
+package models
+
+// Форматы импорта и экспорта питомцев.
+const (
+	PetFormatCSV    = "csv"
+	PetFormatNDJSON = "ndjson"
+)
+
+// PetImportReport - итог импорта: сколько строк прочитано, добавлено (при пробном
+// импорте - прошло проверку) и отклонено, и отклоненные строки с причиной.
+// В отчет попадают первые MaxPetImportErrors ошибок, остальные только считаются (Truncated).
+type PetImportReport struct {
+	DryRun    bool             `json:"dryRun" example:"false"`
+	Total     int              `json:"total" example:"3"`
+	Imported  int              `json:"imported" example:"2"`
+	Failed    int              `json:"failed" example:"1"`
+	Errors    []PetImportError `json:"errors"`
+	Truncated bool             `json:"truncated" example:"false"`
+	Error     string           `json:"error,omitempty" example:"line 4: extraneous or missing \" in quoted-field"`
+}
+
+// MaxPetImportErrors - сколько отклоненных строк перечисляется в отчете импорта.
+const MaxPetImportErrors = 100
+
+// PetImportError - отклоненная строка файла. Row - номер строки с данными, начиная с 1.
+type PetImportError struct {
+	Row   int    `json:"row" example:"2"`
+	Name  string `json:"name,omitempty" example:"Daisy"`
+	Error string `json:"error" example:"category 7 not found"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Виды медицинских записей питомца.
+const (
+	MedicalKindVaccination = "vaccination"
+	MedicalKindVetVisit    = "vetVisit"
+	MedicalKindTreatment   = "treatment"
+)
+
+// PetMedicalRecord - запись медицинской карты питомца: прививка, осмотр у ветеринара
+// или лечение. Для прививок dueDate - дата следующей (повторной) прививки.
+type PetMedicalRecord struct {
+	ID        int       `json:"id" example:"1" readonly:"true"`
+	PetID     int       `json:"petId" example:"1" readonly:"true"`
+	Kind      string    `json:"kind" example:"vaccination" enums:"vaccination,vetVisit,treatment"`
+	Title     string    `json:"title" example:"Rabies" maxLength:"200"`
+	Notes     string    `json:"notes,omitempty" example:"Nobivac, batch 1234" maxLength:"4000"`
+	Vet       string    `json:"vet,omitempty" example:"Dr. Smith" maxLength:"200"`
+	Date      string    `json:"date" example:"2024-03-01" format:"date"`
+	DueDate   string    `json:"dueDate,omitempty" example:"2025-03-01" format:"date"`
+	CreatedBy string    `json:"createdBy,omitempty" example:"admin" readonly:"true"`
+	CreatedAt time.Time `json:"createdAt" readonly:"true"`
+}
+
+// OverdueVaccination - прививка, повторная дата которой прошла, а новой прививки
+// с тем же названием питомцу с тех пор не делали.
+type OverdueVaccination struct {
+	PetID       int              `json:"petId" example:"1"`
+	PetName     string           `json:"petName" example:"Daisy"`
+	Record      PetMedicalRecord `json:"record"`
+	DaysOverdue int              `json:"daysOverdue" example:"14"`
+}
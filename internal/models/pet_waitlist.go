@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PetWaitlistEntry - пользователь ждет, когда забронированный питомец снова
+// появится в продаже. После уведомления запись остается с notifiedAt,
+// повторная запись в лист ожидания снова включает уведомление.
+type PetWaitlistEntry struct {
+	ID         int        `json:"id" example:"1"`
+	PetID      int        `json:"petId" example:"1"`
+	UserName   string     `json:"username" example:"admin"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	NotifiedAt *time.Time `json:"notifiedAt,omitempty"`
+	Email      string     `json:"-"`
+}
@@ -1,5 +1,11 @@
 package models
 
+// Роли пользователей. Роль назначается только в базе, через API ее не изменить.
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin"
+)
+
 type User struct {
 	ID         int    `json:"id" example:"1"`
 	UserName   string `json:"username" example:"admin"`
@@ -9,4 +15,5 @@ type User struct {
 	Password   string `json:"password" example:"admin"`
 	Phone      string `json:"phone" example:"8-999-666-99-66"`
 	UserStatus int    `json:"userStatus" example:"1"`
+	Role       string `json:"role" example:"user" readonly:"true"`
 }
@@ -0,0 +1,277 @@
+package controller
+
+import (
+	"app/internal/infrastructure/responder"
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type AdoptionControllerer interface {
+	ListApplications(w http.ResponseWriter, r *http.Request)
+	Apply(w http.ResponseWriter, r *http.Request)
+	GetApplicationById(w http.ResponseWriter, r *http.Request)
+	Approve(w http.ResponseWriter, r *http.Request)
+	Reject(w http.ResponseWriter, r *http.Request)
+	Withdraw(w http.ResponseWriter, r *http.Request)
+}
+
+type AdoptionServicer interface {
+	Apply(ctx context.Context, application models.AdoptionApplication) (models.AdoptionApplication, error)
+	GetApplicationById(ctx context.Context, id int) (models.AdoptionApplication, error)
+	ListApplications(ctx context.Context, filter models.AdoptionFilter) ([]models.AdoptionApplication, error)
+	Approve(ctx context.Context, id int, review models.AdoptionReview) (models.AdoptionApplication, error)
+	Reject(ctx context.Context, id int, review models.AdoptionReview) (models.AdoptionApplication, error)
+	Withdraw(ctx context.Context, id int) (models.AdoptionApplication, error)
+}
+
+type AdoptionController struct {
+	adoptionService AdoptionServicer
+	responder       responder.Responder
+}
+
+func NewAdoptionController(adoptionService AdoptionServicer, responder responder.Responder) AdoptionControllerer {
+	return &AdoptionController{
+		adoptionService: adoptionService,
+		responder:       responder,
+	}
+}
+
+//	@id				1listApplications
+//	@Security		ApiKeyAuth
+//	@Summary		Returns adoption applications
+//	@Description	Admins see all applications, other users only their own. Newest first
+//	@Tags			adoption
+//	@Accept			json
+//	@Produce		json
+//	@Param			petId		query		int		false	"Applications for this pet"
+//	@Param			username	query		string	false	"Applications of this user"
+//	@Param			status		query		string	false	"Applications with this status"	Enums(submitted, approved, rejected, withdrawn)
+//	@Success		200			{object}	[]models.AdoptionApplication
+//	@Failure		403			{object}	responder.Response	"applications of another user"
+//	@Router			/adoption [get]
+func (c *AdoptionController) ListApplications(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := models.AdoptionFilter{
+		UserName: query.Get("username"),
+		Status:   query.Get("status"),
+	}
+
+	if rawPetID := query.Get("petId"); rawPetID != "" {
+		petID, err := strconv.Atoi(rawPetID)
+		if err != nil {
+			c.responder.ErrorBadRequest(w, err)
+			return
+		}
+		filter.PetID = petID
+	}
+
+	applications, err := c.adoptionService.ListApplications(r.Context(), filter)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(applications, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				2applyForPet
+//	@Security		ApiKeyAuth
+//	@Summary		Applies for a pet
+//	@Description	The applicant is the user from the JWT. Only available pets accept applications, one submitted application per user and pet
+//	@Tags			adoption
+//	@Accept			json
+//	@Produce		json
+//	@Param			object	body		models.AdoptionApplication	true	"Application, only petId and message are used"
+//	@Success		200		{object}	models.AdoptionApplication
+//	@Failure		409		{object}	responder.Response	"pet is not available or already applied for"
+//	@Router			/adoption [post]
+func (c *AdoptionController) Apply(w http.ResponseWriter, r *http.Request) {
+	var body models.AdoptionApplication
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	application, err := c.adoptionService.Apply(r.Context(), models.AdoptionApplication{
+		PetID:   body.PetID,
+		Message: body.Message,
+	})
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(application, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id			3getApplicationById
+//	@Security	ApiKeyAuth
+//	@Summary	Find adoption application by ID
+//	@Tags		adoption
+//	@Accept		json
+//	@Produce	json
+//	@Param		applicationId	path		int	true	"ID of application to return"
+//	@Success	200				{object}	models.AdoptionApplication
+//	@Failure	403				{object}	responder.Response	"application of another user"
+//	@Failure	404				{object}	responder.Response
+//	@Router		/adoption/{applicationId} [get]
+func (c *AdoptionController) GetApplicationById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "applicationId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	application, err := c.adoptionService.GetApplicationById(r.Context(), id)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(application, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				4approveApplication
+//	@Security		ApiKeyAuth
+//	@Summary		Approves an adoption application
+//	@Description	Admin only. The pet becomes pending, an order is placed for it and the other submitted applications for the pet are rejected
+//	@Tags			adoption
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int						true	"ID of application to approve"
+//	@Param			object			body		models.AdoptionReview	false	"Review notes"
+//	@Success		200				{object}	models.AdoptionApplication
+//	@Failure		403				{object}	responder.Response	"admin role required"
+//	@Failure		409				{object}	responder.Response	"application is closed or pet is not available"
+//	@Router			/adoption/{applicationId}/approve [post]
+func (c *AdoptionController) Approve(w http.ResponseWriter, r *http.Request) {
+	c.review(w, r, c.adoptionService.Approve)
+}
+
+//	@id				5rejectApplication
+//	@Security		ApiKeyAuth
+//	@Summary		Rejects an adoption application
+//	@Description	Admin only
+//	@Tags			adoption
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int						true	"ID of application to reject"
+//	@Param			object			body		models.AdoptionReview	false	"Review notes"
+//	@Success		200				{object}	models.AdoptionApplication
+//	@Failure		403				{object}	responder.Response	"admin role required"
+//	@Failure		409				{object}	responder.Response	"application is closed"
+//	@Router			/adoption/{applicationId}/reject [post]
+func (c *AdoptionController) Reject(w http.ResponseWriter, r *http.Request) {
+	c.review(w, r, c.adoptionService.Reject)
+}
+
+//	@id				6withdrawApplication
+//	@Security		ApiKeyAuth
+//	@Summary		Withdraws an adoption application
+//	@Description	Only the applicant can withdraw a submitted application
+//	@Tags			adoption
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"ID of application to withdraw"
+//	@Success		200				{object}	models.AdoptionApplication
+//	@Failure		403				{object}	responder.Response	"application of another user"
+//	@Failure		409				{object}	responder.Response	"application is closed"
+//	@Router			/adoption/{applicationId}/withdraw [post]
+func (c *AdoptionController) Withdraw(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "applicationId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	application, err := c.adoptionService.Withdraw(r.Context(), id)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(application, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+// review разбирает решение по заявке (тело необязательно) и передает его в decide.
+func (c *AdoptionController) review(w http.ResponseWriter, r *http.Request,
+	decide func(ctx context.Context, id int, review models.AdoptionReview) (models.AdoptionApplication, error)) {
+	id, err := strconv.Atoi(chi.URLParam(r, "applicationId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	var review models.AdoptionReview
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&review)
+		if err != nil {
+			c.responder.ErrorBadRequest(w, err)
+			return
+		}
+	}
+
+	application, err := decide(r.Context(), id, review)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(application, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+func (c *AdoptionController) respondError(w http.ResponseWriter, err error) {
+	var transitionErr *models.StatusTransitionError
+	var conflictErr *models.ConflictError
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.responder.ErrorNotFound(w, errors.New("application not found"))
+	case errors.Is(err, models.ErrForbidden):
+		c.responder.ErrorForbidden(w, err)
+	case errors.As(err, &transitionErr), errors.As(err, &conflictErr):
+		c.responder.ErrorConflict(w, err)
+	default:
+		c.responder.ErrorBadRequest(w, err)
+	}
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"app/internal/infrastructure/db"
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const applicationsTable = "adoption_applications"
+
+type AdoptionRepositoryer interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateApplication(ctx context.Context, application models.AdoptionApplication) (models.AdoptionApplication, error)
+	GetApplicationById(ctx context.Context, id int) (models.AdoptionApplication, error)
+	ListApplications(ctx context.Context, filter models.AdoptionFilter) ([]models.AdoptionApplication, error)
+	CloseApplication(ctx context.Context, application models.AdoptionApplication) error
+	RejectPetApplications(ctx context.Context, petID int, notes string, reviewedBy string) error
+}
+
+type AdoptionRepository struct {
+	db *sql.DB
+}
+
+func NewAdoptionRepository(db *sql.DB) AdoptionRepositoryer {
+	return &AdoptionRepository{
+		db: db,
+	}
+}
+
+// InTx выполняет fn в транзакции: методы репозитория, вызванные с контекстом fn,
+// работают в ней, как и методы других репозиториев той же базы.
+func (r AdoptionRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.InTx(ctx, r.db, fn)
+}
+
+// conn - транзакция InTx из ctx или, вне ее, сама база.
+func (r AdoptionRepository) conn(ctx context.Context) db.Conn {
+	return db.ConnFrom(ctx, r.db)
+}
+
+func selectApplications() sq.SelectBuilder {
+	return sq.Select(
+		"id",
+		"pet_id",
+		"username",
+		"message",
+		"status",
+		"review_notes",
+		"reviewed_by",
+		"reviewed_at",
+		"order_id",
+		"created_at",
+	).
+		From(applicationsTable)
+}
+
+func scanApplication(row sq.RowScanner) (models.AdoptionApplication, error) {
+	var application models.AdoptionApplication
+	var message, reviewNotes, reviewedBy sql.NullString
+	var reviewedAt sql.NullTime
+	var orderID sql.NullInt64
+
+	err := row.Scan(
+		&application.ID,
+		&application.PetID,
+		&application.UserName,
+		&message,
+		&application.Status,
+		&reviewNotes,
+		&reviewedBy,
+		&reviewedAt,
+		&orderID,
+		&application.CreatedAt,
+	)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	application.Message = message.String
+	application.ReviewNotes = reviewNotes.String
+	application.ReviewedBy = reviewedBy.String
+	application.OrderID = int(orderID.Int64)
+	if reviewedAt.Valid {
+		application.ReviewedAt = &reviewedAt.Time
+	}
+
+	return application, nil
+}
+
+func (r AdoptionRepository) CreateApplication(ctx context.Context, application models.AdoptionApplication) (models.AdoptionApplication, error) {
+	application.Status = models.AdoptionStatusSubmitted
+	application.CreatedAt = time.Now().UTC()
+
+	res, err := sq.Insert(applicationsTable).
+		Columns("pet_id", "username", "message", "status", "created_at").
+		Values(application.PetID, application.UserName, nullString(application.Message), application.Status, application.CreatedAt).
+		RunWith(r.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	application.ID = int(id)
+
+	return application, nil
+}
+
+func (r AdoptionRepository) GetApplicationById(ctx context.Context, id int) (models.AdoptionApplication, error) {
+	return scanApplication(selectApplications().
+		Where(sq.Eq{"id": id}).
+		RunWith(r.conn(ctx)).
+		QueryRowContext(ctx))
+}
+
+// ListApplications возвращает заявки по фильтру, новые первыми.
+func (r AdoptionRepository) ListApplications(ctx context.Context, filter models.AdoptionFilter) ([]models.AdoptionApplication, error) {
+	where := sq.Eq{}
+	if filter.PetID != 0 {
+		where["pet_id"] = filter.PetID
+	}
+	if filter.UserName != "" {
+		where["username"] = filter.UserName
+	}
+	if filter.Status != "" {
+		where["status"] = filter.Status
+	}
+
+	rows, err := selectApplications().
+		Where(where).
+		OrderBy("id DESC").
+		RunWith(r.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applications := []models.AdoptionApplication{}
+	for rows.Next() {
+		application, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, application)
+	}
+
+	return applications, rows.Err()
+}
+
+// CloseApplication сохраняет решение по заявке. Решение принимается один раз:
+// если заявку уже рассмотрели или отозвали, возвращается ConflictError.
+func (r AdoptionRepository) CloseApplication(ctx context.Context, application models.AdoptionApplication) error {
+	var orderID interface{}
+	if application.OrderID != 0 {
+		orderID = application.OrderID
+	}
+
+	res, err := sq.Update(applicationsTable).
+		SetMap(map[string]interface{}{
+			"status":       application.Status,
+			"review_notes": nullString(application.ReviewNotes),
+			"reviewed_by":  nullString(application.ReviewedBy),
+			"reviewed_at":  application.ReviewedAt,
+			"order_id":     orderID,
+		}).
+		Where(sq.Eq{"id": application.ID, "status": models.AdoptionStatusSubmitted}).
+		RunWith(r.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.NewConflictError("application has already been closed")
+	}
+
+	return nil
+}
+
+// RejectPetApplications отклоняет все нерассмотренные заявки на питомца.
+func (r AdoptionRepository) RejectPetApplications(ctx context.Context, petID int, notes string, reviewedBy string) error {
+	_, err := sq.Update(applicationsTable).
+		SetMap(map[string]interface{}{
+			"status":       models.AdoptionStatusRejected,
+			"review_notes": nullString(notes),
+			"reviewed_by":  nullString(reviewedBy),
+			"reviewed_at":  time.Now().UTC(),
+		}).
+		Where(sq.Eq{"pet_id": petID, "status": models.AdoptionStatusSubmitted}).
+		RunWith(r.conn(ctx)).
+		ExecContext(ctx)
+
+	return err
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
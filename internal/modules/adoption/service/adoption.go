@@ -0,0 +1,272 @@
+package service
+
+import (
+	customMiddleware "app/internal/infrastructure/middleware"
+	"app/internal/models"
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// maxAdoptionTextLength - ограничение длины сообщения заявителя и заметок сотрудника.
+const maxAdoptionTextLength = 4000
+
+type AdoptionServicer interface {
+	Apply(ctx context.Context, application models.AdoptionApplication) (models.AdoptionApplication, error)
+	GetApplicationById(ctx context.Context, id int) (models.AdoptionApplication, error)
+	ListApplications(ctx context.Context, filter models.AdoptionFilter) ([]models.AdoptionApplication, error)
+	Approve(ctx context.Context, id int, review models.AdoptionReview) (models.AdoptionApplication, error)
+	Reject(ctx context.Context, id int, review models.AdoptionReview) (models.AdoptionApplication, error)
+	Withdraw(ctx context.Context, id int) (models.AdoptionApplication, error)
+}
+
+type AdoptionRepositoryer interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateApplication(ctx context.Context, application models.AdoptionApplication) (models.AdoptionApplication, error)
+	GetApplicationById(ctx context.Context, id int) (models.AdoptionApplication, error)
+	ListApplications(ctx context.Context, filter models.AdoptionFilter) ([]models.AdoptionApplication, error)
+	CloseApplication(ctx context.Context, application models.AdoptionApplication) error
+	RejectPetApplications(ctx context.Context, petID int, notes string, reviewedBy string) error
+}
+
+// PetServicer - то, что заявкам нужно от питомцев: заявки принимаются и одобряются
+// только для питомца в продаже. Бронирует питомца не PetService, а заказ, который
+// создается при одобрении через StoreServicer.
+type PetServicer interface {
+	GetPetById(ctx context.Context, id int) (models.Pet, error)
+}
+
+// UserServicer нужен, чтобы заказ по заявке принадлежал заявителю.
+type UserServicer interface {
+	GetUserByName(ctx context.Context, userName string) (models.User, error)
+}
+
+type StoreServicer interface {
+	PlaceOrder(ctx context.Context, order models.Order) (models.Order, error)
+}
+
+type AdoptionService struct {
+	adoptionRepository AdoptionRepositoryer
+	petService         PetServicer
+	storeService       StoreServicer
+	userService        UserServicer
+}
+
+func NewAdoptionService(adoptionRepository AdoptionRepositoryer, petService PetServicer, storeService StoreServicer, userService UserServicer) AdoptionServicer {
+	return &AdoptionService{
+		adoptionRepository: adoptionRepository,
+		petService:         petService,
+		storeService:       storeService,
+		userService:        userService,
+	}
+}
+
+// Apply подает заявку на питомца от имени пользователя из JWT. Подать можно только
+// на питомца в продаже и только одну нерассмотренную заявку на питомца.
+func (s *AdoptionService) Apply(ctx context.Context, application models.AdoptionApplication) (models.AdoptionApplication, error) {
+	userName := customMiddleware.UserNameFromContext(ctx)
+	if userName == "" {
+		return models.AdoptionApplication{}, models.ErrForbidden
+	}
+
+	if utf8.RuneCountInString(application.Message) > maxAdoptionTextLength {
+		return models.AdoptionApplication{}, fmt.Errorf("message must be at most %d characters", maxAdoptionTextLength)
+	}
+
+	pet, err := s.petService.GetPetById(ctx, application.PetID)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	if pet.Status != models.PetStatusAvailable {
+		return models.AdoptionApplication{}, models.NewConflictError(fmt.Sprintf("pet is %s, applications are accepted only for available pets", pet.Status))
+	}
+
+	submitted, err := s.adoptionRepository.ListApplications(ctx, models.AdoptionFilter{
+		PetID:    pet.ID,
+		UserName: userName,
+		Status:   models.AdoptionStatusSubmitted,
+	})
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	if len(submitted) > 0 {
+		return models.AdoptionApplication{}, models.NewConflictError("you already have a submitted application for this pet")
+	}
+
+	application.UserName = userName
+
+	return s.adoptionRepository.CreateApplication(ctx, application)
+}
+
+// GetApplicationById возвращает заявку ее автору или администратору.
+func (s *AdoptionService) GetApplicationById(ctx context.Context, id int) (models.AdoptionApplication, error) {
+	application, err := s.adoptionRepository.GetApplicationById(ctx, id)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	if !canAccess(ctx, application.UserName) {
+		return models.AdoptionApplication{}, models.ErrForbidden
+	}
+
+	return application, nil
+}
+
+// ListApplications возвращает заявки по фильтру. Администратор видит все заявки,
+// остальные пользователи - только свои.
+func (s *AdoptionService) ListApplications(ctx context.Context, filter models.AdoptionFilter) ([]models.AdoptionApplication, error) {
+	if filter.Status != "" && !isAdoptionStatus(filter.Status) {
+		return nil, fmt.Errorf("invalid application status %q", filter.Status)
+	}
+
+	if customMiddleware.RoleFromContext(ctx) != models.UserRoleAdmin {
+		userName := customMiddleware.UserNameFromContext(ctx)
+		if filter.UserName != "" && filter.UserName != userName {
+			return nil, models.ErrForbidden
+		}
+		filter.UserName = userName
+	}
+
+	return s.adoptionRepository.ListApplications(ctx, filter)
+}
+
+// Approve одобряет заявку: для заявителя создается заказ, который в той же транзакции
+// бронирует питомца (pending), остальные заявки на питомца отклоняются. Заказ,
+// решение по заявке и отклонение остальных заявок фиксируются одной транзакцией.
+func (s *AdoptionService) Approve(ctx context.Context, id int, review models.AdoptionReview) (models.AdoptionApplication, error) {
+	application, err := s.openApplication(ctx, id, review)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	pet, err := s.petService.GetPetById(ctx, application.PetID)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	if pet.Status != models.PetStatusAvailable {
+		return models.AdoptionApplication{}, &models.StatusTransitionError{Entity: "pet", From: pet.Status, To: models.PetStatusPending}
+	}
+
+	applicant, err := s.userService.GetUserByName(ctx, application.UserName)
+	if err != nil {
+		return models.AdoptionApplication{}, fmt.Errorf("applicant %q: %w", application.UserName, err)
+	}
+
+	// заказ не даст одобрить две заявки на питомца одновременно: второй получит ConflictError
+	err = s.adoptionRepository.InTx(ctx, func(ctx context.Context) error {
+		order, err := s.storeService.PlaceOrder(ctx, models.Order{
+			UserID:   applicant.ID,
+			PetID:    pet.ID,
+			Quantity: 1,
+			// дату передачи питомца сотрудник согласует с заявителем, пока это день одобрения
+			ShipDate: time.Now().UTC().Format("2006-01-02"),
+			Status:   models.OrderStatusPlaced,
+		})
+		if err != nil {
+			return err
+		}
+
+		application.Status = models.AdoptionStatusApproved
+		application.OrderID = order.ID
+		setReview(ctx, &application, review)
+
+		err = s.adoptionRepository.CloseApplication(ctx, application)
+		if err != nil {
+			return err
+		}
+
+		return s.adoptionRepository.RejectPetApplications(ctx, pet.ID, "another application for this pet was approved", application.ReviewedBy)
+	})
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	return application, nil
+}
+
+func (s *AdoptionService) Reject(ctx context.Context, id int, review models.AdoptionReview) (models.AdoptionApplication, error) {
+	application, err := s.openApplication(ctx, id, review)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	application.Status = models.AdoptionStatusRejected
+	setReview(ctx, &application, review)
+
+	err = s.adoptionRepository.CloseApplication(ctx, application)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	return application, nil
+}
+
+// Withdraw отзывает заявку, пока ее не рассмотрели. Отозвать может только автор.
+func (s *AdoptionService) Withdraw(ctx context.Context, id int) (models.AdoptionApplication, error) {
+	application, err := s.GetApplicationById(ctx, id)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	if application.UserName != customMiddleware.UserNameFromContext(ctx) {
+		return models.AdoptionApplication{}, models.ErrForbidden
+	}
+
+	application.Status = models.AdoptionStatusWithdrawn
+
+	err = s.adoptionRepository.CloseApplication(ctx, application)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	return application, nil
+}
+
+// openApplication возвращает заявку, которую еще можно рассмотреть.
+func (s *AdoptionService) openApplication(ctx context.Context, id int, review models.AdoptionReview) (models.AdoptionApplication, error) {
+	if utf8.RuneCountInString(review.Notes) > maxAdoptionTextLength {
+		return models.AdoptionApplication{}, fmt.Errorf("notes must be at most %d characters", maxAdoptionTextLength)
+	}
+
+	application, err := s.GetApplicationById(ctx, id)
+	if err != nil {
+		return models.AdoptionApplication{}, err
+	}
+
+	if application.Status != models.AdoptionStatusSubmitted {
+		return models.AdoptionApplication{}, models.NewConflictError(fmt.Sprintf("application is already %s", application.Status))
+	}
+
+	return application, nil
+}
+
+func setReview(ctx context.Context, application *models.AdoptionApplication, review models.AdoptionReview) {
+	reviewedAt := time.Now().UTC()
+
+	application.ReviewNotes = review.Notes
+	application.ReviewedBy = customMiddleware.UserNameFromContext(ctx)
+	application.ReviewedAt = &reviewedAt
+}
+
+// canAccess - заявку видят ее автор и администраторы.
+func canAccess(ctx context.Context, owner string) bool {
+	if customMiddleware.RoleFromContext(ctx) == models.UserRoleAdmin {
+		return true
+	}
+
+	return owner != "" && owner == customMiddleware.UserNameFromContext(ctx)
+}
+
+func isAdoptionStatus(status string) bool {
+	switch status {
+	case models.AdoptionStatusSubmitted, models.AdoptionStatusApproved,
+		models.AdoptionStatusRejected, models.AdoptionStatusWithdrawn:
+		return true
+	}
+
+	return false
+}
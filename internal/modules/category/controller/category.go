@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"app/internal/infrastructure/responder"
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type CategoryControllerer interface {
+	ListCategories(w http.ResponseWriter, r *http.Request)
+	GetCategoryById(w http.ResponseWriter, r *http.Request)
+	CreateCategory(w http.ResponseWriter, r *http.Request)
+	UpdateCategory(w http.ResponseWriter, r *http.Request)
+	DeleteCategory(w http.ResponseWriter, r *http.Request)
+}
+
+type CategoryServicer interface {
+	ListCategories(ctx context.Context) ([]models.Category, error)
+	GetCategoryById(ctx context.Context, id int) (models.Category, error)
+	CreateCategory(ctx context.Context, category models.Category) (models.Category, error)
+	UpdateCategory(ctx context.Context, category models.Category) (models.Category, error)
+	DeleteCategory(ctx context.Context, id int) error
+}
+
+type CategoryController struct {
+	categoryService CategoryServicer
+	responder       responder.Responder
+}
+
+func NewCategoryController(categoryService CategoryServicer, responder responder.Responder) CategoryControllerer {
+	return &CategoryController{
+		categoryService: categoryService,
+		responder:       responder,
+	}
+}
+
+//	@id			1listCategories
+//	@Security	ApiKeyAuth
+//	@Summary	Returns all categories
+//	@Tags		category
+//	@Accept		json
+//	@Produce	json
+//	@Success	200	{object}	[]models.Category
+//	@Router		/category [get]
+func (c *CategoryController) ListCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := c.categoryService.ListCategories(context.Background())
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(categories, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id			2getCategoryById
+//	@Security	ApiKeyAuth
+//	@Summary	Find category by ID
+//	@Tags		category
+//	@Accept		json
+//	@Produce	json
+//	@Param		categoryId	path		int	true	"ID of category to return"
+//	@Success	200			{object}	models.Category
+//	@Failure	404			{object}	responder.Response
+//	@Router		/category/{categoryId} [get]
+func (c *CategoryController) GetCategoryById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "categoryId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	category, err := c.categoryService.GetCategoryById(context.Background(), id)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(category, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				3createCategory
+//	@Security		ApiKeyAuth
+//	@Summary		Create a new category
+//	@Description	Admin only
+//	@Tags			category
+//	@Accept			json
+//	@Produce		json
+//	@Param			object	body		models.Category	true	"Category to create, id is ignored"
+//	@Success		200		{object}	models.Category
+//	@Failure		403		{object}	responder.Response	"admin role required"
+//	@Failure		409		{object}	responder.Response
+//	@Router			/category [post]
+func (c *CategoryController) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var category models.Category
+	err := json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	createCategory, err := c.categoryService.CreateCategory(context.Background(), category)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(createCategory, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				4updateCategory
+//	@Security		ApiKeyAuth
+//	@Summary		Rename a category
+//	@Description	Admin only
+//	@Tags			category
+//	@Accept			json
+//	@Produce		json
+//	@Param			categoryId	path		int				true	"ID of category to update"
+//	@Param			object		body		models.Category	true	"Updated category, id is taken from the path"
+//	@Success		200			{object}	models.Category
+//	@Failure		403			{object}	responder.Response	"admin role required"
+//	@Failure		404			{object}	responder.Response
+//	@Failure		409			{object}	responder.Response
+//	@Router			/category/{categoryId} [put]
+func (c *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "categoryId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	var category models.Category
+	err = json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+	category.ID = id
+
+	updateCategory, err := c.categoryService.UpdateCategory(context.Background(), category)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(updateCategory, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				5deleteCategory
+//	@Security		ApiKeyAuth
+//	@Summary		Deletes a category
+//	@Description	Admin only. A category that is still referenced by pets can not be deleted
+//	@Tags			category
+//	@Accept			json
+//	@Produce		json
+//	@Param			categoryId	path		int	true	"ID of category to delete"
+//	@Success		200			{object}	responder.Response
+//	@Failure		403			{object}	responder.Response	"admin role required"
+//	@Failure		404			{object}	responder.Response
+//	@Failure		409			{object}	responder.Response
+//	@Router			/category/{categoryId} [delete]
+func (c *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "categoryId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = c.categoryService.DeleteCategory(context.Background(), id)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	c.responder.Success(w, fmt.Sprint(id))
+}
+
+func (c *CategoryController) respondError(w http.ResponseWriter, err error) {
+	var conflictErr *models.ConflictError
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.responder.ErrorNotFound(w, errors.New("category not found"))
+	case errors.As(err, &conflictErr):
+		c.responder.ErrorConflict(w, err)
+	default:
+		c.responder.ErrorBadRequest(w, err)
+	}
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"app/internal/infrastructure/db"
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	categoriesTable = "categories"
+	petsTable       = "pets"
+)
+
+type CategoryRepositoryer interface {
+	ListCategories(ctx context.Context) ([]models.Category, error)
+	GetCategoryById(ctx context.Context, id int) (models.Category, error)
+	GetCategoryByName(ctx context.Context, name string) (models.Category, error)
+	CreateCategory(ctx context.Context, category models.Category) (models.Category, error)
+	UpdateCategory(ctx context.Context, category models.Category) (models.Category, error)
+	DeleteCategory(ctx context.Context, id int) error
+}
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository(db *sql.DB) CategoryRepositoryer {
+	return &CategoryRepository{
+		db: db,
+	}
+}
+
+func (r CategoryRepository) ListCategories(ctx context.Context) ([]models.Category, error) {
+	rows, err := sq.Select("id", "name").
+		From(categoriesTable).
+		OrderBy("id").
+		RunWith(r.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var category models.Category
+		if err = rows.Scan(&category.ID, &category.Name); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r CategoryRepository) GetCategoryById(ctx context.Context, id int) (models.Category, error) {
+	var category models.Category
+
+	err := sq.Select("id", "name").
+		From(categoriesTable).
+		Where(sq.Eq{"id": id}).
+		RunWith(r.db).
+		QueryRowContext(ctx).
+		Scan(&category.ID, &category.Name)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (r CategoryRepository) GetCategoryByName(ctx context.Context, name string) (models.Category, error) {
+	var category models.Category
+
+	err := sq.Select("id", "name").
+		From(categoriesTable).
+		Where(sq.Eq{"name": name}).
+		Limit(1).
+		RunWith(r.db).
+		QueryRowContext(ctx).
+		Scan(&category.ID, &category.Name)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (r CategoryRepository) CreateCategory(ctx context.Context, category models.Category) (models.Category, error) {
+	res, err := sq.Insert(categoriesTable).
+		Columns("name").
+		Values(category.Name).
+		RunWith(r.db).
+		ExecContext(ctx)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	category.ID = int(id)
+
+	return category, nil
+}
+
+// UpdateCategory переименовывает категорию. Питомцы отдают категорию с именем,
+// поэтому их версия тоже увеличивается.
+func (r CategoryRepository) UpdateCategory(ctx context.Context, category models.Category) (models.Category, error) {
+	tx, err := db.BeginTx(ctx, r.db)
+	if err != nil {
+		return models.Category{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = sq.Update(petsTable).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"category_id": category.ID}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	_, err = sq.Update(categoriesTable).
+		Set("name", category.Name).
+		Where(sq.Eq{"id": category.ID}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	return category, tx.Commit()
+}
+
+// DeleteCategory удаляет категорию, если на нее не ссылается ни один питомец, иначе
+// возвращает ConflictError. Проверка входит в сам DELETE, поэтому питомец, добавленный
+// в категорию параллельно, не останется со ссылкой на удаленную категорию.
+func (r CategoryRepository) DeleteCategory(ctx context.Context, id int) error {
+	tx, err := db.BeginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := sq.Delete(categoriesTable).
+		Where(sq.Eq{"id": id}).
+		Where("NOT EXISTS (SELECT 1 FROM pets WHERE category_id = ?)", id).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		count, err := countPets(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return models.NewConflictError(fmt.Sprintf("category is used by %d pet(s)", count))
+		}
+
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+func countPets(ctx context.Context, tx *db.Tx, categoryID int) (int, error) {
+	var count int
+
+	err := sq.Select("COUNT(id)").
+		From(petsTable).
+		Where(sq.Eq{"category_id": categoryID}).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type CategoryServicer interface {
+	ListCategories(ctx context.Context) ([]models.Category, error)
+	GetCategoryById(ctx context.Context, id int) (models.Category, error)
+	CreateCategory(ctx context.Context, category models.Category) (models.Category, error)
+	UpdateCategory(ctx context.Context, category models.Category) (models.Category, error)
+	DeleteCategory(ctx context.Context, id int) error
+}
+
+type CategoryRepositoryer interface {
+	ListCategories(ctx context.Context) ([]models.Category, error)
+	GetCategoryById(ctx context.Context, id int) (models.Category, error)
+	GetCategoryByName(ctx context.Context, name string) (models.Category, error)
+	CreateCategory(ctx context.Context, category models.Category) (models.Category, error)
+	UpdateCategory(ctx context.Context, category models.Category) (models.Category, error)
+	DeleteCategory(ctx context.Context, id int) error
+}
+
+type CategoryService struct {
+	categoryRepository CategoryRepositoryer
+}
+
+func NewCategoryService(categoryRepository CategoryRepositoryer) CategoryServicer {
+	return &CategoryService{
+		categoryRepository: categoryRepository,
+	}
+}
+
+func (s *CategoryService) ListCategories(ctx context.Context) ([]models.Category, error) {
+	return s.categoryRepository.ListCategories(ctx)
+}
+
+func (s *CategoryService) GetCategoryById(ctx context.Context, id int) (models.Category, error) {
+	return s.categoryRepository.GetCategoryById(ctx, id)
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, category models.Category) (models.Category, error) {
+	category.Name = strings.TrimSpace(category.Name)
+
+	err := s.checkName(ctx, category)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	return s.categoryRepository.CreateCategory(ctx, category)
+}
+
+func (s *CategoryService) UpdateCategory(ctx context.Context, category models.Category) (models.Category, error) {
+	_, err := s.GetCategoryById(ctx, category.ID)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	category.Name = strings.TrimSpace(category.Name)
+
+	err = s.checkName(ctx, category)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	return s.categoryRepository.UpdateCategory(ctx, category)
+}
+
+func (s *CategoryService) DeleteCategory(ctx context.Context, id int) error {
+	_, err := s.GetCategoryById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// категорию, на которую ссылаются питомцы, репозиторий не удалит
+	return s.categoryRepository.DeleteCategory(ctx, id)
+}
+
+// checkName проверяет, что имя задано и не занято другой категорией.
+func (s *CategoryService) checkName(ctx context.Context, category models.Category) error {
+	if category.Name == "" {
+		return errors.New("category name is required")
+	}
+
+	existing, err := s.categoryRepository.GetCategoryByName(ctx, category.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if existing.ID != category.ID {
+		return models.NewConflictError(fmt.Sprintf("category %q already exists", category.Name))
+	}
+
+	return nil
+}
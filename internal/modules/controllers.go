@@ -1,29 +1,52 @@
 package modules
 
 import (
+	"app/internal/infrastructure/idempotency"
 	"app/internal/infrastructure/responder"
 	pC "app/internal/modules/pet/controller"
 	sC "app/internal/modules/store/controller"
+	cC "app/internal/modules/category/controller"
+	tC "app/internal/modules/tag/controller"
+	aC "app/internal/modules/adoption/controller"
 	uC "app/internal/modules/user/controller"
 	"net/http"
 	"os"
+	"strconv"
 	customMiddleware "app/internal/infrastructure/middleware"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/jwtauth"
 )
 
+// defaultMaxUploadSize - ограничение размера загружаемых фото, если не задан MAX_UPLOAD_SIZE.
+const defaultMaxUploadSize = 10 << 20
+
 type Controller struct {
 	User  uC.UserControllerer
 	Pet   pC.PetControllerer
 	Store sC.StoreControllerer
+	Category cC.CategoryControllerer
+	Tag tC.TagControllerer
+	Adoption aC.AdoptionControllerer
+	// idempotent подключается к POST с JWT, которые создают сущности: ключи
+	// хранятся по пользователю, и у анонимных запросов они были бы общими
+	idempotent func(http.Handler) http.Handler
 }
 
-func NewController(services *Service, respond responder.Responder) *Controller {
+func NewController(services *Service, respond responder.Responder, idempotencyKeys idempotency.Store) *Controller {
+	maxUploadSize, err := strconv.ParseInt(os.Getenv("MAX_UPLOAD_SIZE"), 10, 64)
+	if err != nil || maxUploadSize <= 0 {
+		maxUploadSize = defaultMaxUploadSize
+	}
+
 	return &Controller{
 		User:  uC.NewUserController(services.User, respond),
-		Pet:   pC.NewPetController(services.Pet, respond),
+		Pet:   pC.NewPetController(services.Pet, respond, maxUploadSize),
 		Store: sC.NewStoreController(services.Store, respond),
+		Category: cC.NewCategoryController(services.Category, respond),
+		Tag: tC.NewTagController(services.Tag, respond),
+		Adoption: aC.NewAdoptionController(services.Adoption, respond),
+		idempotent: customMiddleware.Idempotency(idempotencyKeys),
 	}
 }
 
@@ -51,6 +74,9 @@ func (c *Controller) InitRoutesUser() http.Handler {
 func (c *Controller) InitRoutesPet() http.Handler {
 	r := chi.NewRouter()
 
+	// фото отдается без авторизации: ссылки на него вставляются в <img>, куда JWT не передать
+	r.Get("/{petId}/photos/{photoId}", c.Pet.GetPetPhoto)
+
 	r.Group(func(r chi.Router) {
 		// подключаем авторизацию
 		signKey := os.Getenv("SIGN_KEY")
@@ -60,13 +86,30 @@ func (c *Controller) InitRoutesPet() http.Handler {
 		r.Use(customMiddleware.Authenticator)
 
 		r.Post("/{petId}/uploadImage", c.Pet.UploadFile)
-		r.Post("/", c.Pet.AddPet)
+		r.Get("/{petId}/history", c.Pet.GetPetHistory)
+		r.Get("/", c.Pet.ListPets)
+		r.With(c.idempotent).Post("/", c.Pet.AddPet)
 		r.Put("/", c.Pet.UpdatePet)
 		r.Get("/findByStatus", c.Pet.FindPetsByStatus)
 		r.Get("/findByTags", c.Pet.FindPetsByTags)
+		r.Get("/search", c.Pet.SearchPets)
+		r.With(customMiddleware.AdminOnly).Post("/import", c.Pet.ImportPets)
+		r.Get("/export", c.Pet.ExportPets)
 		r.Get("/{petId}", c.Pet.GetPetById)
 		r.Post("/{petId}", c.Pet.UpdatePetWithForm)
+		r.Patch("/{petId}", c.Pet.PatchPet)
 		r.Delete("/{petId}", c.Pet.DeletePet)
+		r.Post("/{petId}/restore", c.Pet.RestorePet)
+		r.With(customMiddleware.AdminOnly).Delete("/{petId}/purge", c.Pet.PurgePet)
+		r.Get("/medical/overdue", c.Pet.FindOverdueVaccinations)
+		r.Get("/{petId}/medical", c.Pet.ListMedicalRecords)
+		r.Post("/{petId}/medical", c.Pet.AddMedicalRecord)
+		r.Get("/{petId}/medical/{recordId}", c.Pet.GetMedicalRecord)
+		r.Put("/{petId}/medical/{recordId}", c.Pet.UpdateMedicalRecord)
+		r.Delete("/{petId}/medical/{recordId}", c.Pet.DeleteMedicalRecord)
+		r.Get("/{petId}/waitlist", c.Pet.ListWaitlist)
+		r.Post("/{petId}/waitlist", c.Pet.JoinWaitlist)
+		r.Delete("/{petId}/waitlist", c.Pet.LeaveWaitlist)
 	})
 
 	return r
@@ -84,11 +127,84 @@ func (c *Controller) InitRoutesStore() http.Handler {
 		r.Use(customMiddleware.Authenticator)
 
 		r.Get("/inventory", c.Store.GetInventory)
+		r.With(customMiddleware.AdminOnly).Post("/order/{orderId}/approve", c.Store.ApproveOrder)
+		r.With(customMiddleware.AdminOnly).Post("/order/{orderId}/ship", c.Store.ShipOrder)
+		r.With(customMiddleware.AdminOnly).Post("/order/{orderId}/deliver", c.Store.DeliverOrder)
+		r.Post("/order/{orderId}/cancel", c.Store.CancelOrder)
+		r.With(c.idempotent).Post("/order", c.Store.PlaceOrder)
+		r.Get("/orders", c.Store.ListOrders)
+		r.Get("/order/{orderId}", c.Store.GetOrderById)
+		r.Delete("/order/{orderId}", c.Store.DeleteOrder)
+		r.Get("/accessories", c.Store.ListAccessories)
+		r.Get("/cart", c.Store.GetCart)
+		r.Delete("/cart", c.Store.ClearCart)
+		r.Post("/cart/items", c.Store.AddCartItem)
+		r.Put("/cart/items/{itemId}", c.Store.UpdateCartItem)
+		r.Delete("/cart/items/{itemId}", c.Store.DeleteCartItem)
+		r.With(c.idempotent).Post("/cart/checkout", c.Store.Checkout)
 	})
 
-	r.Post("/order", c.Store.PlaceOrder)
-	r.Get("/order/{orderId}", c.Store.GetOrderById)
-	r.Delete("/order/{orderId}", c.Store.DeleteOrder)
+	return r
+}
+
+func (c *Controller) InitRoutesCategory() http.Handler {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		// подключаем авторизацию
+		signKey := os.Getenv("SIGN_KEY")
+		tokenAuth := jwtauth.New("HS256", []byte(signKey), nil)
+		r.Use(jwtauth.Verifier(tokenAuth))
+		r.Use(customMiddleware.Authenticator)
+
+		r.Get("/", c.Category.ListCategories)
+		r.With(customMiddleware.AdminOnly).Post("/", c.Category.CreateCategory)
+		r.Get("/{categoryId}", c.Category.GetCategoryById)
+		r.With(customMiddleware.AdminOnly).Put("/{categoryId}", c.Category.UpdateCategory)
+		r.With(customMiddleware.AdminOnly).Delete("/{categoryId}", c.Category.DeleteCategory)
+	})
+
+	return r
+}
+
+func (c *Controller) InitRoutesTag() http.Handler {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		// подключаем авторизацию
+		signKey := os.Getenv("SIGN_KEY")
+		tokenAuth := jwtauth.New("HS256", []byte(signKey), nil)
+		r.Use(jwtauth.Verifier(tokenAuth))
+		r.Use(customMiddleware.Authenticator)
+
+		r.Get("/", c.Tag.ListTags)
+		r.Post("/", c.Tag.CreateTag)
+		r.Get("/{tagId}", c.Tag.GetTagById)
+		r.With(customMiddleware.AdminOnly).Put("/{tagId}", c.Tag.RenameTag)
+		r.With(customMiddleware.AdminOnly).Post("/{tagId}/merge/{targetId}", c.Tag.MergeTags)
+		r.With(customMiddleware.AdminOnly).Delete("/{tagId}", c.Tag.DeleteTag)
+	})
+
+	return r
+}
+
+func (c *Controller) InitRoutesAdoption() http.Handler {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		// подключаем авторизацию
+		signKey := os.Getenv("SIGN_KEY")
+		tokenAuth := jwtauth.New("HS256", []byte(signKey), nil)
+		r.Use(jwtauth.Verifier(tokenAuth))
+		r.Use(customMiddleware.Authenticator)
+
+		r.Get("/", c.Adoption.ListApplications)
+		r.Post("/", c.Adoption.Apply)
+		r.Get("/{applicationId}", c.Adoption.GetApplicationById)
+		r.Post("/{applicationId}/withdraw", c.Adoption.Withdraw)
+		r.With(customMiddleware.AdminOnly).Post("/{applicationId}/approve", c.Adoption.Approve)
+		r.With(customMiddleware.AdminOnly).Post("/{applicationId}/reject", c.Adoption.Reject)
+	})
 
 	return r
 }
@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"app/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+//	@id				16listMedicalRecords
+//	@x-sort			16
+//	@Security		ApiKeyAuth
+//	@Summary		Returns the medical records of a pet
+//	@Description	Vaccinations, vet visits and treatments of the pet, newest first
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			petId	path		int	true	"ID of pet"
+//	@Success		200		{object}	[]models.PetMedicalRecord
+//	@Router			/pet/{petId}/medical [get]
+func (c *PetController) ListMedicalRecords(w http.ResponseWriter, r *http.Request) {
+	petID, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	records, err := c.petService.ListMedicalRecords(r.Context(), petID)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				17addMedicalRecord
+//	@x-sort			17
+//	@Security		ApiKeyAuth
+//	@Summary		Adds a medical record to a pet
+//	@Description	kind is vaccination, vetVisit or treatment. dueDate (the follow-up vaccination) is allowed for vaccinations only and must be after date
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			petId	path		int						true	"ID of pet"
+//	@Param			object	body		models.PetMedicalRecord	true	"Medical record"
+//	@Success		200		{object}	models.PetMedicalRecord
+//	@Failure		409		{object}	responder.Response	"pet is deleted"
+//	@Router			/pet/{petId}/medical [post]
+func (c *PetController) AddMedicalRecord(w http.ResponseWriter, r *http.Request) {
+	petID, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	var record models.PetMedicalRecord
+	err = json.NewDecoder(r.Body).Decode(&record)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	record.PetID = petID
+
+	record, err = c.petService.AddMedicalRecord(r.Context(), record)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id			18getMedicalRecord
+//	@x-sort		18
+//	@Security	ApiKeyAuth
+//	@Summary	Find a medical record of a pet by ID
+//	@Tags		pet
+//	@Accept		json
+//	@Produce	json
+//	@Param		petId		path		int	true	"ID of pet"
+//	@Param		recordId	path		int	true	"ID of medical record"
+//	@Success	200			{object}	models.PetMedicalRecord
+//	@Failure	404			{object}	responder.Response	"record not found"
+//	@Router		/pet/{petId}/medical/{recordId} [get]
+func (c *PetController) GetMedicalRecord(w http.ResponseWriter, r *http.Request) {
+	petID, recordID, err := medicalRecordParams(r)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	record, err := c.petService.GetMedicalRecord(r.Context(), petID, recordID)
+	if err != nil {
+		c.respondMedicalError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id			19updateMedicalRecord
+//	@x-sort		19
+//	@Security	ApiKeyAuth
+//	@Summary	Updates a medical record of a pet
+//	@Tags		pet
+//	@Accept		json
+//	@Produce	json
+//	@Param		petId		path		int						true	"ID of pet"
+//	@Param		recordId	path		int						true	"ID of medical record"
+//	@Param		object		body		models.PetMedicalRecord	true	"Medical record"
+//	@Success	200			{object}	models.PetMedicalRecord
+//	@Failure	404			{object}	responder.Response	"record not found"
+//	@Failure	409			{object}	responder.Response	"pet is deleted"
+//	@Router		/pet/{petId}/medical/{recordId} [put]
+func (c *PetController) UpdateMedicalRecord(w http.ResponseWriter, r *http.Request) {
+	petID, recordID, err := medicalRecordParams(r)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	var record models.PetMedicalRecord
+	err = json.NewDecoder(r.Body).Decode(&record)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	record.ID = recordID
+	record.PetID = petID
+
+	record, err = c.petService.UpdateMedicalRecord(r.Context(), record)
+	if err != nil {
+		c.respondMedicalError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id			20deleteMedicalRecord
+//	@x-sort		20
+//	@Security	ApiKeyAuth
+//	@Summary	Deletes a medical record of a pet
+//	@Tags		pet
+//	@Accept		json
+//	@Produce	json
+//	@Param		petId		path		int	true	"ID of pet"
+//	@Param		recordId	path		int	true	"ID of medical record"
+//	@Success	200			{object}	responder.Response
+//	@Failure	404			{object}	responder.Response	"record not found"
+//	@Router		/pet/{petId}/medical/{recordId} [delete]
+func (c *PetController) DeleteMedicalRecord(w http.ResponseWriter, r *http.Request) {
+	petID, recordID, err := medicalRecordParams(r)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = c.petService.DeleteMedicalRecord(r.Context(), petID, recordID)
+	if err != nil {
+		c.respondMedicalError(w, err)
+		return
+	}
+
+	c.responder.Success(w, fmt.Sprint(recordID))
+}
+
+//	@id				21findOverdueVaccinations
+//	@x-sort			21
+//	@Security		ApiKeyAuth
+//	@Summary		Finds pets with overdue vaccinations
+//	@Description	Vaccinations whose follow-up date is before the given date and that were not repeated since (a later vaccination with the same title). Deleted pets are skipped. Most overdue first
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			date	query		string	false	"Date to check against, YYYY-MM-DD (today by default)"
+//	@Success		200		{object}	[]models.OverdueVaccination
+//	@Router			/pet/medical/overdue [get]
+func (c *PetController) FindOverdueVaccinations(w http.ResponseWriter, r *http.Request) {
+	overdue, err := c.petService.FindOverdueVaccinations(r.Context(), r.URL.Query().Get("date"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(overdue, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+func medicalRecordParams(r *http.Request) (int, int, error) {
+	petID, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	recordID, err := strconv.Atoi(chi.URLParam(r, "recordId"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return petID, recordID, nil
+}
+
+// respondMedicalError отвечает 404, если записи нет у этого питомца, остальное - как respondError.
+func (c *PetController) respondMedicalError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.responder.ErrorNotFound(w, errors.New("medical record not found"))
+		return
+	}
+
+	c.respondError(w, err)
+}
@@ -1,66 +1,121 @@
 package controller
 
 import (
+	"app/internal/infrastructure/imaging"
+	"app/internal/infrastructure/jsonpatch"
 	"app/internal/infrastructure/responder"
+	"app/internal/infrastructure/storage"
 	"app/internal/models"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 )
 
+// multipartOverhead - запас на границы и заголовки частей multipart/form-data.
+const multipartOverhead = 1 << 20
+
+// maxPatchSize - ограничение размера тела PATCH.
+const maxPatchSize = 1 << 20
+
 type PetControllerer interface {
 	UploadFile(w http.ResponseWriter, r *http.Request)
 	AddPet(w http.ResponseWriter, r *http.Request)
 	UpdatePet(w http.ResponseWriter, r *http.Request)
+	PatchPet(w http.ResponseWriter, r *http.Request)
 	FindPetsByStatus(w http.ResponseWriter, r *http.Request)
 	FindPetsByTags(w http.ResponseWriter, r *http.Request)
+	ListPets(w http.ResponseWriter, r *http.Request)
+	SearchPets(w http.ResponseWriter, r *http.Request)
 	GetPetById(w http.ResponseWriter, r *http.Request)
 	UpdatePetWithForm(w http.ResponseWriter, r *http.Request)
 	DeletePet(w http.ResponseWriter, r *http.Request)
+	GetPetPhoto(w http.ResponseWriter, r *http.Request)
+	GetPetHistory(w http.ResponseWriter, r *http.Request)
+	RestorePet(w http.ResponseWriter, r *http.Request)
+	PurgePet(w http.ResponseWriter, r *http.Request)
+	ListMedicalRecords(w http.ResponseWriter, r *http.Request)
+	AddMedicalRecord(w http.ResponseWriter, r *http.Request)
+	GetMedicalRecord(w http.ResponseWriter, r *http.Request)
+	UpdateMedicalRecord(w http.ResponseWriter, r *http.Request)
+	DeleteMedicalRecord(w http.ResponseWriter, r *http.Request)
+	FindOverdueVaccinations(w http.ResponseWriter, r *http.Request)
+	ListWaitlist(w http.ResponseWriter, r *http.Request)
+	JoinWaitlist(w http.ResponseWriter, r *http.Request)
+	LeaveWaitlist(w http.ResponseWriter, r *http.Request)
+	ImportPets(w http.ResponseWriter, r *http.Request)
+	ExportPets(w http.ResponseWriter, r *http.Request)
 }
 
 type PetServicer interface {
-	UploadFile(ctx context.Context, id int, file multipart.File, fileHeader *multipart.FileHeader) error
+	UploadFile(ctx context.Context, id int, file multipart.File, fileHeader *multipart.FileHeader) (models.PetPhoto, error)
+	OpenPetPhoto(ctx context.Context, petID int, photoID int, size int) (models.PetPhoto, *storage.Blob, error)
 	AddPet(ctx context.Context, pet models.Pet) (models.Pet, error)
 	UpdatePet(ctx context.Context, pet models.Pet) (models.Pet, error)
+	PatchPet(ctx context.Context, id int, patch jsonpatch.Patcher, version int) (models.Pet, error)
 	FindPetsByStatus(ctx context.Context, status []string) ([]models.Pet, error)
-	FindPetsByTags(ctx context.Context, tags []string) ([]models.Pet, error)
+	FindPetsByTags(ctx context.Context, filter models.PetTagFilter) ([]models.Pet, error)
+	ListPets(ctx context.Context, query models.PetListQuery) (models.PetPage, error)
+	SearchPets(ctx context.Context, text string, limit int) ([]models.PetSearchResult, error)
 	GetPetById(ctx context.Context, id int) (models.Pet, error)
-	UpdatePetWithForm(ctx context.Context, id int, name string, status string) error
+	UpdatePetWithForm(ctx context.Context, id int, name string, status string, version int) error
 	DeletePet(ctx context.Context, id int) error
+	GetPetHistory(ctx context.Context, petID int) ([]models.PetHistoryEntry, error)
+	RestorePet(ctx context.Context, id int) (models.Pet, error)
+	PurgePet(ctx context.Context, id int) error
+	ListMedicalRecords(ctx context.Context, petID int) ([]models.PetMedicalRecord, error)
+	GetMedicalRecord(ctx context.Context, petID int, recordID int) (models.PetMedicalRecord, error)
+	AddMedicalRecord(ctx context.Context, record models.PetMedicalRecord) (models.PetMedicalRecord, error)
+	UpdateMedicalRecord(ctx context.Context, record models.PetMedicalRecord) (models.PetMedicalRecord, error)
+	DeleteMedicalRecord(ctx context.Context, petID int, recordID int) error
+	FindOverdueVaccinations(ctx context.Context, asOf string) ([]models.OverdueVaccination, error)
+	JoinWaitlist(ctx context.Context, petID int) (models.PetWaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, petID int) error
+	ListWaitlist(ctx context.Context, petID int) ([]models.PetWaitlistEntry, error)
+	ImportPets(ctx context.Context, format string, r io.Reader, dryRun bool) (models.PetImportReport, error)
+	ExportPets(ctx context.Context, format string, status []string, w io.Writer) error
 }
 
 type PetController struct {
-	petService PetServicer
-	responder  responder.Responder
+	petService    PetServicer
+	responder     responder.Responder
+	maxUploadSize int64
 }
 
-func NewPetController(petService PetServicer, responder responder.Responder) PetControllerer {
+func NewPetController(petService PetServicer, responder responder.Responder, maxUploadSize int64) PetControllerer {
 	return &PetController{
-		petService: petService,
-		responder:  responder,
+		petService:    petService,
+		responder:     responder,
+		maxUploadSize: maxUploadSize,
 	}
 }
 
-//	@id			1uploadFile
-//	@x-sort		1
-//	@Security	ApiKeyAuth
-//	@Summary	uploads an image
-//	@Tags		pet
-//	@Accept		multipart/form-data
-//	@Produce	json
-//	@Param		petId				path		int		true	"ID of pet to update"
-//	@Param		additionalMetadata	formData	string	false	"Additional data to pass to server"
-//	@Param		file				formData	file	true	"file to upload"
-//	@Success	200					{object}	responder.Response
-//	@Router		/pet/{petId}/uploadImage [post]
+//	@id				1uploadFile
+//	@x-sort			1
+//	@Security		ApiKeyAuth
+//	@Summary		uploads an image
+//	@Description	Accepts JPEG, PNG and GIF images (detected by content) of at most 50 megapixels. EXIF/GPS and other metadata is removed before the image is stored
+//	@Tags			pet
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			petId				path		int		true	"ID of pet to update"
+//	@Param			additionalMetadata	formData	string	false	"Additional data to pass to server"
+//	@Param			file				formData	file	true	"file to upload"
+//	@Success		200					{object}	responder.Response
+//	@Failure		400					{object}	responder.Response	"image dimensions are too large"
+//	@Failure		413					{object}	responder.Response
+//	@Failure		415					{object}	responder.Response
+//	@Router			/pet/{petId}/uploadImage [post]
 func (c *PetController) UploadFile(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "petId"))
 	if err != nil {
@@ -68,6 +123,20 @@ func (c *PetController) UploadFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// ограничиваем тело запроса до разбора формы, запас - на служебные поля multipart
+	r.Body = http.MaxBytesReader(w, r.Body, c.maxUploadSize+multipartOverhead)
+	err = r.ParseMultipartForm(32 << 20)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.responder.ErrorRequestEntityTooLarge(w, fmt.Errorf("file is larger than %d bytes", c.maxUploadSize))
+			return
+		}
+
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
 	additionalMetadata := r.FormValue("additionalMetadata")
 
 	file, header, err := r.FormFile("file")
@@ -77,26 +146,40 @@ func (c *PetController) UploadFile(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	err = c.petService.UploadFile(context.Background(), id, file, header)
+	if header.Size > c.maxUploadSize {
+		c.responder.ErrorRequestEntityTooLarge(w, fmt.Errorf("file is larger than %d bytes", c.maxUploadSize))
+		return
+	}
+
+	photo, err := c.petService.UploadFile(r.Context(), id, file, header)
 	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedFormat) {
+			c.responder.ErrorUnsupportedMediaType(w, err)
+			return
+		}
+
 		c.responder.ErrorBadRequest(w, err)
 		return
 	}
 
-	respStr := fmt.Sprintf("additionalMetadata: %s\nFile uploaded to ./%s, %d bytes", additionalMetadata, header.Filename, header.Size)
+	respStr := fmt.Sprintf("additionalMetadata: %s\nFile uploaded to %s, %d bytes", additionalMetadata, photo.URL, photo.Size)
 	c.responder.Success(w, respStr)
 }
 
-//	@id			2addPet
-//	@x-sort		2
-//	@Security	ApiKeyAuth
-//	@Summary	Add a new pet to the store
-//	@Tags		pet
-//	@Accept		json
-//	@Produce	json
-//	@Param		object	body		models.Pet	true	"Pet object that needs to be added to the store"
-//	@Success	200		{object}	models.Pet
-//	@Router		/pet [post]
+//	@id				2addPet
+//	@x-sort			2
+//	@Security		ApiKeyAuth
+//	@Summary		Add a new pet to the store
+//	@Description	Repeating the request with the same Idempotency-Key returns the saved response instead of adding the pet again
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			object			body		models.Pet	true	"Pet object that needs to be added to the store"
+//	@Param			Idempotency-Key	header		string		false	"Unique key of the request, retries must reuse it"
+//	@Success		200				{object}	models.Pet
+//	@Failure		409				{object}	responder.Response	"request with this key is in progress"
+//	@Failure		422				{object}	responder.Response	"key was used with a different request"
+//	@Router			/pet [post]
 func (c *PetController) AddPet(w http.ResponseWriter, r *http.Request) {
 	var pet models.Pet
 	err := json.NewDecoder(r.Body).Decode(&pet)
@@ -105,9 +188,9 @@ func (c *PetController) AddPet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createPet, err := c.petService.AddPet(context.Background(), pet)
+	createPet, err := c.petService.AddPet(r.Context(), pet)
 	if err != nil {
-		c.responder.ErrorBadRequest(w, err)
+		c.respondError(w, err)
 		return
 	}
 
@@ -127,8 +210,11 @@ func (c *PetController) AddPet(w http.ResponseWriter, r *http.Request) {
 //	@Tags		pet
 //	@Accept		json
 //	@Produce	json
-//	@Param		object	body		models.Pet	true	"Pet object that needs to be added to the store"
-//	@Success	200		{object}	models.Pet
+//	@Param		object		body		models.Pet	true	"Pet object that needs to be added to the store"
+//	@Param		If-Match	header		string		false	"ETag from getPetById, the update fails with 412 if the pet has changed since"
+//	@Success	200			{object}	models.Pet
+//	@Failure	409			{object}	responder.Response	"illegal status transition or pet was changed concurrently"
+//	@Failure	412			{object}	responder.Response	"pet was modified"
 //	@Router		/pet [put]
 func (c *PetController) UpdatePet(w http.ResponseWriter, r *http.Request) {
 	var pet models.Pet
@@ -138,9 +224,16 @@ func (c *PetController) UpdatePet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatePet, err := c.petService.UpdatePet(context.Background(), pet)
+	// версию из тела не принимаем, только из If-Match
+	pet.Version, err = ifMatchVersion(r)
 	if err != nil {
-		c.responder.ErrorBadRequest(w, err)
+		c.respondError(w, err)
+		return
+	}
+
+	updatePet, err := c.petService.UpdatePet(r.Context(), pet)
+	if err != nil {
+		c.respondError(w, err)
 		return
 	}
 
@@ -150,6 +243,81 @@ func (c *PetController) UpdatePet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", versionETag(updatePet.Version))
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				15patchPet
+//	@x-sort			15
+//	@Security		ApiKeyAuth
+//	@Summary		Partially updates a pet
+//	@Description	application/merge-patch+json (RFC 7386): a partial pet, fields set to null are cleared. application/json-patch+json (RFC 6902): a list of operations, e.g. [{"op":"add","path":"/tags/-","value":{"id":2}}]. id, photos and version are read-only. A failed test operation answers 409
+//	@Tags			pet
+//	@Accept			application/merge-patch+json,application/json-patch+json
+//	@Produce		json
+//	@Param			petId		path		int						true	"ID of pet to update"
+//	@Param			patch		body		[]jsonpatch.Operation	true	"JSON Patch operations or a merge patch object"
+//	@Param			If-Match	header		string					false	"ETag from getPetById, the update fails with 412 if the pet has changed since"
+//	@Success		200			{object}	models.Pet
+//	@Failure		409			{object}	responder.Response	"illegal status transition, failed test operation or pet was changed concurrently"
+//	@Failure		412			{object}	responder.Response	"pet was modified"
+//	@Failure		415			{object}	responder.Response	"unsupported patch format"
+//	@Router			/pet/{petId} [patch]
+func (c *PetController) PatchPet(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.responder.ErrorRequestEntityTooLarge(w, fmt.Errorf("patch exceeds %d bytes", maxPatchSize))
+			return
+		}
+
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var patch jsonpatch.Patcher
+	switch mediaType {
+	case jsonpatch.MergePatchType:
+		patch = jsonpatch.MergePatch(body)
+	case jsonpatch.JSONPatchType:
+		patch, err = jsonpatch.DecodePatch(body)
+		if err != nil {
+			c.responder.ErrorBadRequest(w, err)
+			return
+		}
+	default:
+		c.responder.ErrorUnsupportedMediaType(w, fmt.Errorf("content type must be %s or %s", jsonpatch.MergePatchType, jsonpatch.JSONPatchType))
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	pet, err := c.petService.PatchPet(r.Context(), id, patch, version)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(pet, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(pet.Version))
 	fmt.Fprintln(w, string(jsonResp))
 }
 
@@ -167,7 +335,7 @@ func (c *PetController) UpdatePet(w http.ResponseWriter, r *http.Request) {
 func (c *PetController) FindPetsByStatus(w http.ResponseWriter, r *http.Request) {
 	status := r.URL.Query().Get("status")
 	statusSlice := strings.Split(status, ",")
-	pets, err := c.petService.FindPetsByStatus(context.Background(), statusSlice)
+	pets, err := c.petService.FindPetsByStatus(r.Context(), statusSlice)
 	if err != nil {
 		c.responder.ErrorBadRequest(w, err)
 		return
@@ -182,19 +350,332 @@ func (c *PetController) FindPetsByStatus(w http.ResponseWriter, r *http.Request)
 	fmt.Fprintln(w, string(jsonResp))
 }
 
-//	@id			5findPetsByTags
-//	@x-sort		5
-//	@Security	ApiKeyAuth
-//	@Summary	Finds Pets by tags
-//	@Deprecated
-//	@Tags		pet
-//	@Accept		json
-//	@Produce	json
-//	@Param		tags	query		[]string	true	"Tags to filter by"	collectionFormat(csv)
-//	@Success	200		{object}	[]models.Pet
-//	@Router		/pet/findByTags [get]
+//	@id				5findPetsByTags
+//	@x-sort			5
+//	@Security		ApiKeyAuth
+//	@Summary		Finds Pets by tags
+//	@Description	Tags can be provided by name, by ID or both. With match=any a pet needs at least one of the tags, with match=all it needs every one of them
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			tags	query		[]string	false	"Tag names to filter by"	collectionFormat(csv)
+//	@Param			tagIds	query		[]int		false	"Tag IDs to filter by"		collectionFormat(csv)
+//	@Param			match	query		string		false	"Match mode"				Enums(any, all)	Default(any)
+//	@Success		200		{object}	[]models.Pet
+//	@Router			/pet/findByTags [get]
 func (c *PetController) FindPetsByTags(w http.ResponseWriter, r *http.Request) {
-	c.responder.ErrorBadRequest(w, errors.New("deprecated"))
+	filter := models.PetTagFilter{
+		Match: r.URL.Query().Get("match"),
+	}
+
+	for _, name := range strings.Split(r.URL.Query().Get("tags"), ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			filter.Names = append(filter.Names, name)
+		}
+	}
+
+	for _, rawID := range strings.Split(r.URL.Query().Get("tagIds"), ",") {
+		rawID = strings.TrimSpace(rawID)
+		if rawID == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(rawID)
+		if err != nil {
+			c.responder.ErrorBadRequest(w, fmt.Errorf("invalid tag id %q", rawID))
+			return
+		}
+
+		filter.IDs = append(filter.IDs, id)
+	}
+
+	pets, err := c.petService.FindPetsByTags(r.Context(), filter)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(pets, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				10listPets
+//	@x-sort			10
+//	@Security		ApiKeyAuth
+//	@Summary		Lists pets page by page
+//	@Description	Cursor based pagination: pass pagination.nextCursor from the previous page as cursor, keeping the same sort and filters. Deleted pets are listed only when status=deleted is requested. Links to the first and the next page are also returned in the Link header
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			status		query		[]string	false	"Status values to filter by"						collectionFormat(csv)
+//	@Param			categoryId	query		int			false	"Category ID to filter by"
+//	@Param			tag			query		[]string	false	"Tag names, a pet must have all of them"			collectionFormat(csv)
+//	@Param			name		query		string		false	"Name prefix"
+//	@Param			sort		query		string		false	"Sort field, prefix with - for descending order"	Enums(id, -id, name, -name, status, -status)	Default(id)
+//	@Param			limit		query		int			false	"Page size"											minimum(1)	maximum(100)	Default(20)
+//	@Param			cursor		query		string		false	"Cursor of the page to return"
+//	@Success		200			{object}	models.PetPage
+//	@Header			200			{string}	Link	"first and next page links"
+//	@Router			/pet [get]
+func (c *PetController) ListPets(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query := models.PetListQuery{
+		Status:     splitList(params.Get("status")),
+		Tags:       splitList(params.Get("tag")),
+		NamePrefix: params.Get("name"),
+		Sort:       strings.TrimPrefix(params.Get("sort"), "-"),
+		Desc:       strings.HasPrefix(params.Get("sort"), "-"),
+		Cursor:     params.Get("cursor"),
+	}
+
+	var err error
+	if rawCategoryID := params.Get("categoryId"); rawCategoryID != "" {
+		query.CategoryID, err = strconv.Atoi(rawCategoryID)
+		if err != nil {
+			c.responder.ErrorBadRequest(w, fmt.Errorf("invalid category id %q", rawCategoryID))
+			return
+		}
+	}
+
+	if rawLimit := params.Get("limit"); rawLimit != "" {
+		query.Limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			c.responder.ErrorBadRequest(w, fmt.Errorf("invalid limit %q", rawLimit))
+			return
+		}
+	}
+
+	page, err := c.petService.ListPets(r.Context(), query)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(page, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(r, ""))}
+	if page.Pagination.HasMore {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, page.Pagination.NextCursor)))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				11searchPets
+//	@x-sort			11
+//	@Security		ApiKeyAuth
+//	@Summary		Full-text search over pets
+//	@Description	Searches words as prefixes in pet name, category name and tag names, deleted pets are skipped. Results are ordered by relevance, matches are wrapped in <mark> in highlight (name) and snippet (best matching field). Answers 503 when the server is built without SQLite FTS5
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			q		query		string	true	"Search text"	example(golden puppy)
+//	@Param			limit	query		int		false	"Maximum number of results"	minimum(1)	maximum(100)	Default(20)
+//	@Success		200		{object}	[]models.PetSearchResult
+//	@Failure		503		{object}	responder.Response
+//	@Router			/pet/search [get]
+func (c *PetController) SearchPets(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			c.responder.ErrorBadRequest(w, fmt.Errorf("invalid limit %q", rawLimit))
+			return
+		}
+	}
+
+	results, err := c.petService.SearchPets(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		if errors.Is(err, models.ErrSearchUnavailable) {
+			c.responder.ErrorServiceUnavailable(w, err)
+			return
+		}
+
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				12getPetHistory
+//	@x-sort			12
+//	@Security		ApiKeyAuth
+//	@Summary		Returns the change history of a pet
+//	@Description	Every change of the pet with old and new field values, the user from the JWT and the request ID, oldest first. The history is kept after the pet is purged
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			petId	path		int	true	"ID of pet"
+//	@Success		200		{object}	[]models.PetHistoryEntry
+//	@Router			/pet/{petId}/history [get]
+func (c *PetController) GetPetHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	history, err := c.petService.GetPetHistory(r.Context(), id)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				13restorePet
+//	@x-sort			13
+//	@Security		ApiKeyAuth
+//	@Summary		Restores a deleted pet
+//	@Description	Returns the pet to the status it had before deletion (taken from the pet history, available if unknown). A reserved pet becomes available, as deletion drops its order reservation
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			petId	path		int	true	"ID of pet to restore"
+//	@Success		200		{object}	models.Pet
+//	@Failure		409		{object}	responder.Response	"pet is not deleted or was changed concurrently"
+//	@Router			/pet/{petId}/restore [post]
+func (c *PetController) RestorePet(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	pet, err := c.petService.RestorePet(r.Context(), id)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(pet, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				14purgePet
+//	@x-sort			14
+//	@Security		ApiKeyAuth
+//	@Summary		Permanently removes a deleted pet
+//	@Description	Admin only. Removes the pet with its photos and tags, and the stored files no other pet uses. The pet history is kept and ends with a purge entry, adoption applications are kept and the submitted ones are rejected. The pet must be deleted first and must not be referenced by orders
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			petId	path		int	true	"ID of pet to purge"
+//	@Success		200		{object}	responder.Response
+//	@Failure		403		{object}	responder.Response	"admin role required"
+//	@Failure		409		{object}	responder.Response	"pet is not deleted or has orders"
+//	@Router			/pet/{petId}/purge [delete]
+func (c *PetController) PurgePet(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = c.petService.PurgePet(r.Context(), id)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	c.responder.Success(w, fmt.Sprint(id))
+}
+
+// respondError отвечает 409 на недопустимую смену статуса и конфликты, 400 на остальные ошибки.
+func (c *PetController) respondError(w http.ResponseWriter, err error) {
+	var transitionErr *models.StatusTransitionError
+	var conflictErr *models.ConflictError
+
+	switch {
+	case errors.Is(err, models.ErrVersionMismatch):
+		c.responder.ErrorPreconditionFailed(w, err)
+	case errors.As(err, &transitionErr), errors.As(err, &conflictErr):
+		c.responder.ErrorConflict(w, err)
+	default:
+		c.responder.ErrorBadRequest(w, err)
+	}
+}
+
+// versionETag - ETag питомца по его версии.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion достает ожидаемую версию из If-Match. Без заголовка или
+// с "*" версия не проверяется (0). ETag, который не может быть версией,
+// ни с чем не совпадет, поэтому на него сразу ErrVersionMismatch.
+func ifMatchVersion(r *http.Request) (int, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+
+	ifMatch = strings.TrimPrefix(ifMatch, "W/")
+	version, err := strconv.Atoi(strings.Trim(ifMatch, `"`))
+	if err != nil || version <= 0 {
+		return 0, models.ErrVersionMismatch
+	}
+
+	return version, nil
+}
+
+// pageURL - адрес текущего запроса с другим курсором, остальные параметры сохраняются.
+func pageURL(r *http.Request, cursor string) string {
+	u := *r.URL
+	query := u.Query()
+	if cursor == "" {
+		query.Del("cursor")
+	} else {
+		query.Set("cursor", cursor)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.RequestURI()
+}
+
+// splitList разбирает значения, перечисленные через запятую, пропуская пустые.
+func splitList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
 }
 
 //	@id			6getPetById
@@ -206,6 +687,7 @@ func (c *PetController) FindPetsByTags(w http.ResponseWriter, r *http.Request) {
 //	@Produce	json
 //	@Param		petId	path		int	true	"ID of pet to return"
 //	@Success	200		{object}	models.Pet
+//	@Header		200		{string}	ETag	"version of the pet, pass it in If-Match to update"
 //	@Router		/pet/{petId} [get]
 func (c *PetController) GetPetById(w http.ResponseWriter, r *http.Request) {
 	petID := chi.URLParam(r, "petId")
@@ -215,7 +697,7 @@ func (c *PetController) GetPetById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pet, err := c.petService.GetPetById(context.Background(), id)
+	pet, err := c.petService.GetPetById(r.Context(), id)
 	if err != nil {
 		c.responder.ErrorBadRequest(w, err)
 		return
@@ -227,6 +709,7 @@ func (c *PetController) GetPetById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", versionETag(pet.Version))
 	fmt.Fprintln(w, string(jsonResp))
 }
 
@@ -237,10 +720,13 @@ func (c *PetController) GetPetById(w http.ResponseWriter, r *http.Request) {
 //	@Tags		pet
 //	@Accept		application/x-www-form-urlencoded
 //	@Produce	json
-//	@Param		petId	path		int		true	"ID of pet that needs to be updated"
-//	@Param		name	formData	string	false	"Updated name of the pet"
-//	@Param		status	formData	string	false	"Updated status of the pet"
-//	@Success	200		{object}	responder.Response
+//	@Param		petId		path		int		true	"ID of pet that needs to be updated"
+//	@Param		name		formData	string	false	"Updated name of the pet"
+//	@Param		status		formData	string	false	"Updated status of the pet"	Enums(available, pending, sold, deleted)
+//	@Param		If-Match	header		string	false	"ETag from getPetById, the update fails with 412 if the pet has changed since"
+//	@Success	200			{object}	responder.Response
+//	@Failure	409			{object}	responder.Response	"illegal status transition or pet was changed concurrently"
+//	@Failure	412			{object}	responder.Response	"pet was modified"
 //	@Router		/pet/{petId} [post]
 func (c *PetController) UpdatePetWithForm(w http.ResponseWriter, r *http.Request) {
 	petID := chi.URLParam(r, "petId")
@@ -260,9 +746,15 @@ func (c *PetController) UpdatePetWithForm(w http.ResponseWriter, r *http.Request
 	name := r.FormValue("name")
 	status := r.FormValue("status")
 
-	err = c.petService.UpdatePetWithForm(context.Background(), id, name, status)
+	version, err := ifMatchVersion(r)
 	if err != nil {
-		c.responder.ErrorBadRequest(w, err)
+		c.respondError(w, err)
+		return
+	}
+
+	err = c.petService.UpdatePetWithForm(r.Context(), id, name, status, version)
+	if err != nil {
+		c.respondError(w, err)
 		return
 	}
 
@@ -278,6 +770,7 @@ func (c *PetController) UpdatePetWithForm(w http.ResponseWriter, r *http.Request
 //	@Produce	json
 //	@Param		petId	path		int	true	"Pet id to delete"
 //	@Success	200		{object}	responder.Response
+//	@Failure	409		{object}	responder.Response	"illegal status transition or pet was changed concurrently"
 //	@Router		/pet/{petId} [delete]
 func (c *PetController) DeletePet(w http.ResponseWriter, r *http.Request) {
 	petID := chi.URLParam(r, "petId")
@@ -287,11 +780,73 @@ func (c *PetController) DeletePet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.petService.DeletePet(context.Background(), id)
+	err = c.petService.DeletePet(r.Context(), id)
 	if err != nil {
-		c.responder.ErrorBadRequest(w, err)
+		c.respondError(w, err)
 		return
 	}
 
 	c.responder.Success(w, fmt.Sprint(id))
 }
+
+//	@id				9getPetPhoto
+//	@x-sort			9
+//	@Summary		Downloads an uploaded pet photo
+//	@Description	No authorization, so the photo URL can be used in an img tag. Supports Range requests and conditional requests with If-None-Match / If-Modified-Since
+//	@Tags			pet
+//	@Produce		image/jpeg,image/png,image/gif,application/octet-stream
+//	@Param			petId	path	int	true	"ID of pet"
+//	@Param			photoId	path	int	true	"ID of photo to return"
+//	@Param			size	query	int	false	"Return the resized variant with this maximum side instead of the original, the original itself if it is not larger"
+//	@Success		200		{file}	binary
+//	@Success		206		{file}	binary
+//	@Success		304
+//	@Header			200		{string}	ETag			"checksum of the photo"
+//	@Header			200		{string}	Last-Modified	"upload time of the photo"
+//	@Router			/pet/{petId}/photos/{photoId} [get]
+func (c *PetController) GetPetPhoto(w http.ResponseWriter, r *http.Request) {
+	petID, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	photoID, err := strconv.Atoi(chi.URLParam(r, "photoId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	var size int
+	if rawSize := r.URL.Query().Get("size"); rawSize != "" {
+		size, err = strconv.Atoi(rawSize)
+		if err != nil {
+			c.responder.ErrorBadRequest(w, err)
+			return
+		}
+	}
+
+	photo, blob, err := c.petService.OpenPetPhoto(r.Context(), petID, photoID, size)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, storage.ErrBlobNotFound) {
+			c.responder.ErrorNotFound(w, errors.New("photo not found"))
+			return
+		}
+
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+	defer blob.Close()
+
+	modTime := photo.CreatedAt
+	if modTime.IsZero() {
+		modTime = blob.ModTime
+	}
+
+	// содержимое фото неизменно, поэтому контрольная сумма годится как ETag
+	w.Header().Set("Content-Type", photo.MimeType)
+	w.Header().Set("ETag", `"`+photo.Checksum+`"`)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int((24*time.Hour).Seconds())))
+
+	http.ServeContent(w, r, photo.FileName, modTime, blob)
+}
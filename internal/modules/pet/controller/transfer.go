@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"app/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// maxImportSize - ограничение размера файла импорта.
+const maxImportSize = 32 << 20
+
+// importFormats - форматы импорта по Content-Type.
+var importFormats = map[string]string{
+	"text/csv":             models.PetFormatCSV,
+	"application/x-ndjson": models.PetFormatNDJSON,
+	"application/ndjson":   models.PetFormatNDJSON,
+}
+
+// exportContentTypes - Content-Type выгрузки по формату.
+var exportContentTypes = map[string]string{
+	models.PetFormatCSV:    "text/csv; charset=utf-8",
+	models.PetFormatNDJSON: "application/x-ndjson",
+}
+
+//	@id				25importPets
+//	@x-sort			25
+//	@Security		ApiKeyAuth
+//	@Summary		Imports pets from CSV or NDJSON
+//	@Description	Admin only. The file is read as a stream, each row is added like POST /pet and independently of the others. CSV needs a header with at least the name column, lists (tag_ids, photo_urls) are separated by |. The export file can be imported back. With dryRun rows are only validated. The report counts all rows and lists the first 100 rejected ones
+//	@Tags			pet
+//	@Accept			text/csv,application/x-ndjson
+//	@Produce		json
+//	@Param			dryRun	query		bool	false	"Validate rows without adding pets"
+//	@Success		200		{object}	models.PetImportReport
+//	@Failure		403		{object}	responder.Response	"admin role required"
+//	@Failure		415		{object}	responder.Response
+//	@Router			/pet/import [post]
+func (c *PetController) ImportPets(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	format, ok := importFormats[mediaType]
+	if !ok {
+		c.responder.ErrorUnsupportedMediaType(w, errors.New("content type must be text/csv or application/x-ndjson"))
+		return
+	}
+
+	var dryRun bool
+	if raw := r.URL.Query().Get("dryRun"); raw != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(raw)
+		if err != nil {
+			c.responder.ErrorBadRequest(w, err)
+			return
+		}
+	}
+
+	// если файл больше ограничения, строки до обрыва добавляются, а обрыв попадает в report.Error
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	report, err := c.petService.ImportPets(r.Context(), format, body, dryRun)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				26exportPets
+//	@x-sort			26
+//	@Security		ApiKeyAuth
+//	@Summary		Exports pets as CSV or NDJSON
+//	@Description	Streams pets with categories, tags and photo URLs ordered by ID. Without status all pets except deleted are exported
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		text/csv,application/x-ndjson
+//	@Param			format	query		string		false	"File format"	Enums(csv, ndjson)	default(ndjson)
+//	@Param			status	query		[]string	false	"Status values, comma separated"	Enums(available, pending, sold, deleted)
+//	@Success		200		{file}		file
+//	@Router			/pet/export [get]
+func (c *PetController) ExportPets(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	format := params.Get("format")
+	if format == "" {
+		format = models.PetFormatNDJSON
+	}
+
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.responder.ErrorBadRequest(w, fmt.Errorf("unsupported format %q, expected %q or %q", format, models.PetFormatCSV, models.PetFormatNDJSON))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="pets.%s"`, format))
+
+	out := &exportWriter{w: w}
+
+	err := c.petService.ExportPets(r.Context(), format, splitList(params.Get("status")), out)
+	if err != nil {
+		// после первой порции статус ответа уже отправлен, ошибку остается только записать в лог
+		if out.written {
+			log.Printf("pet export: %v", err)
+			return
+		}
+
+		w.Header().Del("Content-Disposition")
+		c.respondError(w, err)
+	}
+}
+
+// exportWriter передает выгрузку клиенту порциями и запоминает, начат ли уже ответ.
+type exportWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (ew *exportWriter) Write(p []byte) (int, error) {
+	ew.written = true
+	return ew.w.Write(p)
+}
+
+func (ew *exportWriter) Flush() {
+	if flusher, ok := ew.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"app/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+//	@id				22listWaitlist
+//	@x-sort			22
+//	@Security		ApiKeyAuth
+//	@Summary		Returns the waitlist of a pet
+//	@Description	Admins see the whole waitlist, other users only their own entry
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			petId	path		int	true	"ID of pet"
+//	@Success		200		{object}	[]models.PetWaitlistEntry
+//	@Router			/pet/{petId}/waitlist [get]
+func (c *PetController) ListWaitlist(w http.ResponseWriter, r *http.Request) {
+	petID, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	entries, err := c.petService.ListWaitlist(r.Context(), petID)
+	if err != nil {
+		c.respondWaitlistError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				23joinWaitlist
+//	@x-sort			23
+//	@Security		ApiKeyAuth
+//	@Summary		Joins the waitlist of a pending pet
+//	@Description	The user from the JWT is notified (log, webhook or email, depending on the server settings) when the pet becomes available again
+//	@Tags			pet
+//	@Accept			json
+//	@Produce		json
+//	@Param			petId	path		int	true	"ID of pet"
+//	@Success		200		{object}	models.PetWaitlistEntry
+//	@Failure		409		{object}	responder.Response	"pet is not pending"
+//	@Router			/pet/{petId}/waitlist [post]
+func (c *PetController) JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	petID, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	entry, err := c.petService.JoinWaitlist(r.Context(), petID)
+	if err != nil {
+		c.respondWaitlistError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id			24leaveWaitlist
+//	@x-sort		24
+//	@Security	ApiKeyAuth
+//	@Summary	Leaves the waitlist of a pet
+//	@Tags		pet
+//	@Accept		json
+//	@Produce	json
+//	@Param		petId	path		int	true	"ID of pet"
+//	@Success	200		{object}	responder.Response
+//	@Failure	404		{object}	responder.Response	"not on the waitlist"
+//	@Router		/pet/{petId}/waitlist [delete]
+func (c *PetController) LeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	petID, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = c.petService.LeaveWaitlist(r.Context(), petID)
+	if err != nil {
+		c.respondWaitlistError(w, err)
+		return
+	}
+
+	c.responder.Success(w, fmt.Sprint(petID))
+}
+
+func (c *PetController) respondWaitlistError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.responder.ErrorNotFound(w, errors.New("not on the waitlist"))
+	case errors.Is(err, models.ErrForbidden):
+		c.responder.ErrorForbidden(w, err)
+	default:
+		c.respondError(w, err)
+	}
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const historyTable = "pet_history"
+
+func (pr PetRepository) AddPetHistory(ctx context.Context, entry models.PetHistoryEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	_, err = sq.Insert(historyTable).
+		Columns("pet_id", "action", "changes", "username", "request_id", "created_at").
+		Values(entry.PetID, entry.Action, string(changes), entry.UserName, entry.RequestID, entry.CreatedAt).
+		RunWith(pr.conn(ctx)).
+		ExecContext(ctx)
+
+	return err
+}
+
+// GetPetHistory возвращает историю изменений питомца от старых записей к новым.
+func (pr PetRepository) GetPetHistory(ctx context.Context, petID int) ([]models.PetHistoryEntry, error) {
+	rows, err := sq.Select("id", "pet_id", "action", "changes", "username", "request_id", "created_at").
+		From(historyTable).
+		Where(sq.Eq{"pet_id": petID}).
+		OrderBy("id").
+		RunWith(pr.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.PetHistoryEntry{}
+	for rows.Next() {
+		var entry models.PetHistoryEntry
+		var changes string
+		var userName, requestID sql.NullString
+
+		err = rows.Scan(&entry.ID, &entry.PetID, &entry.Action, &changes, &userName, &requestID, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+			return nil, err
+		}
+		entry.UserName = userName.String
+		entry.RequestID = requestID.String
+
+		history = append(history, entry)
+	}
+
+	return history, rows.Err()
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const medicalTable = "pet_medical_records"
+
+func selectMedicalRecords() sq.SelectBuilder {
+	return sq.Select(
+		"pet_medical_records.id",
+		"pet_medical_records.pet_id",
+		"pet_medical_records.kind",
+		"pet_medical_records.title",
+		"pet_medical_records.notes",
+		"pet_medical_records.vet",
+		"pet_medical_records.date",
+		"pet_medical_records.due_date",
+		"pet_medical_records.created_by",
+		"pet_medical_records.created_at",
+	).
+		From(medicalTable)
+}
+
+// scanMedicalRecord читает запись, выбранную selectMedicalRecords; extra - дополнительные колонки после нее.
+func scanMedicalRecord(row sq.RowScanner, extra ...interface{}) (models.PetMedicalRecord, error) {
+	var record models.PetMedicalRecord
+	var notes, vet, dueDate, createdBy sql.NullString
+
+	dest := []interface{}{
+		&record.ID,
+		&record.PetID,
+		&record.Kind,
+		&record.Title,
+		&notes,
+		&vet,
+		&record.Date,
+		&dueDate,
+		&createdBy,
+		&record.CreatedAt,
+	}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return models.PetMedicalRecord{}, err
+	}
+
+	record.Notes = notes.String
+	record.Vet = vet.String
+	record.DueDate = dueDate.String
+	record.CreatedBy = createdBy.String
+
+	return record, nil
+}
+
+// ListMedicalRecords возвращает медицинскую карту питомца, новые записи первыми.
+func (pr PetRepository) ListMedicalRecords(ctx context.Context, petID int) ([]models.PetMedicalRecord, error) {
+	rows, err := selectMedicalRecords().
+		Where(sq.Eq{"pet_id": petID}).
+		OrderBy("date DESC", "id DESC").
+		RunWith(pr.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []models.PetMedicalRecord{}
+	for rows.Next() {
+		record, err := scanMedicalRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+func (pr PetRepository) GetMedicalRecord(ctx context.Context, petID int, recordID int) (models.PetMedicalRecord, error) {
+	return scanMedicalRecord(selectMedicalRecords().
+		Where(sq.Eq{"id": recordID, "pet_id": petID}).
+		RunWith(pr.conn(ctx)).
+		QueryRowContext(ctx))
+}
+
+func (pr PetRepository) AddMedicalRecord(ctx context.Context, record models.PetMedicalRecord) (models.PetMedicalRecord, error) {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+
+	res, err := sq.Insert(medicalTable).
+		Columns("pet_id", "kind", "title", "notes", "vet", "date", "due_date", "created_by", "created_at").
+		Values(
+			record.PetID,
+			record.Kind,
+			record.Title,
+			nullString(record.Notes),
+			nullString(record.Vet),
+			record.Date,
+			nullString(record.DueDate),
+			nullString(record.CreatedBy),
+			record.CreatedAt,
+		).
+		RunWith(pr.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return models.PetMedicalRecord{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.PetMedicalRecord{}, err
+	}
+
+	record.ID = int(id)
+
+	return record, nil
+}
+
+// UpdateMedicalRecord заменяет содержимое записи, автор и время создания не меняются.
+func (pr PetRepository) UpdateMedicalRecord(ctx context.Context, record models.PetMedicalRecord) error {
+	res, err := sq.Update(medicalTable).
+		SetMap(map[string]interface{}{
+			"kind":     record.Kind,
+			"title":    record.Title,
+			"notes":    nullString(record.Notes),
+			"vet":      nullString(record.Vet),
+			"date":     record.Date,
+			"due_date": nullString(record.DueDate),
+		}).
+		Where(sq.Eq{"id": record.ID, "pet_id": record.PetID}).
+		RunWith(pr.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkRowAffected(res)
+}
+
+func (pr PetRepository) DeleteMedicalRecord(ctx context.Context, petID int, recordID int) error {
+	res, err := sq.Delete(medicalTable).
+		Where(sq.Eq{"id": recordID, "pet_id": petID}).
+		RunWith(pr.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkRowAffected(res)
+}
+
+// FindOverdueVaccinations возвращает прививки, срок повторения которых наступил раньше
+// asOf (YYYY-MM-DD). Учитывается только последняя прививка питомца с каждым названием:
+// если повторную уже сделали, старая запись не считается просроченной.
+// Удаленные питомцы не попадают в выборку.
+func (pr PetRepository) FindOverdueVaccinations(ctx context.Context, asOf string) ([]models.OverdueVaccination, error) {
+	rows, err := selectMedicalRecords().
+		Columns("pets.name").
+		Join("pets ON pets.id = pet_medical_records.pet_id").
+		Where(sq.Eq{"pet_medical_records.kind": models.MedicalKindVaccination}).
+		Where(sq.NotEq{"pet_medical_records.due_date": nil}).
+		Where(sq.Lt{"pet_medical_records.due_date": asOf}).
+		Where(sq.NotEq{"pets.status": models.PetStatusDeleted}).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM pet_medical_records AS later
+			WHERE later.pet_id = pet_medical_records.pet_id
+				AND later.kind = pet_medical_records.kind
+				AND later.title = pet_medical_records.title
+				AND (later.date > pet_medical_records.date
+					OR (later.date = pet_medical_records.date AND later.id > pet_medical_records.id)))`).
+		OrderBy("pet_medical_records.due_date", "pet_medical_records.id").
+		RunWith(pr.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overdue := []models.OverdueVaccination{}
+	for rows.Next() {
+		var petName sql.NullString
+
+		record, err := scanMedicalRecord(rows, &petName)
+		if err != nil {
+			return nil, err
+		}
+
+		overdue = append(overdue, models.OverdueVaccination{
+			PetID:   record.PetID,
+			PetName: petName.String,
+			Record:  record,
+		})
+	}
+
+	return overdue, rows.Err()
+}
+
+// checkRowAffected возвращает sql.ErrNoRows, если запрос не затронул ни одной строки.
+func checkRowAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
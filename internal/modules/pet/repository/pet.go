@@ -1,12 +1,13 @@
 package repository
 
 import (
+	"app/internal/infrastructure/db"
 	"app/internal/models"
 	"context"
 	"database/sql"
 	"errors"
-	"log"
-	"mime/multipart"
+	"fmt"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 )
@@ -20,14 +21,34 @@ const (
 )
 
 type PetRepositoryer interface {
-	UploadFile(ctx context.Context, id int, file multipart.File, fileHeader *multipart.FileHeader) error
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	UploadFile(ctx context.Context, photo models.PetPhoto) (models.PetPhoto, error)
+	AddPhotoVariants(ctx context.Context, photoID int, variants []models.PetPhotoVariant) error
+	GetPetPhoto(ctx context.Context, petID int, photoID int) (models.PetPhoto, error)
 	AddPet(ctx context.Context, body models.Pet) (models.Pet, error)
-	UpdatePet(ctx context.Context, body models.Pet) (models.Pet, error)
+	CheckPet(ctx context.Context, pet models.Pet) error
+	UpdatePet(ctx context.Context, body models.Pet) (models.Pet, []string, error)
 	FindPetsByStatus(ctx context.Context, status []string) ([]models.Pet, error)
-	FindPetsByTags(ctx context.Context, tags []string) ([]models.Pet, error)
+	FindPetsByTags(ctx context.Context, filter models.PetTagFilter) ([]models.Pet, error)
+	ListPets(ctx context.Context, query models.PetListQuery, after *models.PetCursor) ([]models.Pet, error)
+	SearchPets(ctx context.Context, match string, limit int) ([]models.PetSearchResult, error)
 	GetPetById(ctx context.Context, id int) (models.Pet, error)
-	UpdatePetWithForm(ctx context.Context, id int, name string, status string) error
-	DeletePet(ctx context.Context, id int) error
+	UpdatePetWithForm(ctx context.Context, id int, name string, status string, version int) error
+	DeletePet(ctx context.Context, id int, version int) error
+	AddPetHistory(ctx context.Context, entry models.PetHistoryEntry) error
+	GetPetHistory(ctx context.Context, petID int) ([]models.PetHistoryEntry, error)
+	PurgePet(ctx context.Context, id int) ([]string, error)
+	BlobReferenced(ctx context.Context, key string) (bool, error)
+	ListMedicalRecords(ctx context.Context, petID int) ([]models.PetMedicalRecord, error)
+	GetMedicalRecord(ctx context.Context, petID int, recordID int) (models.PetMedicalRecord, error)
+	AddMedicalRecord(ctx context.Context, record models.PetMedicalRecord) (models.PetMedicalRecord, error)
+	UpdateMedicalRecord(ctx context.Context, record models.PetMedicalRecord) error
+	DeleteMedicalRecord(ctx context.Context, petID int, recordID int) error
+	FindOverdueVaccinations(ctx context.Context, asOf string) ([]models.OverdueVaccination, error)
+	JoinWaitlist(ctx context.Context, petID int, userName string) (models.PetWaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, petID int, userName string) error
+	ListWaitlist(ctx context.Context, petID int, userName string) ([]models.PetWaitlistEntry, error)
+	TakeWaitlist(ctx context.Context, petID int) ([]models.PetWaitlistEntry, error)
 }
 
 type PetRepository struct {
@@ -40,39 +61,71 @@ func NewPetRepository(db *sql.DB) PetRepositoryer {
 	}
 }
 
-func (r PetRepository) UploadFile(ctx context.Context, id int, file multipart.File, fileHeader *multipart.FileHeader) error {
-	_, err := sq.Insert("pet_photos").
-		Columns("pet_id", "photo_url").
-		Values(id, fileHeader.Filename).
-		RunWith(r.db).
-		Exec()
-	if err != nil {
-		return err
-	}
+// InTx выполняет fn в транзакции: методы репозитория, вызванные с контекстом fn,
+// работают в ней, как и методы других репозиториев той же базы.
+func (pr PetRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.InTx(ctx, pr.db, fn)
+}
 
-	return nil
+// conn - транзакция InTx из ctx или, вне ее, сама база.
+func (pr PetRepository) conn(ctx context.Context) db.Conn {
+	return db.ConnFrom(ctx, pr.db)
 }
 
 func (r PetRepository) AddPet(ctx context.Context, pet models.Pet) (models.Pet, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
+	var created models.Pet
+
+	err := r.withTx(ctx, true, func(tx *db.Tx) error {
+		var err error
+		created, err = r.addPet(ctx, tx, pet)
+		return err
+	})
+
+	return created, err
+}
+
+// CheckPet выполняет вставку питомца как AddPet, но откатывает ее: так проверяются
+// категория и теги без записи в базу (пробный импорт).
+func (r PetRepository) CheckPet(ctx context.Context, pet models.Pet) error {
+	return r.withTx(ctx, false, func(tx *db.Tx) error {
+		_, err := r.addPet(ctx, tx, pet)
+		return err
+	})
+}
+
+// withTx выполняет fn в транзакции. Если fn вернула ошибку или commit == false,
+// изменения откатываются.
+func (r PetRepository) withTx(ctx context.Context, commit bool, fn func(tx *db.Tx) error) error {
+	tx, err := db.BeginTx(ctx, r.db)
 	if err != nil {
-		return models.Pet{}, err
+		return err
 	}
 	defer tx.Rollback()
 
-	// создание/обновление категории
-	_, err = sq.Replace(categoriesTable).
-		Columns("id", "name").
-		Values(pet.Category.ID, pet.Category.Name).
-		RunWith(tx).Exec()
+	err = fn(tx)
+	if err != nil || !commit {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addPet добавляет питомца с фото и тегами в рамках транзакции tx.
+func (r PetRepository) addPet(ctx context.Context, tx *db.Tx, pet models.Pet) (models.Pet, error) {
+	// категория должна уже существовать
+	categoryID, err := r.resolveCategory(ctx, tx, &pet.Category)
 	if err != nil {
 		return models.Pet{}, err
 	}
 
 	// создание питомца
+	values := petProfileValues(pet)
+	values["name"] = pet.Name
+	values["category_id"] = categoryID
+	values["status"] = pet.Status
+
 	res, err := sq.Insert(petsTable).
-		Columns("name", "category_id", "status").
-		Values(pet.Name, pet.Category.ID, pet.Status).
+		SetMap(values).
 		RunWith(tx).Exec()
 	if err != nil {
 		return models.Pet{}, err
@@ -87,104 +140,137 @@ func (r PetRepository) AddPet(ctx context.Context, pet models.Pet) (models.Pet,
 	pet.ID = int(id)
 
 	// добавление фото
-	insertBuilder := sq.Insert(photosTable).Columns("pet_id", "photo_url")
-	for _, photo := range pet.PhotoUrls {
-		insertBuilder = insertBuilder.Values(pet.ID, photo)
+	if len(pet.PhotoUrls) > 0 {
+		insertBuilder := sq.Insert(photosTable).Columns("pet_id", "photo_url")
+		for _, photo := range pet.PhotoUrls {
+			insertBuilder = insertBuilder.Values(pet.ID, photo)
+		}
+
+		_, err = insertBuilder.RunWith(tx).Exec()
+		if err != nil {
+			return models.Pet{}, err
+		}
 	}
 
-	_, err = insertBuilder.RunWith(tx).Exec()
+	// теги должны уже существовать
+	pet.Tags, err = r.resolveTags(ctx, tx, pet.Tags)
 	if err != nil {
 		return models.Pet{}, err
 	}
 
-	// создание/обновление тегов
-	insertBuilder = sq.Replace(tagsTable).Columns("id", "name")
-	for _, tag := range pet.Tags {
-		insertBuilder = insertBuilder.Values(tag.ID, tag.Name)
+	if len(pet.Tags) > 0 {
+		// добавляние принадлежности тегов
+		insertBuilder := sq.Insert(tagPetsTable).Columns("pet_id", "tag_id")
+		for _, tag := range pet.Tags {
+			insertBuilder = insertBuilder.Values(pet.ID, tag.ID)
+		}
+
+		_, err = insertBuilder.RunWith(tx).Exec()
+		if err != nil {
+			return models.Pet{}, err
+		}
+	}
+
+	return pet, nil
+}
+
+// UpdatePet заменяет питомца целиком. Фото, которых нет в pet.PhotoUrls, удаляются вместе
+// с копиями; возвращаются ключи их файлов, на которые больше никто не ссылается.
+func (pr PetRepository) UpdatePet(ctx context.Context, pet models.Pet) (models.Pet, []string, error) {
+	tx, err := db.BeginTx(ctx, pr.db)
+	if err != nil {
+		return models.Pet{}, nil, err
 	}
+	defer tx.Rollback()
 
-	_, err = insertBuilder.RunWith(tx).Exec()
+	// категория должна уже существовать
+	categoryID, err := pr.resolveCategory(ctx, tx, &pet.Category)
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
 	}
 
-	// добавляние принадлежности тегов
-	insertBuilder = sq.Insert(tagPetsTable).Columns("pet_id", "tag_id")
-	for _, tag := range pet.Tags {
-		insertBuilder = insertBuilder.Values(pet.ID, tag.ID)
+	values := petProfileValues(pet)
+	values["name"] = pet.Name
+	values["category_id"] = categoryID
+	values["status"] = pet.Status
+	values["version"] = sq.Expr("version + 1")
+	if pet.Status != models.PetStatusPending {
+		values["reserved_by_order"] = nil
 	}
 
-	_, err = insertBuilder.RunWith(tx).Exec()
+	// обновление питомца, при указанной версии - только если ее никто не успел изменить
+	res, err := sq.Update(petsTable).
+		SetMap(values).
+		Where(versionCondition(pet.ID, pet.Version)).
+		RunWith(tx).Exec()
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
 	}
 
-	// фиксация транзакции
-	err = tx.Commit()
+	err = checkVersionUpdated(res)
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
 	}
 
-	return pet, nil
-}
-
-func (pr PetRepository) UpdatePet(ctx context.Context, pet models.Pet) (models.Pet, error) {
-	tx, err := pr.db.BeginTx(ctx, nil)
-	if err != nil {
-		return models.Pet{}, err
+	// удаляем только те фото, которых больше нет в списке, чтобы
+	// не потерять данные загруженных файлов
+	removed := sq.And{
+		sq.Eq{"pet_id": pet.ID},
+		sq.NotEq{"photo_url": pet.PhotoUrls},
 	}
-	defer tx.Rollback()
 
-	// создание/обновление категории
-	_, err = sq.Replace(categoriesTable).
-		Columns("id", "name").
-		Values(pet.Category.ID, pet.Category.Name).
-		RunWith(tx).Exec()
+	keys, err := photoBlobKeys(ctx, tx, removed)
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
 	}
 
-	// обновление питомца
-	_, err = sq.Update(petsTable).
-		SetMap(map[string]interface{}{
-			"name":        pet.Name,
-			"category_id": pet.Category.ID,
-			"status":      pet.Status,
-		}).
-		Where(sq.Eq{"id": pet.ID}).
-		RunWith(tx).Exec()
+	err = deletePhotos(ctx, tx, removed)
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
 	}
 
-	// сначала снести фото
-	_, err = sq.Delete(photosTable).
+	var existingUrls []string
+	rows, err := sq.Select("photo_url").
+		From(photosTable).
 		Where(sq.Eq{"pet_id": pet.ID}).
-		RunWith(tx).Exec()
+		RunWith(tx).QueryContext(ctx)
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
 	}
 
-	// добавление фото
+	for rows.Next() {
+		var url string
+		if err = rows.Scan(&url); err != nil {
+			rows.Close()
+			return models.Pet{}, nil, err
+		}
+		existingUrls = append(existingUrls, url)
+	}
+	rows.Close()
+
+	// добавление новых фото
 	insertBuilder := sq.Insert(photosTable).Columns("pet_id", "photo_url")
+	newPhotos := 0
 	for _, photo := range pet.PhotoUrls {
+		if containsString(existingUrls, photo) {
+			continue
+		}
+		existingUrls = append(existingUrls, photo)
 		insertBuilder = insertBuilder.Values(pet.ID, photo)
+		newPhotos++
 	}
 
-	_, err = insertBuilder.RunWith(tx).Exec()
-	if err != nil {
-		return models.Pet{}, err
-	}
-
-	// создание/обновление тегов
-	insertBuilder = sq.Replace(tagsTable).Columns("id", "name")
-	for _, tag := range pet.Tags {
-		insertBuilder = insertBuilder.Values(tag.ID, tag.Name)
+	if newPhotos > 0 {
+		_, err = insertBuilder.RunWith(tx).Exec()
+		if err != nil {
+			return models.Pet{}, nil, err
+		}
 	}
 
-	_, err = insertBuilder.RunWith(tx).Exec()
+	// теги должны уже существовать
+	pet.Tags, err = pr.resolveTags(ctx, tx, pet.Tags)
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
 	}
 
 	// сначала удаляем принадлежность тегов
@@ -192,268 +278,453 @@ func (pr PetRepository) UpdatePet(ctx context.Context, pet models.Pet) (models.P
 		Where(sq.Eq{"pet_id": pet.ID}).
 		RunWith(tx).Exec()
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
+	}
+
+	if len(pet.Tags) > 0 {
+		// добавляние принадлежности тегов
+		insertBuilder = sq.Insert(tagPetsTable).Columns("pet_id", "tag_id")
+		for _, tag := range pet.Tags {
+			insertBuilder = insertBuilder.Values(pet.ID, tag.ID)
+		}
+
+		_, err = insertBuilder.RunWith(tx).Exec()
+		if err != nil {
+			return models.Pet{}, nil, err
+		}
 	}
 
-	// добавляние принадлежности тегов
-	insertBuilder = sq.Insert(tagPetsTable).Columns("pet_id", "tag_id")
-	for _, tag := range pet.Tags {
-		insertBuilder = insertBuilder.Values(pet.ID, tag.ID)
+	err = sq.Select("version").
+		From(petsTable).
+		Where(sq.Eq{"id": pet.ID}).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&pet.Version)
+	if err != nil {
+		return models.Pet{}, nil, err
 	}
 
-	_, err = insertBuilder.RunWith(tx).Exec()
+	// файлы удаленных фото могут быть нужны другим питомцам
+	unreferenced, err := unreferencedKeys(ctx, tx, keys)
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
 	}
 
 	// фиксация транзакции
 	err = tx.Commit()
 	if err != nil {
-		return models.Pet{}, err
+		return models.Pet{}, nil, err
 	}
 
-	return pet, nil
+	return pet, unreferenced, nil
 }
 
 func (pr PetRepository) FindPetsByStatus(ctx context.Context, status []string) ([]models.Pet, error) {
-	rows, err := sq.Select(
-		"pets.id",
-		"pets.name",
-		"pets.category_id",
-		"pets.status",
-		"categories.id",
-		"categories.name",
-		"pet_photos.photo_url",
-		"tags.id",
-		"tags.name",
-	).
-		From(petsTable).
-		LeftJoin("categories ON pets.category_id = categories.id").
-		LeftJoin("pet_photos ON pets.id = pet_photos.pet_id").
-		LeftJoin("tag_pets ON pets.id = tag_pets.pet_id").
-		LeftJoin("tags ON tag_pets.tag_id = tags.id").
-		Where(sq.Eq{"pets.status": status}).
-		RunWith(pr.db).
-		QueryContext(ctx)
-	if err != nil {
-		return nil, err
+	return pr.findPets(ctx, sq.Eq{"pets.status": status})
+}
+
+func (pr PetRepository) FindPetsByTags(ctx context.Context, filter models.PetTagFilter) ([]models.Pet, error) {
+	conditions := make([]sq.Sqlizer, 0, len(filter.Names)+len(filter.IDs))
+
+	// каждое условие - наличие у питомца конкретного тега
+	for _, name := range filter.Names {
+		conditions = append(conditions, hasTagName(name))
 	}
 
-	type PetRow struct {
-		ID         sql.NullInt64
-		Name       sql.NullString
-		CategoryID sql.NullInt64
-		Status     sql.NullString
-		Category   struct {
-			ID   sql.NullInt64
-			Name sql.NullString
-		}
-		PhotoUrls []sql.NullString
-		Tags      []struct {
-			ID   sql.NullInt64
-			Name sql.NullString
-		}
+	for _, id := range filter.IDs {
+		conditions = append(conditions, sq.Expr(
+			"EXISTS (SELECT 1 FROM tag_pets WHERE tag_pets.pet_id = pets.id AND tag_pets.tag_id = ?)",
+			id,
+		))
+	}
+
+	if len(conditions) == 0 {
+		return []models.Pet{}, nil
 	}
 
-	var petRowArr []*PetRow
+	var tagsCondition sq.Sqlizer = sq.Or(conditions)
+	if filter.Match == models.TagMatchAll {
+		tagsCondition = sq.And(conditions)
+	}
 
-	for rows.Next() {
-		var petRow PetRow
-		var photo sql.NullString
-		var tag struct {
-			ID   sql.NullInt64
-			Name sql.NullString
-		}
+	return pr.findPets(ctx, sq.And{
+		tagsCondition,
+		sq.NotEq{"pets.status": "deleted"},
+	})
+}
 
-		err = rows.Scan(
-			&petRow.ID,
-			&petRow.Name,
-			&petRow.CategoryID,
-			&petRow.Status,
-			&petRow.Category.ID,
-			&petRow.Category.Name,
-			&photo,
-			&tag.ID,
-			&tag.Name,
-		)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
+// petSortColumns - выражения для сортировки списка питомцев по полю.
+var petSortColumns = map[string]string{
+	models.PetSortID:     "pets.id",
+	models.PetSortName:   "pets.name",
+	models.PetSortStatus: "COALESCE(pets.status, '')",
+}
 
-		var existingPet *PetRow
-		for _, p := range petRowArr {
-			if p.ID.Valid && p.ID.Int64 == petRow.ID.Int64 {
-				existingPet = p
-				break
-			}
-		}
+// ListPets возвращает не больше query.Limit питомцев, следующих за курсором after
+// в порядке сортировки. Сначала выбираются только ID страницы, затем по ним
+// загружаются сами питомцы с фото и тегами.
+func (pr PetRepository) ListPets(ctx context.Context, query models.PetListQuery, after *models.PetCursor) ([]models.Pet, error) {
+	sortColumn, ok := petSortColumns[query.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", query.Sort)
+	}
 
-		if existingPet != nil {
-			if photo.Valid {
-				existingPet.PhotoUrls = append(existingPet.PhotoUrls, photo)
-			}
+	where := sq.And{}
 
-			if tag.ID.Valid {
-				existingPet.Tags = append(existingPet.Tags, tag)
-			}
-		} else {
-			if !photo.Valid {
-				petRow.PhotoUrls = []sql.NullString{}
-			} else {
-				petRow.PhotoUrls = append(petRow.PhotoUrls, photo)
-			}
+	if len(query.Status) > 0 {
+		where = append(where, sq.Eq{"pets.status": query.Status})
+	} else {
+		where = append(where, sq.NotEq{"pets.status": "deleted"})
+	}
 
-			if !tag.ID.Valid {
-				petRow.Tags = []struct {
-					ID   sql.NullInt64
-					Name sql.NullString
-				}{}
-			} else {
-				petRow.Tags = append(petRow.Tags, tag)
-			}
+	if query.CategoryID != 0 {
+		where = append(where, sq.Eq{"pets.category_id": query.CategoryID})
+	}
 
-			petRowArr = append(petRowArr, &petRow)
-		}
+	for _, name := range query.Tags {
+		where = append(where, hasTagName(name))
 	}
 
-	result := make([]models.Pet, len(petRowArr))
-	for i, p := range petRowArr {
-		result[i] = models.Pet{
-			ID:     int(p.ID.Int64),
-			Name:   p.Name.String,
-			Status: p.Status.String,
-			Category: models.Category{
-				ID:   int(p.Category.ID.Int64),
-				Name: p.Category.Name.String,
-			},
-		}
+	if query.NamePrefix != "" {
+		where = append(where, sq.Expr(`pets.name LIKE ? ESCAPE '\'`, escapeLike(query.NamePrefix)+"%"))
+	}
 
-		for _, photo := range p.PhotoUrls {
-			result[i].PhotoUrls = append(result[i].PhotoUrls, photo.String)
+	op, direction := ">", "ASC"
+	if query.Desc {
+		op, direction = "<", "DESC"
+	}
+
+	// keyset: строки строго после курсора, при равном значении поля - по ID
+	if after != nil {
+		if query.Sort == models.PetSortID {
+			where = append(where, sq.Expr("pets.id "+op+" ?", after.ID))
+		} else {
+			where = append(where, sq.Expr(
+				fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND pets.id %[2]s ?))", sortColumn, op),
+				after.Value, after.Value, after.ID,
+			))
 		}
+	}
+
+	orderBy := []string{sortColumn + " " + direction}
+	if query.Sort != models.PetSortID {
+		orderBy = append(orderBy, "pets.id "+direction)
+	}
 
-		for _, tag := range p.Tags {
-			result[i].Tags = append(result[i].Tags, models.Tag{
-				ID:   int(tag.ID.Int64),
-				Name: tag.Name.String,
-			})
+	rows, err := sq.Select("pets.id").
+		From(petsTable).
+		Where(where).
+		OrderBy(orderBy...).
+		Limit(uint64(query.Limit)).
+		RunWith(pr.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
 		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return []models.Pet{}, nil
+	}
+
+	pets, err := pr.findPets(ctx, sq.Eq{"pets.id": ids})
+	if err != nil {
+		return nil, err
+	}
+
+	// findPets сортирует по ID, возвращаем порядок страницы
+	position := make(map[int]int, len(ids))
+	for i, id := range ids {
+		position[id] = i
+	}
+
+	result := make([]models.Pet, len(ids))
+	for _, pet := range pets {
+		result[position[pet.ID]] = pet
 	}
 
 	return result, nil
 }
 
-func (pr PetRepository) FindPetsByTags(ctx context.Context, tags []string) ([]models.Pet, error) {
-	return nil, nil
-}
+// SearchPets ищет неудаленных питомцев в полнотекстовом индексе по выражению
+// FTS5 MATCH. Совпадения в имени весят больше, чем в тегах и категории.
+func (pr PetRepository) SearchPets(ctx context.Context, match string, limit int) ([]models.PetSearchResult, error) {
+	var indexed bool
+	err := pr.conn(ctx).QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'pets_fts')",
+	).Scan(&indexed)
+	if err != nil {
+		return nil, err
+	}
 
-func (r PetRepository) GetPetById(ctx context.Context, id int) (models.Pet, error) {
-	var exists bool
-	err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pets WHERE id = ?)", id).Scan(&exists)
+	if !indexed {
+		return nil, models.ErrSearchUnavailable
+	}
+
+	rows, err := sq.Select(
+		"pets_fts.rowid",
+		"bm25(pets_fts, 10.0, 2.0, 5.0) AS rank",
+		"highlight(pets_fts, 0, '<mark>', '</mark>')",
+		"snippet(pets_fts, -1, '<mark>', '</mark>', '…', 8)",
+	).
+		From("pets_fts").
+		Join("pets ON pets.id = pets_fts.rowid").
+		Where("pets_fts MATCH ?", match).
+		Where(sq.NotEq{"pets.status": "deleted"}).
+		OrderBy("rank", "pets.id").
+		Limit(uint64(limit)).
+		RunWith(pr.conn(ctx)).
+		QueryContext(ctx)
 	if err != nil {
-		return models.Pet{}, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	if !exists {
-		return models.Pet{}, errors.New("pet not found")
+	var results []models.PetSearchResult
+	var ids []int
+	for rows.Next() {
+		var result models.PetSearchResult
+		err = rows.Scan(&result.Pet.ID, &result.Rank, &result.Highlight, &result.Snippet)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+		ids = append(ids, result.Pet.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return []models.PetSearchResult{}, nil
+	}
+
+	pets, err := pr.findPets(ctx, sq.Eq{"pets.id": ids})
+	if err != nil {
+		return nil, err
 	}
 
-	var pet models.Pet
+	byID := make(map[int]models.Pet, len(pets))
+	for _, pet := range pets {
+		byID[pet.ID] = pet
+	}
+
+	for i := range results {
+		results[i].Pet = byID[results[i].Pet.ID]
+	}
+
+	return results, nil
+}
+
+// hasTagName - условие наличия у питомца тега с указанным именем.
+func hasTagName(name string) sq.Sqlizer {
+	return sq.Expr(
+		"EXISTS (SELECT 1 FROM tag_pets JOIN tags ON tag_pets.tag_id = tags.id WHERE tag_pets.pet_id = pets.id AND tags.name = ?)",
+		name,
+	)
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы префикс искался буквально.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
 
+// petBatchSize - сколько ID питомцев передается в один запрос при догрузке фото и тегов.
+// Держится ниже лимита параметров запроса SQLite (999 в старых сборках).
+const petBatchSize = 500
+
+// findPets выбирает питомцев по условию вместе с категорией, а фото и теги догружает
+// отдельными запросами по порциям ID. Соединение питомцев сразу с фото и тегами
+// давало бы по строке на каждую пару фото-тег.
+func (pr PetRepository) findPets(ctx context.Context, where sq.Sqlizer) ([]models.Pet, error) {
 	rows, err := sq.Select(
 		"pets.id",
 		"pets.name",
-		"pets.category_id",
 		"pets.status",
+		"pets.version",
 		"categories.id",
 		"categories.name",
-		"pet_photos.photo_url",
-		"tags.id",
-		"tags.name",
 	).
+		Columns(petProfileColumns...).
 		From(petsTable).
 		LeftJoin("categories ON pets.category_id = categories.id").
-		LeftJoin("pet_photos ON pets.id = pet_photos.pet_id").
-		LeftJoin("tag_pets ON pets.id = tag_pets.pet_id").
-		LeftJoin("tags ON tag_pets.tag_id = tags.id").
-		Where(sq.Eq{"pets.id": id}).
-		RunWith(r.db).
+		Where(where).
+		OrderBy("pets.id").
+		RunWith(pr.conn(ctx)).
 		QueryContext(ctx)
 	if err != nil {
-		log.Println(err)
-		return models.Pet{}, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	type PetRow struct {
-		ID         sql.NullInt64
-		Name       sql.NullString
-		CategoryID sql.NullInt64
-		Status     sql.NullString
-		Category   struct {
-			ID   sql.NullInt64
-			Name sql.NullString
+	pets := []models.Pet{}
+	for rows.Next() {
+		var pet models.Pet
+		var name, status, categoryName sql.NullString
+		var version, categoryID sql.NullInt64
+		var profile petProfileRow
+
+		dest := []interface{}{
+			&pet.ID,
+			&name,
+			&status,
+			&version,
+			&categoryID,
+			&categoryName,
 		}
-		PhotoUrls []sql.NullString
-		Tags      []struct {
-			ID   sql.NullInt64
-			Name sql.NullString
+
+		err = rows.Scan(append(dest, profile.scanTargets()...)...)
+		if err != nil {
+			return nil, err
 		}
-	}
 
-	var petRow PetRow
+		pet.Name = name.String
+		pet.Status = status.String
+		pet.Version = int(version.Int64)
+		pet.Category = models.Category{
+			ID:   int(categoryID.Int64),
+			Name: categoryName.String,
+		}
+		profile.apply(&pet)
 
-	for rows.Next() {
-		var photo sql.NullString
-		var tag struct {
-			ID   sql.NullInt64
-			Name sql.NullString
+		pets = append(pets, pet)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(pets); start += petBatchSize {
+		end := start + petBatchSize
+		if end > len(pets) {
+			end = len(pets)
 		}
 
-		err = rows.Scan(
-			&petRow.ID,
-			&petRow.Name,
-			&petRow.CategoryID,
-			&petRow.Status,
-			&petRow.Category.ID,
-			&petRow.Category.Name,
-			&photo,
-			&tag.ID,
-			&tag.Name,
-		)
+		err = pr.loadCollections(ctx, pets[start:end])
 		if err != nil {
-			log.Println(err)
-			return models.Pet{}, err
+			return nil, err
 		}
+	}
+
+	return pets, nil
+}
+
+// loadCollections заполняет ссылки на фото, теги и загруженные фото питомцев,
+// по одному запросу на коллекцию для всей порции.
+func (pr PetRepository) loadCollections(ctx context.Context, pets []models.Pet) error {
+	ids := make([]int, len(pets))
+	for i := range pets {
+		ids[i] = pets[i].ID
+	}
 
-		petRow.PhotoUrls = append(petRow.PhotoUrls, photo)
-		petRow.Tags = append(petRow.Tags, tag)
+	photoUrls, err := pr.loadPhotoUrls(ctx, ids)
+	if err != nil {
+		return err
 	}
 
-	pet.ID = int(petRow.ID.Int64)
-	pet.Name = petRow.Name.String
-	pet.Category.ID = int(petRow.Category.ID.Int64)
-	pet.Category.Name = petRow.Category.Name.String
-	pet.Status = petRow.Status.String
+	tags, err := pr.loadTags(ctx, ids)
+	if err != nil {
+		return err
+	}
 
-	for _, photo := range petRow.PhotoUrls {
-		pet.PhotoUrls = append(pet.PhotoUrls, photo.String)
+	photos, err := pr.loadPhotos(ctx, ids)
+	if err != nil {
+		return err
 	}
 
-	for _, tag := range petRow.Tags {
-		t := models.Tag{
-			ID:   int(tag.ID.Int64),
-			Name: tag.Name.String,
+	for i := range pets {
+		pets[i].PhotoUrls = photoUrls[pets[i].ID]
+		pets[i].Tags = tags[pets[i].ID]
+		pets[i].Photos = photos[pets[i].ID]
+	}
+
+	return nil
+}
+
+// loadPhotoUrls загружает ссылки на фото питомцев без повторов, в порядке добавления.
+// Ключ результата - ID питомца.
+func (pr PetRepository) loadPhotoUrls(ctx context.Context, petIDs []int) (map[int][]string, error) {
+	rows, err := sq.Select("pet_id", "photo_url").
+		From(photosTable).
+		Where(sq.Eq{"pet_id": petIDs}).
+		GroupBy("pet_id", "photo_url").
+		OrderBy("pet_id", "MIN(id)").
+		RunWith(pr.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int][]string)
+	for rows.Next() {
+		var petID int
+		var url string
+		if err = rows.Scan(&petID, &url); err != nil {
+			return nil, err
 		}
+		result[petID] = append(result[petID], url)
+	}
 
-		pet.Tags = append(pet.Tags, t)
+	return result, rows.Err()
+}
+
+// loadTags загружает теги питомцев без повторов, в порядке привязки.
+// Ключ результата - ID питомца.
+func (pr PetRepository) loadTags(ctx context.Context, petIDs []int) (map[int][]models.Tag, error) {
+	rows, err := sq.Select("tag_pets.pet_id", "tags.id", "tags.name").
+		From("tag_pets").
+		Join("tags ON tag_pets.tag_id = tags.id").
+		Where(sq.Eq{"tag_pets.pet_id": petIDs}).
+		GroupBy("tag_pets.pet_id", "tags.id", "tags.name").
+		OrderBy("tag_pets.pet_id", "MIN(tag_pets.id)").
+		RunWith(pr.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return pet, nil
+	result := make(map[int][]models.Tag)
+	for rows.Next() {
+		var petID int
+		var tag models.Tag
+		if err = rows.Scan(&petID, &tag.ID, &tag.Name); err != nil {
+			return nil, err
+		}
+		result[petID] = append(result[petID], tag)
+	}
+
+	return result, rows.Err()
+}
+
+func (r PetRepository) GetPetById(ctx context.Context, id int) (models.Pet, error) {
+	pets, err := r.findPets(ctx, sq.Eq{"pets.id": id})
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	if len(pets) == 0 {
+		return models.Pet{}, errors.New("pet not found")
+	}
+
+	return pets[0], nil
 }
 
-func (r PetRepository) UpdatePetWithForm(ctx context.Context, id int, name string, status string) error {
-	updateMap := sq.Eq{}
+func (r PetRepository) UpdatePetWithForm(ctx context.Context, id int, name string, status string, version int) error {
+	updateMap := sq.Eq{"version": sq.Expr("version + 1")}
 
 	if name == "" && status == "" {
 		return nil
@@ -467,28 +738,125 @@ func (r PetRepository) UpdatePetWithForm(ctx context.Context, id int, name strin
 		updateMap["status"] = status
 	}
 
-	_, err := sq.Update(petsTable).
+	// бронь заказа живет, только пока питомец в pending
+	if status != "" && status != models.PetStatusPending {
+		updateMap["reserved_by_order"] = nil
+	}
+
+	res, err := sq.Update(petsTable).
 		SetMap(updateMap).
-		Where(sq.Eq{"id": id}).
-		RunWith(r.db).ExecContext(ctx)
+		Where(versionCondition(id, version)).
+		RunWith(r.conn(ctx)).ExecContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return checkVersionUpdated(res)
 }
 
-func (r PetRepository) DeletePet(ctx context.Context, id int) error {
-	_, err := sq.Update(petsTable).
+func (r PetRepository) DeletePet(ctx context.Context, id int, version int) error {
+	res, err := sq.Update(petsTable).
 		SetMap(map[string]interface{}{
-			"status": "deleted",
+			"status":            "deleted",
+			"version":           sq.Expr("version + 1"),
+			"reserved_by_order": nil,
 		}).
-		Where(sq.Eq{"id": id}).
-		RunWith(r.db).
+		Where(versionCondition(id, version)).
+		RunWith(r.conn(ctx)).
 		ExecContext(ctx)
 	if err != nil {
 		return err
 	}
 
+	return checkVersionUpdated(res)
+}
+
+// versionCondition выбирает питомца по ID и, если версия указана (не 0), по версии.
+func versionCondition(id int, version int) sq.Sqlizer {
+	if version == 0 {
+		return sq.Eq{"id": id}
+	}
+
+	return sq.Eq{"id": id, "version": version}
+}
+
+// checkVersionUpdated возвращает ErrVersionMismatch, если условное обновление
+// не нашло питомца: существование питомца сервис проверяет заранее, значит
+// не совпала версия.
+func checkVersionUpdated(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrVersionMismatch
+	}
+
 	return nil
 }
+
+// resolveCategory проверяет, что категория питомца существует, и подставляет ее актуальное имя.
+// Возвращает значение для pets.category_id: NULL, если категория не указана.
+func (pr PetRepository) resolveCategory(ctx context.Context, tx *db.Tx, category *models.Category) (interface{}, error) {
+	if category.ID == 0 {
+		*category = models.Category{}
+		return nil, nil
+	}
+
+	err := sq.Select("name").
+		From(categoriesTable).
+		Where(sq.Eq{"id": category.ID}).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&category.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("category %d not found", category.ID)
+		}
+		return nil, err
+	}
+
+	return category.ID, nil
+}
+
+// resolveTags проверяет, что все теги питомца существуют, убирает повторы
+// и подставляет актуальные имена тегов.
+func (pr PetRepository) resolveTags(ctx context.Context, tx *db.Tx, tags []models.Tag) ([]models.Tag, error) {
+	resolved := make([]models.Tag, 0, len(tags))
+	seen := make(map[int]bool, len(tags))
+
+	for _, tag := range tags {
+		if seen[tag.ID] {
+			continue
+		}
+		seen[tag.ID] = true
+
+		err := sq.Select("name").
+			From(tagsTable).
+			Where(sq.Eq{"id": tag.ID}).
+			RunWith(tx).
+			QueryRowContext(ctx).
+			Scan(&tag.Name)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("tag %d not found", tag.ID)
+			}
+			return nil, err
+		}
+
+		resolved = append(resolved, tag)
+	}
+
+	return resolved, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
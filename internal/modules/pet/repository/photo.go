@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"app/internal/infrastructure/db"
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	photoVariantsTable = "pet_photo_variants"
+)
+
+func (r PetRepository) UploadFile(ctx context.Context, photo models.PetPhoto) (models.PetPhoto, error) {
+	tx, err := db.BeginTx(ctx, r.db)
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+	defer tx.Rollback()
+
+	photo.CreatedAt = time.Now().UTC()
+
+	res, err := sq.Insert(photosTable).
+		Columns("pet_id", "photo_url", "file_name", "blob_key", "size", "mime_type", "checksum", "created_at").
+		Values(photo.PetID, "", photo.FileName, photo.BlobKey, photo.Size, photo.MimeType, photo.Checksum, photo.CreatedAt).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	// новое фото меняет питомца, поэтому увеличиваем его версию
+	_, err = sq.Update(petsTable).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": photo.PetID}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	// ссылка строится по ID фото, поэтому проставляем ее после вставки
+	photo.ID = int(id)
+	photo.URL = photoURL(photo.PetID, photo.ID)
+
+	_, err = sq.Update(photosTable).
+		Set("photo_url", photo.URL).
+		Where(sq.Eq{"id": photo.ID}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	return photo, nil
+}
+
+func (r PetRepository) GetPetPhoto(ctx context.Context, petID int, photoID int) (models.PetPhoto, error) {
+	var photo models.PetPhoto
+	var fileName, mimeType sql.NullString
+	var createdAt sql.NullTime
+
+	err := sq.Select(
+		"id",
+		"pet_id",
+		"photo_url",
+		"file_name",
+		"blob_key",
+		"size",
+		"mime_type",
+		"checksum",
+		"created_at",
+	).
+		From(photosTable).
+		Where(sq.And{
+			sq.Eq{"id": photoID},
+			sq.Eq{"pet_id": petID},
+			sq.NotEq{"blob_key": nil},
+		}).
+		RunWith(r.conn(ctx)).
+		QueryRowContext(ctx).
+		Scan(
+			&photo.ID,
+			&photo.PetID,
+			&photo.URL,
+			&fileName,
+			&photo.BlobKey,
+			&photo.Size,
+			&mimeType,
+			&photo.Checksum,
+			&createdAt,
+		)
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	photo.FileName = fileName.String
+	photo.MimeType = mimeType.String
+	photo.CreatedAt = createdAt.Time
+
+	variants, err := r.loadVariants(ctx, []int{photo.ID})
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+	photo.Variants = variants[photo.ID]
+
+	return photo, nil
+}
+
+func (r PetRepository) AddPhotoVariants(ctx context.Context, photoID int, variants []models.PetPhotoVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	insertBuilder := sq.Insert(photoVariantsTable).
+		Columns("photo_id", "max_size", "width", "height", "blob_key", "size", "mime_type", "checksum")
+	for _, v := range variants {
+		insertBuilder = insertBuilder.Values(photoID, v.MaxSize, v.Width, v.Height, v.BlobKey, v.Size, v.MimeType, v.Checksum)
+	}
+
+	_, err := insertBuilder.RunWith(r.conn(ctx)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// loadPhotos загружает загруженные через API фото (с уменьшенными копиями)
+// сразу для набора питомцев, ключ результата - ID питомца.
+func (r PetRepository) loadPhotos(ctx context.Context, petIDs []int) (map[int][]models.PetPhoto, error) {
+	result := make(map[int][]models.PetPhoto)
+	if len(petIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := sq.Select(
+		"id",
+		"pet_id",
+		"photo_url",
+		"file_name",
+		"blob_key",
+		"size",
+		"mime_type",
+		"checksum",
+		"created_at",
+	).
+		From(photosTable).
+		Where(sq.And{
+			sq.Eq{"pet_id": petIDs},
+			sq.NotEq{"blob_key": nil},
+		}).
+		OrderBy("id").
+		RunWith(r.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []models.PetPhoto
+	var photoIDs []int
+
+	for rows.Next() {
+		var photo models.PetPhoto
+		var fileName, mimeType sql.NullString
+		var createdAt sql.NullTime
+
+		err = rows.Scan(
+			&photo.ID,
+			&photo.PetID,
+			&photo.URL,
+			&fileName,
+			&photo.BlobKey,
+			&photo.Size,
+			&mimeType,
+			&photo.Checksum,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		photo.FileName = fileName.String
+		photo.MimeType = mimeType.String
+		photo.CreatedAt = createdAt.Time
+
+		photos = append(photos, photo)
+		photoIDs = append(photoIDs, photo.ID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	variants, err := r.loadVariants(ctx, photoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, photo := range photos {
+		photo.Variants = variants[photo.ID]
+		result[photo.PetID] = append(result[photo.PetID], photo)
+	}
+
+	return result, nil
+}
+
+// loadVariants загружает уменьшенные копии для набора фото, ключ результата - ID фото.
+func (r PetRepository) loadVariants(ctx context.Context, photoIDs []int) (map[int][]models.PetPhotoVariant, error) {
+	result := make(map[int][]models.PetPhotoVariant)
+	if len(photoIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := sq.Select(
+		"pet_photo_variants.photo_id",
+		"pet_photos.pet_id",
+		"pet_photo_variants.max_size",
+		"pet_photo_variants.width",
+		"pet_photo_variants.height",
+		"pet_photo_variants.blob_key",
+		"pet_photo_variants.size",
+		"pet_photo_variants.mime_type",
+		"pet_photo_variants.checksum",
+	).
+		From(photoVariantsTable).
+		Join("pet_photos ON pet_photo_variants.photo_id = pet_photos.id").
+		Where(sq.Eq{"pet_photo_variants.photo_id": photoIDs}).
+		OrderBy("pet_photo_variants.photo_id", "pet_photo_variants.max_size").
+		RunWith(r.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photoID, petID int
+		var v models.PetPhotoVariant
+
+		err = rows.Scan(
+			&photoID,
+			&petID,
+			&v.MaxSize,
+			&v.Width,
+			&v.Height,
+			&v.BlobKey,
+			&v.Size,
+			&v.MimeType,
+			&v.Checksum,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		v.URL = fmt.Sprintf("%s?size=%d", photoURL(petID, photoID), v.MaxSize)
+		result[photoID] = append(result[photoID], v)
+	}
+
+	return result, rows.Err()
+}
+
+func photoURL(petID, photoID int) string {
+	return fmt.Sprintf("/v2/pet/%d/photos/%d", petID, photoID)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"app/internal/models"
+	"database/sql"
+)
+
+// petProfileColumns - колонки расширенного профиля питомца в порядке сканирования.
+var petProfileColumns = []string{
+	"pets.breed",
+	"pets.birth_date",
+	"pets.sex",
+	"pets.weight",
+	"pets.description",
+	"pets.price",
+}
+
+// petProfileRow - поля профиля в том виде, в котором они лежат в базе (все необязательные).
+type petProfileRow struct {
+	Breed       sql.NullString
+	BirthDate   sql.NullString
+	Sex         sql.NullString
+	Weight      sql.NullFloat64
+	Description sql.NullString
+	Price       sql.NullInt64
+}
+
+func (p *petProfileRow) scanTargets() []interface{} {
+	return []interface{}{
+		&p.Breed,
+		&p.BirthDate,
+		&p.Sex,
+		&p.Weight,
+		&p.Description,
+		&p.Price,
+	}
+}
+
+// apply переносит профиль в модель питомца.
+func (p petProfileRow) apply(pet *models.Pet) {
+	pet.Breed = p.Breed.String
+	pet.BirthDate = p.BirthDate.String
+	pet.Sex = p.Sex.String
+	pet.Description = p.Description.String
+
+	if p.Weight.Valid {
+		weight := p.Weight.Float64
+		pet.Weight = &weight
+	}
+	if p.Price.Valid {
+		price := p.Price.Int64
+		pet.Price = &price
+	}
+}
+
+// petProfileValues - значения профиля для вставки и обновления, пустые поля хранятся как NULL.
+func petProfileValues(pet models.Pet) map[string]interface{} {
+	return map[string]interface{}{
+		"breed":       nullString(pet.Breed),
+		"birth_date":  nullString(pet.BirthDate),
+		"sex":         nullString(pet.Sex),
+		"weight":      pet.Weight,
+		"description": nullString(pet.Description),
+		"price":       pet.Price,
+	}
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
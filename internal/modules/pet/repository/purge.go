@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"app/internal/infrastructure/db"
+	"app/internal/models"
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// PurgePet физически удаляет питомца вместе с фото, их копиями, тегами, медицинской
+// картой и позициями корзин. История и заявки на усыновление остаются: по ним видно,
+// кто и когда удалил питомца и кто хотел его забрать; нерассмотренные заявки отклоняются.
+// Питомца из заказа удалить нельзя.
+// Возвращает ключи файлов, на которые после удаления больше никто не ссылается:
+// файлы хранятся по содержимому, и одно фото могло быть загружено нескольким питомцам.
+func (pr PetRepository) PurgePet(ctx context.Context, id int) ([]string, error) {
+	tx, err := db.BeginTx(ctx, pr.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// питомец из корзины попадает в позиции заказа, а не в orders.pet_id
+	var ordered bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM orders WHERE pet_id = ?) OR EXISTS (SELECT 1 FROM order_items WHERE pet_id = ?)",
+		id, id,
+	).Scan(&ordered)
+	if err != nil {
+		return nil, err
+	}
+
+	if ordered {
+		return nil, models.NewConflictError("pet is referenced by orders and cannot be purged")
+	}
+
+	photos := sq.Eq{"pet_id": id}
+
+	keys, err := photoBlobKeys(ctx, tx, photos)
+	if err != nil {
+		return nil, err
+	}
+
+	err = deletePhotos(ctx, tx, photos)
+	if err != nil {
+		return nil, err
+	}
+
+	deletes := []sq.DeleteBuilder{
+		sq.Delete(tagPetsTable).Where(sq.Eq{"pet_id": id}),
+		sq.Delete(medicalTable).Where(sq.Eq{"pet_id": id}),
+		sq.Delete(waitlistTable).Where(sq.Eq{"pet_id": id}),
+		sq.Delete("cart_items").Where(sq.Eq{"pet_id": id}),
+		sq.Delete(petsTable).Where(sq.Eq{"id": id}),
+	}
+
+	_, err = sq.Update("adoption_applications").
+		SetMap(map[string]interface{}{
+			"status":       models.AdoptionStatusRejected,
+			"review_notes": "pet was purged",
+			"reviewed_at":  time.Now().UTC(),
+		}).
+		Where(sq.Eq{"pet_id": id, "status": models.AdoptionStatusSubmitted}).
+		RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, del := range deletes {
+		_, err = del.RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	unreferenced, err := unreferencedKeys(ctx, tx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return unreferenced, nil
+}
+
+// photoBlobKeys возвращает ключи файлов фото, подходящих под условие photos, и их копий.
+func photoBlobKeys(ctx context.Context, tx *db.Tx, photos sq.Sqlizer) ([]string, error) {
+	keys, err := queryStrings(ctx, tx, sq.Select("blob_key").
+		From(photosTable).
+		Where(sq.And{photos, sq.NotEq{"blob_key": nil}}))
+	if err != nil {
+		return nil, err
+	}
+
+	variantKeys, err := queryStrings(ctx, tx, sq.Select("blob_key").
+		From(photoVariantsTable).
+		Where(sq.Expr("photo_id IN (?)", sq.Select("id").From(photosTable).Where(photos))))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(keys, variantKeys...), nil
+}
+
+// deletePhotos удаляет фото, подходящие под условие photos, вместе с их копиями.
+func deletePhotos(ctx context.Context, tx *db.Tx, photos sq.Sqlizer) error {
+	_, err := sq.Delete(photoVariantsTable).
+		Where(sq.Expr("photo_id IN (?)", sq.Select("id").From(photosTable).Where(photos))).
+		RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = sq.Delete(photosTable).Where(photos).RunWith(tx).ExecContext(ctx)
+
+	return err
+}
+
+// unreferencedKeys оставляет из keys ключи файлов, на которые больше не ссылаются
+// ни фото, ни их копии.
+func unreferencedKeys(ctx context.Context, tx *db.Tx, keys []string) ([]string, error) {
+	var unreferenced []string
+	for _, key := range keys {
+		if containsString(unreferenced, key) {
+			continue
+		}
+
+		referenced, err := blobReferenced(ctx, tx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if !referenced {
+			unreferenced = append(unreferenced, key)
+		}
+	}
+
+	return unreferenced, nil
+}
+
+// BlobReferenced проверяет, ссылаются ли на файл key фото или их копии.
+func (pr PetRepository) BlobReferenced(ctx context.Context, key string) (bool, error) {
+	return blobReferenced(ctx, pr.conn(ctx), key)
+}
+
+func blobReferenced(ctx context.Context, conn db.Conn, key string) (bool, error) {
+	var referenced bool
+	err := conn.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pet_photos WHERE blob_key = ?) OR EXISTS (SELECT 1 FROM pet_photo_variants WHERE blob_key = ?)",
+		key, key,
+	).Scan(&referenced)
+
+	return referenced, err
+}
+
+func queryStrings(ctx context.Context, tx *db.Tx, query sq.SelectBuilder) ([]string, error) {
+	rows, err := query.RunWith(tx).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err = rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
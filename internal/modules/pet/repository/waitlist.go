@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"app/internal/infrastructure/db"
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const waitlistTable = "pet_waitlist"
+
+func scanWaitlistEntry(row sq.RowScanner, extra ...interface{}) (models.PetWaitlistEntry, error) {
+	var entry models.PetWaitlistEntry
+	var notifiedAt sql.NullTime
+
+	dest := []interface{}{&entry.ID, &entry.PetID, &entry.UserName, &entry.CreatedAt, &notifiedAt}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return models.PetWaitlistEntry{}, err
+	}
+
+	if notifiedAt.Valid {
+		entry.NotifiedAt = &notifiedAt.Time
+	}
+
+	return entry, nil
+}
+
+// JoinWaitlist записывает пользователя в лист ожидания питомца. Если запись уже есть,
+// она снова ждет уведомления.
+func (pr PetRepository) JoinWaitlist(ctx context.Context, petID int, userName string) (models.PetWaitlistEntry, error) {
+	_, err := sq.Insert(waitlistTable).
+		Columns("pet_id", "username", "created_at").
+		Values(petID, userName, time.Now().UTC()).
+		Suffix("ON CONFLICT (pet_id, username) DO UPDATE SET notified_at = NULL, created_at = excluded.created_at").
+		RunWith(pr.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return models.PetWaitlistEntry{}, err
+	}
+
+	return scanWaitlistEntry(sq.Select("id", "pet_id", "username", "created_at", "notified_at").
+		From(waitlistTable).
+		Where(sq.Eq{"pet_id": petID, "username": userName}).
+		RunWith(pr.conn(ctx)).
+		QueryRowContext(ctx))
+}
+
+func (pr PetRepository) LeaveWaitlist(ctx context.Context, petID int, userName string) error {
+	res, err := sq.Delete(waitlistTable).
+		Where(sq.Eq{"pet_id": petID, "username": userName}).
+		RunWith(pr.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkRowAffected(res)
+}
+
+// ListWaitlist возвращает лист ожидания питомца в порядке записи,
+// с непустым userName - только запись этого пользователя.
+func (pr PetRepository) ListWaitlist(ctx context.Context, petID int, userName string) ([]models.PetWaitlistEntry, error) {
+	where := sq.Eq{"pet_id": petID}
+	if userName != "" {
+		where["username"] = userName
+	}
+
+	rows, err := sq.Select("id", "pet_id", "username", "created_at", "notified_at").
+		From(waitlistTable).
+		Where(where).
+		OrderBy("created_at", "id").
+		RunWith(pr.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.PetWaitlistEntry{}
+	for rows.Next() {
+		entry, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// TakeWaitlist выбирает еще не уведомленных пользователей из листа ожидания вместе
+// с их почтой и отмечает их уведомленными. Выборка и отметка идут в одной транзакции,
+// чтобы при двух одновременных возвратах питомца в продажу никто не получил два уведомления.
+func (pr PetRepository) TakeWaitlist(ctx context.Context, petID int) ([]models.PetWaitlistEntry, error) {
+	tx, err := db.BeginTx(ctx, pr.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// по времени отметки потом выбираются отмеченные записи, а Postgres хранит только микросекунды
+	notifiedAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	// сначала отмечаем: запись блокирует таблицу до конца транзакции
+	_, err = sq.Update(waitlistTable).
+		Set("notified_at", notifiedAt).
+		Where(sq.Eq{"pet_id": petID, "notified_at": nil}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sq.Select(
+		"pet_waitlist.id",
+		"pet_waitlist.pet_id",
+		"pet_waitlist.username",
+		"pet_waitlist.created_at",
+		"pet_waitlist.notified_at",
+		"users.email",
+	).
+		From(waitlistTable).
+		LeftJoin("users ON users.username = pet_waitlist.username").
+		Where(sq.Eq{"pet_waitlist.pet_id": petID, "pet_waitlist.notified_at": notifiedAt}).
+		OrderBy("pet_waitlist.created_at", "pet_waitlist.id").
+		RunWith(tx).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.PetWaitlistEntry
+	for rows.Next() {
+		var email sql.NullString
+
+		entry, err := scanWaitlistEntry(rows, &email)
+		if err != nil {
+			return nil, err
+		}
+		entry.Email = email.String
+
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, tx.Commit()
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	customMiddleware "app/internal/infrastructure/middleware"
+	"app/internal/models"
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// GetPetHistory возвращает историю питомца. История переживает окончательное
+// удаление питомца, поэтому питомец проверяется, только если записей нет.
+func (s *PetService) GetPetHistory(ctx context.Context, petID int) ([]models.PetHistoryEntry, error) {
+	history, err := s.petRepository.GetPetHistory(ctx, petID)
+	if err != nil || len(history) > 0 {
+		return history, err
+	}
+
+	_, err = s.GetPetById(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// recordHistory записывает изменение питомца в журнал от имени пользователя
+// из JWT запроса. Вызывается в транзакции изменения (PetRepository.InTx): если
+// журнал записать не удалось, изменение тоже откатывается.
+func (s *PetService) recordHistory(ctx context.Context, petID int, action string, changes map[string]models.PetFieldChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return s.petRepository.AddPetHistory(ctx, models.PetHistoryEntry{
+		PetID:     petID,
+		Action:    action,
+		Changes:   changes,
+		UserName:  customMiddleware.UserNameFromContext(ctx),
+		RequestID: middleware.GetReqID(ctx),
+	})
+}
+
+// diffPets возвращает поля, которые отличаются у before и after.
+// Если before == nil (питомец создан), в изменения попадают все заполненные поля.
+func diffPets(before *models.Pet, after models.Pet) map[string]models.PetFieldChange {
+	newFields := petFields(after)
+
+	changes := make(map[string]models.PetFieldChange)
+	if before == nil {
+		for name, value := range newFields {
+			if value != nil {
+				changes[name] = models.PetFieldChange{New: value}
+			}
+		}
+
+		return changes
+	}
+
+	oldFields := petFields(*before)
+	for name, value := range newFields {
+		if !reflect.DeepEqual(oldFields[name], value) {
+			changes[name] = models.PetFieldChange{Old: oldFields[name], New: value}
+		}
+	}
+
+	return changes
+}
+
+// petFields - значения полей питомца в виде, пригодном для сравнения:
+// пустые значения равны nil, фото и теги без повторов и отсортированы.
+func petFields(pet models.Pet) map[string]interface{} {
+	fields := map[string]interface{}{
+		"name":        nil,
+		"status":      nil,
+		"category":    nil,
+		"photoUrls":   nil,
+		"tags":        nil,
+		"breed":       nil,
+		"birthDate":   nil,
+		"sex":         nil,
+		"weight":      nil,
+		"description": nil,
+		"price":       nil,
+	}
+
+	if pet.Name != "" {
+		fields["name"] = pet.Name
+	}
+
+	if pet.Status != "" {
+		fields["status"] = pet.Status
+	}
+
+	if pet.Category.ID != 0 {
+		fields["category"] = pet.Category
+	}
+
+	if urls := uniqueSorted(pet.PhotoUrls); len(urls) > 0 {
+		fields["photoUrls"] = urls
+	}
+
+	if tags := uniqueTags(pet.Tags); len(tags) > 0 {
+		fields["tags"] = tags
+	}
+
+	if pet.Breed != "" {
+		fields["breed"] = pet.Breed
+	}
+
+	if pet.BirthDate != "" {
+		fields["birthDate"] = pet.BirthDate
+	}
+
+	if pet.Sex != "" {
+		fields["sex"] = pet.Sex
+	}
+
+	if pet.Weight != nil {
+		fields["weight"] = *pet.Weight
+	}
+
+	if pet.Description != "" {
+		fields["description"] = pet.Description
+	}
+
+	if pet.Price != nil {
+		fields["price"] = *pet.Price
+	}
+
+	return fields
+}
+
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func uniqueTags(tags []models.Tag) []models.Tag {
+	seen := make(map[int]bool, len(tags))
+	result := make([]models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		if tag.ID == 0 || seen[tag.ID] {
+			continue
+		}
+		seen[tag.ID] = true
+		result = append(result, tag)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	customMiddleware "app/internal/infrastructure/middleware"
+	"app/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxMedicalTitleLength = 200
+	maxMedicalVetLength   = 200
+	maxMedicalNotesLength = 4000
+)
+
+var medicalKinds = map[string]bool{
+	models.MedicalKindVaccination: true,
+	models.MedicalKindVetVisit:    true,
+	models.MedicalKindTreatment:   true,
+}
+
+func (s *PetService) ListMedicalRecords(ctx context.Context, petID int) ([]models.PetMedicalRecord, error) {
+	_, err := s.GetPetById(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.petRepository.ListMedicalRecords(ctx, petID)
+}
+
+func (s *PetService) GetMedicalRecord(ctx context.Context, petID int, recordID int) (models.PetMedicalRecord, error) {
+	return s.petRepository.GetMedicalRecord(ctx, petID, recordID)
+}
+
+// AddMedicalRecord добавляет запись в медицинскую карту от имени пользователя из JWT.
+func (s *PetService) AddMedicalRecord(ctx context.Context, record models.PetMedicalRecord) (models.PetMedicalRecord, error) {
+	err := s.checkMedicalPet(ctx, record.PetID)
+	if err != nil {
+		return models.PetMedicalRecord{}, err
+	}
+
+	err = validateMedicalRecord(record)
+	if err != nil {
+		return models.PetMedicalRecord{}, err
+	}
+
+	record.CreatedBy = customMiddleware.UserNameFromContext(ctx)
+	record.CreatedAt = time.Time{}
+
+	var created models.PetMedicalRecord
+	err = s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.petRepository.AddMedicalRecord(ctx, record)
+		if err != nil {
+			return err
+		}
+
+		return s.recordMedicalHistory(ctx, models.PetActionAddMedicalRecord, nil, &created)
+	})
+	if err != nil {
+		return models.PetMedicalRecord{}, err
+	}
+
+	return created, nil
+}
+
+func (s *PetService) UpdateMedicalRecord(ctx context.Context, record models.PetMedicalRecord) (models.PetMedicalRecord, error) {
+	err := s.checkMedicalPet(ctx, record.PetID)
+	if err != nil {
+		return models.PetMedicalRecord{}, err
+	}
+
+	err = validateMedicalRecord(record)
+	if err != nil {
+		return models.PetMedicalRecord{}, err
+	}
+
+	var updated models.PetMedicalRecord
+	err = s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		current, err := s.petRepository.GetMedicalRecord(ctx, record.PetID, record.ID)
+		if err != nil {
+			return err
+		}
+
+		err = s.petRepository.UpdateMedicalRecord(ctx, record)
+		if err != nil {
+			return err
+		}
+
+		updated, err = s.petRepository.GetMedicalRecord(ctx, record.PetID, record.ID)
+		if err != nil {
+			return err
+		}
+
+		return s.recordMedicalHistory(ctx, models.PetActionUpdateMedicalRecord, &current, &updated)
+	})
+	if err != nil {
+		return models.PetMedicalRecord{}, err
+	}
+
+	return updated, nil
+}
+
+func (s *PetService) DeleteMedicalRecord(ctx context.Context, petID int, recordID int) error {
+	return s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		err := s.checkMedicalPet(ctx, petID)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.petRepository.GetMedicalRecord(ctx, petID, recordID)
+		if err != nil {
+			return err
+		}
+
+		err = s.petRepository.DeleteMedicalRecord(ctx, petID, recordID)
+		if err != nil {
+			return err
+		}
+
+		return s.recordMedicalHistory(ctx, models.PetActionDeleteMedicalRecord, &current, nil)
+	})
+}
+
+// recordMedicalHistory записывает в журнал питомца изменение записи медицинской карты:
+// before == nil - запись добавлена, after == nil - удалена.
+func (s *PetService) recordMedicalHistory(ctx context.Context, action string, before *models.PetMedicalRecord, after *models.PetMedicalRecord) error {
+	var change models.PetFieldChange
+	petID := 0
+	if before != nil {
+		change.Old = *before
+		petID = before.PetID
+	}
+	if after != nil {
+		change.New = *after
+		petID = after.PetID
+	}
+
+	return s.recordHistory(ctx, petID, action, map[string]models.PetFieldChange{
+		"medicalRecord": change,
+	})
+}
+
+// FindOverdueVaccinations возвращает просроченные на дату asOf (YYYY-MM-DD, по умолчанию
+// сегодня) прививки питомцев, самые давние первыми.
+func (s *PetService) FindOverdueVaccinations(ctx context.Context, asOf string) ([]models.OverdueVaccination, error) {
+	date := time.Now().UTC().Truncate(24 * time.Hour)
+	if asOf != "" {
+		var err error
+		date, err = time.Parse(dateLayout, asOf)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", asOf)
+		}
+	}
+
+	overdue, err := s.petRepository.FindOverdueVaccinations(ctx, date.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range overdue {
+		dueDate, err := time.Parse(dateLayout, overdue[i].Record.DueDate)
+		if err != nil {
+			continue
+		}
+		overdue[i].DaysOverdue = int(date.Sub(dueDate).Hours() / 24)
+	}
+
+	return overdue, nil
+}
+
+// checkMedicalPet проверяет, что питомец существует и не удален: медицинскую карту
+// удаленного питомца можно посмотреть, но не изменить.
+func (s *PetService) checkMedicalPet(ctx context.Context, petID int) error {
+	pet, err := s.GetPetById(ctx, petID)
+	if err != nil {
+		return err
+	}
+
+	if pet.Status == models.PetStatusDeleted {
+		return models.NewConflictError("medical records of a deleted pet cannot be changed")
+	}
+
+	return nil
+}
+
+// validateMedicalRecord проверяет вид записи, даты и длину текстовых полей.
+// Дата следующей прививки есть только у прививок и должна быть позже самой прививки.
+func validateMedicalRecord(record models.PetMedicalRecord) error {
+	if !medicalKinds[record.Kind] {
+		return fmt.Errorf("invalid medical record kind %q, expected one of %q, %q, %q", record.Kind,
+			models.MedicalKindVaccination, models.MedicalKindVetVisit, models.MedicalKindTreatment)
+	}
+
+	if record.Title == "" {
+		return errors.New("medical record title is required")
+	}
+
+	if utf8.RuneCountInString(record.Title) > maxMedicalTitleLength {
+		return fmt.Errorf("title must be at most %d characters", maxMedicalTitleLength)
+	}
+
+	if utf8.RuneCountInString(record.Vet) > maxMedicalVetLength {
+		return fmt.Errorf("vet must be at most %d characters", maxMedicalVetLength)
+	}
+
+	if utf8.RuneCountInString(record.Notes) > maxMedicalNotesLength {
+		return fmt.Errorf("notes must be at most %d characters", maxMedicalNotesLength)
+	}
+
+	date, err := time.Parse(dateLayout, record.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", record.Date)
+	}
+
+	if date.After(time.Now()) {
+		return errors.New("medical record date cannot be in the future")
+	}
+
+	if record.DueDate == "" {
+		return nil
+	}
+
+	if record.Kind != models.MedicalKindVaccination {
+		return errors.New("due date is only allowed for vaccinations")
+	}
+
+	dueDate, err := time.Parse(dateLayout, record.DueDate)
+	if err != nil {
+		return fmt.Errorf("invalid due date %q, expected YYYY-MM-DD", record.DueDate)
+	}
+
+	if !dueDate.After(date) {
+		return errors.New("due date must be after the vaccination date")
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"app/internal/infrastructure/jsonpatch"
+	"app/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PatchPet применяет к питомцу частичное изменение и сохраняет результат как UpdatePet.
+// Патч применяется к прочитанной версии питомца, поэтому если питомца изменили
+// параллельно, сохранение не затрет чужие изменения: с If-Match вернет ErrVersionMismatch,
+// без него - ConflictError, как UpdatePet.
+func (s *PetService) PatchPet(ctx context.Context, id int, patch jsonpatch.Patcher, version int) (models.Pet, error) {
+	current, err := s.GetPetById(ctx, id)
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	if version != 0 && version != current.Version {
+		return models.Pet{}, models.ErrVersionMismatch
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	doc, err = patch.Apply(doc)
+	if err != nil {
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return models.Pet{}, models.NewConflictError(err.Error())
+		}
+		return models.Pet{}, err
+	}
+
+	var pet models.Pet
+	if err = json.Unmarshal(doc, &pet); err != nil {
+		return models.Pet{}, fmt.Errorf("%w: %v", jsonpatch.ErrInvalidPatch, err)
+	}
+
+	if pet.ID != id {
+		return models.Pet{}, errors.New("pet id cannot be changed")
+	}
+
+	pet.Version = current.Version
+
+	updated, err := s.UpdatePet(ctx, pet)
+	if err != nil {
+		return models.Pet{}, concurrentChangeError(err, version != 0)
+	}
+
+	return updated, nil
+}
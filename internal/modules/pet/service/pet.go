@@ -1,86 +1,608 @@
 package service
 
 import (
+	"app/internal/infrastructure/imaging"
+	"app/internal/infrastructure/jsonpatch"
+	"app/internal/infrastructure/storage"
 	"app/internal/models"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"mime/multipart"
+	"strings"
+	"unicode"
 )
 
 type PetServicer interface {
-	UploadFile(ctx context.Context, id int, file multipart.File, fileHeader *multipart.FileHeader) error
+	UploadFile(ctx context.Context, id int, file multipart.File, fileHeader *multipart.FileHeader) (models.PetPhoto, error)
+	OpenPetPhoto(ctx context.Context, petID int, photoID int, size int) (models.PetPhoto, *storage.Blob, error)
 	AddPet(ctx context.Context, body models.Pet) (models.Pet, error)
 	UpdatePet(ctx context.Context, body models.Pet) (models.Pet, error)
+	PatchPet(ctx context.Context, id int, patch jsonpatch.Patcher, version int) (models.Pet, error)
 	FindPetsByStatus(ctx context.Context, status []string) ([]models.Pet, error)
-	FindPetsByTags(ctx context.Context, tags []string) ([]models.Pet, error)
+	FindPetsByTags(ctx context.Context, filter models.PetTagFilter) ([]models.Pet, error)
+	ListPets(ctx context.Context, query models.PetListQuery) (models.PetPage, error)
+	SearchPets(ctx context.Context, text string, limit int) ([]models.PetSearchResult, error)
 	GetPetById(ctx context.Context, id int) (models.Pet, error)
-	UpdatePetWithForm(ctx context.Context, id int, name string, status string) error
+	UpdatePetWithForm(ctx context.Context, id int, name string, status string, version int) error
 	DeletePet(ctx context.Context, id int) error
+	GetPetHistory(ctx context.Context, petID int) ([]models.PetHistoryEntry, error)
+	RestorePet(ctx context.Context, id int) (models.Pet, error)
+	PurgePet(ctx context.Context, id int) error
+	ListMedicalRecords(ctx context.Context, petID int) ([]models.PetMedicalRecord, error)
+	GetMedicalRecord(ctx context.Context, petID int, recordID int) (models.PetMedicalRecord, error)
+	AddMedicalRecord(ctx context.Context, record models.PetMedicalRecord) (models.PetMedicalRecord, error)
+	UpdateMedicalRecord(ctx context.Context, record models.PetMedicalRecord) (models.PetMedicalRecord, error)
+	DeleteMedicalRecord(ctx context.Context, petID int, recordID int) error
+	FindOverdueVaccinations(ctx context.Context, asOf string) ([]models.OverdueVaccination, error)
+	JoinWaitlist(ctx context.Context, petID int) (models.PetWaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, petID int) error
+	ListWaitlist(ctx context.Context, petID int) ([]models.PetWaitlistEntry, error)
+	RecordPetStatusChange(ctx context.Context, before models.Pet, after models.Pet) error
+	PetStatusChanged(ctx context.Context, before models.Pet, after models.Pet)
+	RecordPetTagsChange(ctx context.Context, petID int, action string, before []models.Tag, after []models.Tag) error
+	ImportPets(ctx context.Context, format string, r io.Reader, dryRun bool) (models.PetImportReport, error)
+	ExportPets(ctx context.Context, format string, status []string, w io.Writer) error
 }
 
 type PetRepositoryer interface {
-	UploadFile(ctx context.Context, id int, file multipart.File, fileHeader *multipart.FileHeader) error
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	UploadFile(ctx context.Context, photo models.PetPhoto) (models.PetPhoto, error)
+	AddPhotoVariants(ctx context.Context, photoID int, variants []models.PetPhotoVariant) error
+	GetPetPhoto(ctx context.Context, petID int, photoID int) (models.PetPhoto, error)
 	AddPet(ctx context.Context, body models.Pet) (models.Pet, error)
-	UpdatePet(ctx context.Context, body models.Pet) (models.Pet, error)
+	CheckPet(ctx context.Context, pet models.Pet) error
+	UpdatePet(ctx context.Context, body models.Pet) (models.Pet, []string, error)
 	FindPetsByStatus(ctx context.Context, status []string) ([]models.Pet, error)
-	FindPetsByTags(ctx context.Context, tags []string) ([]models.Pet, error)
+	FindPetsByTags(ctx context.Context, filter models.PetTagFilter) ([]models.Pet, error)
+	ListPets(ctx context.Context, query models.PetListQuery, after *models.PetCursor) ([]models.Pet, error)
+	SearchPets(ctx context.Context, match string, limit int) ([]models.PetSearchResult, error)
 	GetPetById(ctx context.Context, id int) (models.Pet, error)
-	UpdatePetWithForm(ctx context.Context, id int, name string, status string) error
-	DeletePet(ctx context.Context, id int) error
+	UpdatePetWithForm(ctx context.Context, id int, name string, status string, version int) error
+	DeletePet(ctx context.Context, id int, version int) error
+	AddPetHistory(ctx context.Context, entry models.PetHistoryEntry) error
+	GetPetHistory(ctx context.Context, petID int) ([]models.PetHistoryEntry, error)
+	PurgePet(ctx context.Context, id int) ([]string, error)
+	BlobReferenced(ctx context.Context, key string) (bool, error)
+	ListMedicalRecords(ctx context.Context, petID int) ([]models.PetMedicalRecord, error)
+	GetMedicalRecord(ctx context.Context, petID int, recordID int) (models.PetMedicalRecord, error)
+	AddMedicalRecord(ctx context.Context, record models.PetMedicalRecord) (models.PetMedicalRecord, error)
+	UpdateMedicalRecord(ctx context.Context, record models.PetMedicalRecord) error
+	DeleteMedicalRecord(ctx context.Context, petID int, recordID int) error
+	FindOverdueVaccinations(ctx context.Context, asOf string) ([]models.OverdueVaccination, error)
+	JoinWaitlist(ctx context.Context, petID int, userName string) (models.PetWaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, petID int, userName string) error
+	ListWaitlist(ctx context.Context, petID int, userName string) ([]models.PetWaitlistEntry, error)
+	TakeWaitlist(ctx context.Context, petID int) ([]models.PetWaitlistEntry, error)
 }
 
 type PetService struct {
 	petRepository PetRepositoryer
+	blobStore     storage.BlobStore
+	variantSizes  []int
+	notifier      Notifier
 }
 
-func NewPetService(petRepository PetRepositoryer) PetServicer {
+func NewPetService(petRepository PetRepositoryer, blobStore storage.BlobStore, variantSizes []int, notifier Notifier) PetServicer {
 	return &PetService{
 		petRepository: petRepository,
+		blobStore:     blobStore,
+		variantSizes:  variantSizes,
+		notifier:      notifier,
 	}
 }
 
-func (s *PetService) UploadFile(ctx context.Context, id int, file multipart.File, fileHeader *multipart.FileHeader) error {
-	return s.petRepository.UploadFile(ctx, id, file, fileHeader)
+func (s *PetService) UploadFile(ctx context.Context, id int, file multipart.File, fileHeader *multipart.FileHeader) (models.PetPhoto, error) {
+	_, err := s.GetPetById(ctx, id)
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	// тип определяем по содержимому, присланному клиентом Content-Type не доверяем
+	mimeType, err := imaging.Sniff(data)
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	// размеры проверяем по заголовку до сохранения, не декодируя картинку целиком
+	err = imaging.CheckDimensions(bytes.NewReader(data), mimeType)
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	data, err = imaging.StripMetadata(data, mimeType)
+	if err != nil {
+		return models.PetPhoto{}, fmt.Errorf("%w: %v", imaging.ErrUnsupportedFormat, err)
+	}
+
+	// контрольная сумма служит и ключом в хранилище
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	key := storage.ContentKey(checksum)
+	err = s.blobStore.Put(ctx, key, bytes.NewReader(data))
+	if err != nil {
+		return models.PetPhoto{}, err
+	}
+
+	var photo models.PetPhoto
+	err = s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		photo, err = s.petRepository.UploadFile(ctx, models.PetPhoto{
+			PetID:    id,
+			FileName: imaging.SanitizeFileName(fileHeader.Filename),
+			BlobKey:  key,
+			Size:     int64(len(data)),
+			MimeType: mimeType,
+			Checksum: checksum,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, id, models.PetActionUploadPhoto, map[string]models.PetFieldChange{
+			"photos": {New: photo.URL},
+		})
+	})
+	if err != nil {
+		// файл уже в хранилище, а фото не записано: файл не нужен,
+		// если его не загрузили другому питомцу
+		s.deleteBlobs(ctx, id, []string{key})
+		return models.PetPhoto{}, err
+	}
+
+	// уменьшенные копии не обязательны: если картинку не удалось
+	// разобрать, фото все равно остается загруженным
+	variants, err := s.createVariants(ctx, bytes.NewReader(data), mimeType)
+	if err != nil {
+		log.Printf("pet %d photo %d: create variants: %v", id, photo.ID, err)
+		return photo, nil
+	}
+
+	// фото уже записано, поэтому и без копий загрузка удалась
+	err = s.petRepository.AddPhotoVariants(ctx, photo.ID, variants)
+	if err != nil {
+		log.Printf("pet %d photo %d: add variants: %v", id, photo.ID, err)
+
+		keys := make([]string, 0, len(variants))
+		for _, variant := range variants {
+			keys = append(keys, variant.BlobKey)
+		}
+		s.deleteBlobs(ctx, id, keys)
+
+		return photo, nil
+	}
+
+	for i := range variants {
+		variants[i].URL = fmt.Sprintf("%s?size=%d", photo.URL, variants[i].MaxSize)
+	}
+	photo.Variants = variants
+
+	return photo, nil
+}
+
+// createVariants сохраняет в хранилище уменьшенные копии изображения для каждого
+// настроенного размера, который меньше исходной картинки.
+func (s *PetService) createVariants(ctx context.Context, r io.Reader, mimeType string) ([]models.PetPhotoVariant, error) {
+	if len(s.variantSizes) == 0 {
+		return nil, nil
+	}
+
+	img, err := imaging.Decode(r, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+
+	var variants []models.PetPhotoVariant
+	for _, maxSize := range s.variantSizes {
+		if bounds.Dx() <= maxSize && bounds.Dy() <= maxSize {
+			continue
+		}
+
+		width, height := imaging.Fit(bounds.Dx(), bounds.Dy(), maxSize)
+		data, variantType, err := imaging.Encode(imaging.Resize(img, width, height), mimeType)
+		if err != nil {
+			return nil, err
+		}
+
+		sum := sha256.Sum256(data)
+		checksum := hex.EncodeToString(sum[:])
+		key := storage.ContentKey(checksum)
+
+		err = s.blobStore.Put(ctx, key, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		variants = append(variants, models.PetPhotoVariant{
+			MaxSize:  maxSize,
+			Width:    width,
+			Height:   height,
+			BlobKey:  key,
+			Size:     int64(len(data)),
+			MimeType: variantType,
+			Checksum: checksum,
+		})
+	}
+
+	return variants, nil
+}
+
+// OpenPetPhoto открывает фото или, если задан size, его уменьшенную копию.
+// Для размера, копии которого у фото нет, потому что оно само не больше, отдается оригинал.
+func (s *PetService) OpenPetPhoto(ctx context.Context, petID int, photoID int, size int) (models.PetPhoto, *storage.Blob, error) {
+	photo, err := s.petRepository.GetPetPhoto(ctx, petID, photoID)
+	if err != nil {
+		return models.PetPhoto{}, nil, err
+	}
+
+	if size != 0 {
+		found := false
+		for _, v := range photo.Variants {
+			if v.MaxSize == size {
+				photo.BlobKey = v.BlobKey
+				photo.Size = v.Size
+				photo.MimeType = v.MimeType
+				photo.Checksum = v.Checksum
+				found = true
+				break
+			}
+		}
+
+		// копия настроенного размера не строится, если исходная картинка не больше
+		// его, - тогда она сама и есть ответ
+		if !found && !containsInt(s.variantSizes, size) {
+			return models.PetPhoto{}, nil, storage.ErrBlobNotFound
+		}
+	}
+
+	blob, err := s.blobStore.Open(ctx, photo.BlobKey)
+	if err != nil {
+		return models.PetPhoto{}, nil, err
+	}
+
+	return photo, blob, nil
 }
 
 func (s *PetService) AddPet(ctx context.Context, pet models.Pet) (models.Pet, error) {
-	return s.petRepository.AddPet(ctx, pet)
+	pet, err := prepareNewPet(pet)
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	return s.addPet(ctx, pet, models.PetActionCreate)
+}
+
+// addPet сохраняет проверенного питомца и записывает его создание в журнал
+// как action в одной транзакции.
+func (s *PetService) addPet(ctx context.Context, pet models.Pet, action string) (models.Pet, error) {
+	var created models.Pet
+
+	err := s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.petRepository.AddPet(ctx, pet)
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, created.ID, action, diffPets(nil, created))
+	})
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	return created, nil
+}
+
+// prepareNewPet проверяет нового питомца: статус по умолчанию available,
+// создать сразу удаленного нельзя.
+func prepareNewPet(pet models.Pet) (models.Pet, error) {
+	if pet.Status == "" {
+		pet.Status = models.PetStatusAvailable
+	}
+
+	if err := validatePetStatus(pet.Status); err != nil {
+		return models.Pet{}, err
+	}
+
+	if pet.Status == models.PetStatusDeleted {
+		return models.Pet{}, errors.New("pet cannot be created as deleted")
+	}
+
+	if err := validatePetProfile(pet); err != nil {
+		return models.Pet{}, err
+	}
+
+	return pet, nil
 }
 
+// UpdatePet заменяет питомца целиком. Если pet.Version не 0, обновление
+// выполняется, только пока питомец не изменился с этой версии.
 func (s *PetService) UpdatePet(ctx context.Context, pet models.Pet) (models.Pet, error) {
-	_, err := s.GetPetById(ctx, pet.ID)
+	current, err := s.GetPetById(ctx, pet.ID)
 	if err != nil {
 		return models.Pet{}, err
 	}
 
-	return s.petRepository.UpdatePet(ctx, pet)
+	if pet.Version != 0 && pet.Version != current.Version {
+		return models.Pet{}, models.ErrVersionMismatch
+	}
+
+	err = checkPetStatusTransition(current.Status, pet.Status)
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	err = validatePetProfile(pet)
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	// без If-Match сохраняем поверх прочитанной версии: переход статуса проверен
+	// по ней, и параллельная бронь заказа не должна затереться
+	ifMatch := pet.Version != 0
+	pet.Version = current.Version
+
+	var updatePet models.Pet
+	var keys []string
+	err = s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		updatePet, keys, err = s.petRepository.UpdatePet(ctx, pet)
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, pet.ID, models.PetActionUpdate, diffPets(&current, updatePet))
+	})
+	if err != nil {
+		return models.Pet{}, concurrentChangeError(err, ifMatch)
+	}
+
+	s.deleteBlobs(ctx, pet.ID, keys)
+	s.notifyAvailable(ctx, current, updatePet)
+
+	return updatePet, nil
 }
 
 func (s *PetService) FindPetsByStatus(ctx context.Context, status []string) ([]models.Pet, error) {
 	return s.petRepository.FindPetsByStatus(ctx, status)
 }
 
-func (s *PetService) FindPetsByTags(ctx context.Context, tags []string) ([]models.Pet, error) {
-	return s.petRepository.FindPetsByTags(ctx, tags)
+func (s *PetService) FindPetsByTags(ctx context.Context, filter models.PetTagFilter) ([]models.Pet, error) {
+	if len(filter.Names) == 0 && len(filter.IDs) == 0 {
+		return nil, errors.New("at least one tag must be provided")
+	}
+
+	switch filter.Match {
+	case "":
+		filter.Match = models.TagMatchAny
+	case models.TagMatchAny, models.TagMatchAll:
+	default:
+		return nil, fmt.Errorf("invalid match mode %q, expected %q or %q", filter.Match, models.TagMatchAny, models.TagMatchAll)
+	}
+
+	return s.petRepository.FindPetsByTags(ctx, filter)
+}
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+func (s *PetService) ListPets(ctx context.Context, query models.PetListQuery) (models.PetPage, error) {
+	switch query.Sort {
+	case "":
+		query.Sort = models.PetSortID
+	case models.PetSortID, models.PetSortName, models.PetSortStatus:
+	default:
+		return models.PetPage{}, fmt.Errorf("invalid sort field %q, expected %q, %q or %q", query.Sort, models.PetSortID, models.PetSortName, models.PetSortStatus)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultPageLimit
+	}
+	if query.Limit < 0 || query.Limit > maxPageLimit {
+		return models.PetPage{}, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+
+	sort := query.Sort
+	if query.Desc {
+		sort = "-" + sort
+	}
+
+	var after *models.PetCursor
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return models.PetPage{}, err
+		}
+
+		// значение в курсоре имеет смысл только для той сортировки, с которой он выдан
+		if cursor.Sort != sort {
+			return models.PetPage{}, fmt.Errorf("cursor was issued for sort %q, not %q", cursor.Sort, sort)
+		}
+		after = &cursor
+	}
+
+	// запрашиваем на одного больше, чтобы узнать, есть ли следующая страница
+	pageQuery := query
+	pageQuery.Limit++
+
+	pets, err := s.petRepository.ListPets(ctx, pageQuery, after)
+	if err != nil {
+		return models.PetPage{}, err
+	}
+
+	page := models.PetPage{
+		Items: pets,
+		Pagination: models.Pagination{
+			Limit: query.Limit,
+			Sort:  sort,
+		},
+	}
+
+	if len(pets) > query.Limit {
+		page.Items = pets[:query.Limit]
+		last := page.Items[len(page.Items)-1]
+
+		cursor := models.PetCursor{Sort: sort, ID: last.ID}
+		switch query.Sort {
+		case models.PetSortName:
+			cursor.Value = last.Name
+		case models.PetSortStatus:
+			cursor.Value = last.Status
+		}
+
+		page.Pagination.HasMore = true
+		page.Pagination.NextCursor = encodeCursor(cursor)
+	}
+
+	return page, nil
+}
+
+// SearchPets ищет питомцев по словам из text в имени, категории и тегах.
+// Каждое слово ищется как префикс, питомцы с большим числом совпадений выше.
+func (s *PetService) SearchPets(ctx context.Context, text string, limit int) ([]models.PetSearchResult, error) {
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	if limit < 0 || limit > maxPageLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+
+	// от запроса остаются только буквы и цифры, чтобы пользовательский ввод
+	// не разбирался как синтаксис FTS5
+	terms := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) == 0 {
+		return nil, errors.New("search query must contain at least one word")
+	}
+
+	for i, term := range terms {
+		terms[i] = `"` + term + `"*`
+	}
+
+	return s.petRepository.SearchPets(ctx, strings.Join(terms, " OR "), limit)
+}
+
+// encodeCursor упаковывает курсор в непрозрачную для клиента строку.
+func encodeCursor(cursor models.PetCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (models.PetCursor, error) {
+	var cursor models.PetCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	return cursor, nil
 }
 
 func (s *PetService) GetPetById(ctx context.Context, id int) (models.Pet, error) {
 	return s.petRepository.GetPetById(ctx, id)
 }
 
-func (s *PetService) UpdatePetWithForm(ctx context.Context, id int, name string, status string) error {
-	_, err := s.GetPetById(ctx, id)
+func (s *PetService) UpdatePetWithForm(ctx context.Context, id int, name string, status string, version int) error {
+	current, err := s.GetPetById(ctx, id)
 	if err != nil {
 		return err
 	}
-	
-	return s.petRepository.UpdatePetWithForm(ctx, id, name, status)
+
+	if version != 0 && version != current.Version {
+		return models.ErrVersionMismatch
+	}
+
+	// пустой статус в форме означает, что он не меняется
+	if status != "" {
+		err = checkPetStatusTransition(current.Status, status)
+		if err != nil {
+			return err
+		}
+	}
+
+	updated := current
+	if name != "" {
+		updated.Name = name
+	}
+	if status != "" {
+		updated.Status = status
+	}
+
+	err = s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		err := s.petRepository.UpdatePetWithForm(ctx, id, name, status, current.Version)
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, id, models.PetActionUpdate, diffPets(&current, updated))
+	})
+	if err != nil {
+		return concurrentChangeError(err, version != 0)
+	}
+
+	s.notifyAvailable(ctx, current, updated)
+
+	return nil
 }
 
 func (s *PetService) DeletePet(ctx context.Context, id int) error {
-	_, err := s.GetPetById(ctx, id)
+	current, err := s.GetPetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = checkPetStatusTransition(current.Status, models.PetStatusDeleted)
 	if err != nil {
 		return err
 	}
-	
-	return s.petRepository.DeletePet(ctx, id)
+
+	deleted := current
+	deleted.Status = models.PetStatusDeleted
+
+	err = s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		err := s.petRepository.DeletePet(ctx, id, current.Version)
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, id, models.PetActionDelete, diffPets(&current, deleted))
+	})
+	if err != nil {
+		return concurrentChangeError(err, false)
+	}
+
+	return nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"app/internal/models"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxBreedLength       = 100
+	maxDescriptionLength = 4000
+
+	// dateLayout - даты без времени (рождения, прививок) хранятся в формате ISO 8601.
+	dateLayout = "2006-01-02"
+)
+
+// validatePetProfile проверяет необязательные поля профиля питомца.
+// Пустые поля не проверяются: профиль можно заполнять постепенно.
+func validatePetProfile(pet models.Pet) error {
+	if utf8.RuneCountInString(pet.Breed) > maxBreedLength {
+		return fmt.Errorf("breed must be at most %d characters", maxBreedLength)
+	}
+
+	if utf8.RuneCountInString(pet.Description) > maxDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", maxDescriptionLength)
+	}
+
+	if pet.Sex != "" && pet.Sex != models.PetSexMale && pet.Sex != models.PetSexFemale {
+		return fmt.Errorf("invalid pet sex %q, expected %q or %q", pet.Sex, models.PetSexMale, models.PetSexFemale)
+	}
+
+	if pet.BirthDate != "" {
+		birthDate, err := time.Parse(dateLayout, pet.BirthDate)
+		if err != nil {
+			return fmt.Errorf("invalid birth date %q, expected YYYY-MM-DD", pet.BirthDate)
+		}
+
+		if birthDate.After(time.Now()) {
+			return errors.New("birth date cannot be in the future")
+		}
+	}
+
+	// NaN и бесконечность приходят из CSV (strconv.ParseFloat их принимает)
+	if pet.Weight != nil && (math.IsNaN(*pet.Weight) || math.IsInf(*pet.Weight, 0) || *pet.Weight <= 0) {
+		return errors.New("weight must be a positive number")
+	}
+
+	// цена в минимальных единицах валюты (копейках, центах), 0 - бесплатно
+	if pet.Price != nil && *pet.Price < 0 {
+		return errors.New("price cannot be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"app/internal/models"
+	"context"
+	"log"
+)
+
+// RestorePet возвращает удаленного питомца в статус, который был у него до удаления.
+// Если в истории удаления нет (питомец удален до появления журнала), он становится available.
+// Бронь заказа при удалении снимается, поэтому забронированный питомец тоже
+// возвращается в продажу.
+func (s *PetService) RestorePet(ctx context.Context, id int) (models.Pet, error) {
+	current, err := s.GetPetById(ctx, id)
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	if current.Status != models.PetStatusDeleted {
+		return models.Pet{}, models.NewConflictError("pet is not deleted")
+	}
+
+	history, err := s.petRepository.GetPetHistory(ctx, id)
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	status := statusBeforeDeletion(history)
+
+	restored := current
+	restored.Status = status
+	restored.Version++
+
+	err = s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		err := s.petRepository.UpdatePetWithForm(ctx, id, "", status, current.Version)
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, id, models.PetActionRestore, diffPets(&current, restored))
+	})
+	if err != nil {
+		return models.Pet{}, concurrentChangeError(err, false)
+	}
+
+	s.notifyAvailable(ctx, current, restored)
+
+	return restored, nil
+}
+
+// statusBeforeDeletion ищет в истории последнее удаление и возвращает статус до него,
+// если из этого статуса питомца можно удалить по petStatusTransitions.
+func statusBeforeDeletion(history []models.PetHistoryEntry) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		change, ok := history[i].Changes["status"]
+		if !ok || change.New != models.PetStatusDeleted {
+			continue
+		}
+
+		status, _ := change.Old.(string)
+		if validatePetStatus(status) != nil || status == models.PetStatusDeleted ||
+			checkPetStatusTransition(status, models.PetStatusDeleted) != nil {
+			break
+		}
+
+		// бронь снята вместе с удалением, заказ питомца больше не держит
+		if status == models.PetStatusPending {
+			break
+		}
+
+		return status
+	}
+
+	return models.PetStatusAvailable
+}
+
+// PurgePet окончательно удаляет питомца, который уже помечен удаленным,
+// и его файлы, если они больше не нужны другим питомцам. История питомца
+// остается и заканчивается записью purge с его последними данными.
+func (s *PetService) PurgePet(ctx context.Context, id int) error {
+	current, err := s.GetPetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if current.Status != models.PetStatusDeleted {
+		return models.NewConflictError("only deleted pets can be purged")
+	}
+
+	var keys []string
+	err = s.petRepository.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		keys, err = s.petRepository.PurgePet(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, id, models.PetActionPurge, diffPets(&current, models.Pet{}))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.deleteBlobs(ctx, id, keys)
+
+	return nil
+}
+
+// deleteBlobs удаляет файлы, на которые после изменения питомца petID больше не
+// ссылаются записи в базе. Записи уже удалены, оставшийся файл только занимает
+// место, поэтому ошибка удаления лишь пишется в лог.
+func (s *PetService) deleteBlobs(ctx context.Context, petID int, keys []string) {
+	for _, key := range keys {
+		// файлы хранятся по содержимому: после фиксации удаления то же фото
+		// могли загрузить снова, и тогда файл опять нужен
+		referenced, err := s.petRepository.BlobReferenced(ctx, key)
+		if err != nil {
+			log.Printf("pet %d: check blob %s: %v", petID, key, err)
+			continue
+		}
+		if referenced {
+			continue
+		}
+
+		if err = s.blobStore.Delete(ctx, key); err != nil {
+			log.Printf("pet %d: delete blob %s: %v", petID, key, err)
+		}
+	}
+}
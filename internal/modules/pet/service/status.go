@@ -0,0 +1,85 @@
+package service
+
+import (
+	"app/internal/models"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// petStatusTransitions - жизненный цикл питомца: в продаже -> забронирован -> продан.
+// Удалить можно из любого статуса, а вернуть из deleted - только через RestorePet,
+// который восстанавливает статус до удаления.
+var petStatusTransitions = map[string][]string{
+	models.PetStatusAvailable: {models.PetStatusPending, models.PetStatusDeleted},
+	models.PetStatusPending:   {models.PetStatusAvailable, models.PetStatusSold, models.PetStatusDeleted},
+	models.PetStatusSold:      {models.PetStatusDeleted},
+	models.PetStatusDeleted:   {},
+}
+
+// validatePetStatus проверяет, что статус входит в жизненный цикл питомца.
+func validatePetStatus(status string) error {
+	if _, ok := petStatusTransitions[status]; !ok {
+		return fmt.Errorf("invalid pet status %q, expected one of %q, %q, %q, %q", status,
+			models.PetStatusAvailable, models.PetStatusPending, models.PetStatusSold, models.PetStatusDeleted)
+	}
+
+	return nil
+}
+
+// checkPetStatusTransition проверяет смену статуса from -> to. Повторная установка
+// того же статуса допустима. Питомца со статусом вне жизненного цикла (такие могли
+// остаться с тех пор, как статус был произвольной строкой) можно перевести в любой.
+func checkPetStatusTransition(from string, to string) error {
+	if err := validatePetStatus(to); err != nil {
+		return err
+	}
+
+	if from == to {
+		return nil
+	}
+
+	allowed, ok := petStatusTransitions[from]
+	if !ok {
+		return nil
+	}
+
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+
+	return &models.StatusTransitionError{Entity: "pet", From: from, To: to}
+}
+
+// RecordPetStatusChange записывает в историю смену статуса питомца, которую заказ
+// сохраняет в обход PetService (бронирование при оформлении заказа, отмена и доставка
+// заказа). Вызывается в транзакции заказа, поэтому ошибка журнала отменяет и заказ.
+func (s *PetService) RecordPetStatusChange(ctx context.Context, before models.Pet, after models.Pet) error {
+	return s.recordHistory(ctx, after.ID, models.PetActionUpdate, diffPets(&before, after))
+}
+
+// PetStatusChanged вызывается после фиксации такой смены статуса и уведомляет
+// лист ожидания, если питомец вернулся в продажу.
+func (s *PetService) PetStatusChanged(ctx context.Context, before models.Pet, after models.Pet) {
+	s.notifyAvailable(ctx, before, after)
+}
+
+// RecordPetTagsChange записывает в историю смену тегов питомца при слиянии или
+// удалении тега (action). Вызывается в транзакции TagService.
+func (s *PetService) RecordPetTagsChange(ctx context.Context, petID int, action string, before []models.Tag, after []models.Tag) error {
+	return s.recordHistory(ctx, petID, action, diffPets(&models.Pet{Tags: before}, models.Pet{Tags: after}))
+}
+
+// concurrentChangeError - запись питомца всегда условна по прочитанной версии, потому что
+// переход статуса проверяется по ней. Если версию задал клиент (If-Match), несовпадение -
+// ErrVersionMismatch (412), иначе питомца изменили параллельно, например забронировал
+// заказ, и возвращается ConflictError (409).
+func concurrentChangeError(err error, ifMatch bool) error {
+	if !ifMatch && errors.Is(err, models.ErrVersionMismatch) {
+		return models.NewConflictError("pet was changed concurrently, reload it and retry")
+	}
+
+	return err
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"app/internal/models"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// exportBatchSize - сколько питомцев читается из базы за один запрос при экспорте.
+	exportBatchSize = 100
+
+	// maxNDJSONLine - ограничение длины одной строки NDJSON.
+	maxNDJSONLine = 1 << 20
+
+	// csvListSeparator разделяет несколько значений в одной ячейке CSV (теги, фото).
+	csvListSeparator = "|"
+)
+
+// petCSVColumns - колонки CSV при экспорте. При импорте id, category_name, tag_names
+// и version игнорируются, поэтому выгруженный файл можно загрузить обратно.
+var petCSVColumns = []string{
+	"id", "name", "status", "category_id", "category_name", "tag_ids", "tag_names", "photo_urls",
+	"breed", "birth_date", "sex", "weight", "description", "price", "version",
+}
+
+// petReader читает питомцев из файла импорта по одному. io.EOF - конец файла.
+// Ошибка строки (*rowError) не мешает читать дальше, остальные ошибки прерывают импорт.
+type petReader interface {
+	Next() (models.Pet, error)
+}
+
+type rowError struct {
+	err error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+// ImportPets добавляет питомцев из CSV или NDJSON, читая файл потоком. Каждая строка
+// проходит те же проверки и ту же транзакционную вставку, что и AddPet, и не зависит
+// от остальных: корректные добавляются, ошибочные перечисляются в отчете.
+// При dryRun строки только проверяются, вставка откатывается.
+func (s *PetService) ImportPets(ctx context.Context, format string, r io.Reader, dryRun bool) (models.PetImportReport, error) {
+	reader, err := newPetReader(format, r)
+	if err != nil {
+		return models.PetImportReport{}, err
+	}
+
+	report := models.PetImportReport{
+		DryRun: dryRun,
+		Errors: []models.PetImportError{},
+	}
+
+	for {
+		pet, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr *rowError
+		if err != nil && !errors.As(err, &rowErr) {
+			// дальше файл не разобрать, но уже добавленные строки остаются
+			report.Error = err.Error()
+			break
+		}
+
+		report.Total++
+
+		if err == nil {
+			err = s.importPet(ctx, pet, dryRun)
+		}
+
+		if err == nil {
+			report.Imported++
+			continue
+		}
+
+		report.Failed++
+
+		// в большом файле ошибок может быть столько же, сколько строк
+		if len(report.Errors) == models.MaxPetImportErrors {
+			report.Truncated = true
+			continue
+		}
+
+		report.Errors = append(report.Errors, models.PetImportError{
+			Row:   report.Total,
+			Name:  pet.Name,
+			Error: err.Error(),
+		})
+	}
+
+	return report, nil
+}
+
+func (s *PetService) importPet(ctx context.Context, pet models.Pet, dryRun bool) error {
+	pet.ID = 0
+	pet.Version = 0
+	pet.Photos = nil
+
+	pet, err := prepareNewPet(pet)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return s.petRepository.CheckPet(ctx, pet)
+	}
+
+	_, err = s.addPet(ctx, pet, models.PetActionImport)
+
+	return err
+}
+
+// ExportPets выгружает питомцев (без удаленных, если статусы не заданы) в CSV или
+// NDJSON порциями по exportBatchSize. После каждой порции данные сбрасываются
+// клиенту, если w это поддерживает, поэтому выгрузка не держит в памяти весь каталог.
+func (s *PetService) ExportPets(ctx context.Context, format string, status []string, w io.Writer) error {
+	for _, st := range status {
+		if err := validatePetStatus(st); err != nil {
+			return err
+		}
+	}
+
+	writer, err := newPetWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	query := models.PetListQuery{
+		Status: status,
+		Sort:   models.PetSortID,
+		Limit:  exportBatchSize,
+	}
+
+	var after *models.PetCursor
+	for {
+		pets, err := s.petRepository.ListPets(ctx, query, after)
+		if err != nil {
+			return err
+		}
+
+		for _, pet := range pets {
+			if err = writer.Write(pet); err != nil {
+				return err
+			}
+		}
+
+		if err = writer.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(interface{ Flush() }); ok {
+			flusher.Flush()
+		}
+
+		if len(pets) < exportBatchSize {
+			return nil
+		}
+		after = &models.PetCursor{ID: pets[len(pets)-1].ID}
+	}
+}
+
+func newPetReader(format string, r io.Reader) (petReader, error) {
+	switch format {
+	case models.PetFormatCSV:
+		return newCSVPetReader(r)
+	case models.PetFormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+		return &ndjsonPetReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected %q or %q", format, models.PetFormatCSV, models.PetFormatNDJSON)
+	}
+}
+
+// ndjsonPetReader читает по питомцу (JSON как в POST /pet) из каждой непустой строки.
+type ndjsonPetReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonPetReader) Next() (models.Pet, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var pet models.Pet
+		if err := json.Unmarshal([]byte(line), &pet); err != nil {
+			return models.Pet{}, &rowError{fmt.Errorf("line %d: %v", r.line, err)}
+		}
+
+		return pet, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return models.Pet{}, fmt.Errorf("line %d: %v", r.line+1, err)
+	}
+
+	return models.Pet{}, io.EOF
+}
+
+// csvPetReader читает питомцев из CSV с заголовком. Порядок колонок любой,
+// неизвестные колонки пропускаются, обязательна только name.
+type csvPetReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVPetReader(r io.Reader) (*csvPetReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv header is missing")
+		}
+		return nil, err
+	}
+
+	// Excel сохраняет CSV в UTF-8 с BOM перед первой колонкой
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New(`csv header must contain the "name" column`)
+	}
+
+	return &csvPetReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvPetReader) Next() (models.Pet, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+
+	pet, err := r.parse(record)
+	if err != nil {
+		return pet, &rowError{fmt.Errorf("line %d: %v", line, err)}
+	}
+
+	return pet, nil
+}
+
+func (r *csvPetReader) parse(record []string) (models.Pet, error) {
+	get := func(column string) string {
+		i, ok := r.columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	pet := models.Pet{
+		Name:        get("name"),
+		Status:      get("status"),
+		Breed:       get("breed"),
+		BirthDate:   get("birth_date"),
+		Sex:         get("sex"),
+		Description: get("description"),
+	}
+
+	if value := get("category_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return pet, fmt.Errorf("invalid category_id %q", value)
+		}
+		pet.Category.ID = id
+	}
+
+	for _, value := range splitCSVList(get("tag_ids")) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return pet, fmt.Errorf("invalid tag id %q", value)
+		}
+		pet.Tags = append(pet.Tags, models.Tag{ID: id})
+	}
+
+	pet.PhotoUrls = splitCSVList(get("photo_urls"))
+
+	if value := get("weight"); value != "" {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return pet, fmt.Errorf("invalid weight %q", value)
+		}
+		pet.Weight = &weight
+	}
+
+	if value := get("price"); value != "" {
+		price, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return pet, fmt.Errorf("invalid price %q, expected an integer in minor units", value)
+		}
+		pet.Price = &price
+	}
+
+	return pet, nil
+}
+
+func splitCSVList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, csvListSeparator) {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+
+	return values
+}
+
+// petWriter записывает питомцев в файл экспорта, Flush дописывает буфер в поток.
+type petWriter interface {
+	Write(pet models.Pet) error
+	Flush() error
+}
+
+func newPetWriter(format string, w io.Writer) (petWriter, error) {
+	switch format {
+	case models.PetFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(petCSVColumns); err != nil {
+			return nil, err
+		}
+		return &csvPetWriter{writer: writer}, nil
+	case models.PetFormatNDJSON:
+		return &ndjsonPetWriter{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected %q or %q", format, models.PetFormatCSV, models.PetFormatNDJSON)
+	}
+}
+
+type ndjsonPetWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonPetWriter) Write(pet models.Pet) error {
+	return w.encoder.Encode(pet)
+}
+
+func (w *ndjsonPetWriter) Flush() error {
+	return nil
+}
+
+type csvPetWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvPetWriter) Write(pet models.Pet) error {
+	tagIDs := make([]string, 0, len(pet.Tags))
+	tagNames := make([]string, 0, len(pet.Tags))
+	for _, tag := range pet.Tags {
+		tagIDs = append(tagIDs, strconv.Itoa(tag.ID))
+		tagNames = append(tagNames, tag.Name)
+	}
+
+	var categoryID, weight, price string
+	if pet.Category.ID != 0 {
+		categoryID = strconv.Itoa(pet.Category.ID)
+	}
+	if pet.Weight != nil {
+		weight = strconv.FormatFloat(*pet.Weight, 'f', -1, 64)
+	}
+	if pet.Price != nil {
+		price = strconv.FormatInt(*pet.Price, 10)
+	}
+
+	return w.writer.Write([]string{
+		strconv.Itoa(pet.ID),
+		pet.Name,
+		pet.Status,
+		categoryID,
+		pet.Category.Name,
+		strings.Join(tagIDs, csvListSeparator),
+		strings.Join(tagNames, csvListSeparator),
+		strings.Join(pet.PhotoUrls, csvListSeparator),
+		pet.Breed,
+		pet.BirthDate,
+		pet.Sex,
+		weight,
+		pet.Description,
+		price,
+		strconv.Itoa(pet.Version),
+	})
+}
+
+func (w *csvPetWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"app/internal/infrastructure/notify"
+	customMiddleware "app/internal/infrastructure/middleware"
+	"app/internal/models"
+	"context"
+	"fmt"
+	"log"
+)
+
+// Notifier доставляет уведомления пользователям, не задерживая запрос.
+type Notifier interface {
+	Notify(msg notify.Message)
+}
+
+// JoinWaitlist записывает пользователя из JWT в лист ожидания забронированного
+// питомца: когда питомец вернется в продажу, пользователь получит уведомление.
+func (s *PetService) JoinWaitlist(ctx context.Context, petID int) (models.PetWaitlistEntry, error) {
+	userName := customMiddleware.UserNameFromContext(ctx)
+	if userName == "" {
+		return models.PetWaitlistEntry{}, models.ErrForbidden
+	}
+
+	pet, err := s.GetPetById(ctx, petID)
+	if err != nil {
+		return models.PetWaitlistEntry{}, err
+	}
+
+	if pet.Status != models.PetStatusPending {
+		return models.PetWaitlistEntry{}, models.NewConflictError(fmt.Sprintf("pet is %s, only pending pets have a waitlist", pet.Status))
+	}
+
+	return s.petRepository.JoinWaitlist(ctx, petID, userName)
+}
+
+func (s *PetService) LeaveWaitlist(ctx context.Context, petID int) error {
+	return s.petRepository.LeaveWaitlist(ctx, petID, customMiddleware.UserNameFromContext(ctx))
+}
+
+// ListWaitlist возвращает лист ожидания питомца. Администратор видит всех,
+// остальные пользователи - только свою запись.
+func (s *PetService) ListWaitlist(ctx context.Context, petID int) ([]models.PetWaitlistEntry, error) {
+	_, err := s.GetPetById(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	userName := ""
+	if customMiddleware.RoleFromContext(ctx) != models.UserRoleAdmin {
+		userName = customMiddleware.UserNameFromContext(ctx)
+		if userName == "" {
+			return nil, models.ErrForbidden
+		}
+	}
+
+	return s.petRepository.ListWaitlist(ctx, petID, userName)
+}
+
+// notifyAvailable уведомляет лист ожидания, если питомец вернулся в продажу.
+// Изменение питомца к этому моменту уже сохранено, поэтому ошибки только логируются.
+func (s *PetService) notifyAvailable(ctx context.Context, before models.Pet, after models.Pet) {
+	if before.Status == models.PetStatusAvailable || after.Status != models.PetStatusAvailable {
+		return
+	}
+
+	entries, err := s.petRepository.TakeWaitlist(ctx, after.ID)
+	if err != nil {
+		log.Printf("pet %d: take waitlist: %v", after.ID, err)
+		return
+	}
+
+	for _, entry := range entries {
+		s.notifier.Notify(notify.Message{
+			Event:    notify.EventPetAvailable,
+			UserName: entry.UserName,
+			Email:    entry.Email,
+			Subject:  fmt.Sprintf("%s is available again", after.Name),
+			Text:     fmt.Sprintf("Hello, %s!\n\n%s (pet #%d) you were waiting for is available again.", entry.UserName, after.Name, after.ID),
+			Data: map[string]interface{}{
+				"petId":   after.ID,
+				"petName": after.Name,
+				"status":  after.Status,
+			},
+		})
+	}
+}
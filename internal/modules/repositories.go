@@ -4,6 +4,9 @@ import (
 	uR "app/internal/modules/user/repository"
 	pR "app/internal/modules/pet/repository"
 	sR "app/internal/modules/store/repository"
+	cR "app/internal/modules/category/repository"
+	tR "app/internal/modules/tag/repository"
+	aR "app/internal/modules/adoption/repository"
 	"database/sql"
 )
 
@@ -11,6 +14,9 @@ type Repository struct {
 	User uR.UserRepositoryer
 	Pet  pR.PetRepositoryer
 	Store sR.StoreRepositoryer
+	Category cR.CategoryRepositoryer
+	Tag tR.TagRepositoryer
+	Adoption aR.AdoptionRepositoryer
 }
 
 func NewRepository(db *sql.DB) *Repository {
@@ -18,5 +24,8 @@ func NewRepository(db *sql.DB) *Repository {
 		User: uR.NewUserRepository(db),
 		Pet:  pR.NewPetRepository(db),
 		Store: sR.NewStoreRepository(db),
+		Category: cR.NewCategoryRepository(db),
+		Tag: tR.NewTagRepository(db),
+		Adoption: aR.NewAdoptionRepository(db),
 	}
 }
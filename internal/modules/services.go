@@ -1,21 +1,37 @@
 package modules
 
 import (
+	"app/internal/infrastructure/storage"
 	uS "app/internal/modules/user/service"
 	pS "app/internal/modules/pet/service"
 	sS "app/internal/modules/store/service"
+	cS "app/internal/modules/category/service"
+	tS "app/internal/modules/tag/service"
+	aS "app/internal/modules/adoption/service"
 )
 
 type Service struct {
 	User uS.UserServicer
 	Pet  pS.PetServicer
 	Store sS.StoreServicer
+	Category cS.CategoryServicer
+	Tag tS.TagServicer
+	Adoption aS.AdoptionServicer
 }
 
-func NewService(repos *Repository) *Service {
+func NewService(repos *Repository, blobStore storage.BlobStore, variantSizes []int, notifier pS.Notifier) *Service {
+	pet := pS.NewPetService(repos.Pet, blobStore, variantSizes, notifier)
+	store := sS.NewStoreService(repos.Store, pet)
+	user := uS.NewUserService(repos.User)
+
 	return &Service{
-		User: uS.NewUserService(repos.User),
-		Pet:  pS.NewPetService(repos.Pet),
-		Store: sS.NewStoreService(repos.Store),
+		User: user,
+		Pet:  pet,
+		Store: store,
+		Category: cS.NewCategoryService(repos.Category),
+		Tag: tS.NewTagService(repos.Tag, pet),
+		// заявки создают заказы через StoreService, а он бронирует питомца;
+		// владелец заказа - заявитель, его ID берем из UserService
+		Adoption: aS.NewAdoptionService(repos.Adoption, pet, store, user),
 	}
 }
\ No newline at end of file
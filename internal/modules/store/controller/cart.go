@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"app/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+//	@id				10getCart
+//	@Security		ApiKeyAuth
+//	@Summary		Returns the cart of the current user
+//	@Description	Prices are current, they are fixed when the cart is checked out
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	models.Cart
+//	@Router			/store/cart [get]
+func (sc StoreController) GetCart(w http.ResponseWriter, r *http.Request) {
+	cart, err := sc.storeService.GetCart(r.Context())
+	if err != nil {
+		sc.respondError(w, err)
+		return
+	}
+
+	sc.respondCart(w, cart)
+}
+
+//	@id				11addCartItem
+//	@Security		ApiKeyAuth
+//	@Summary		Adds a pet or an accessory to the cart
+//	@Description	Set either petId or accessoryId. A pet must be available and have a price, it is added once and reserved only on checkout. Quantity of an accessory already in the cart is increased, up to 99 in total
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			object	body		models.CartItem	true	"Cart item"
+//	@Success		200		{object}	models.Cart
+//	@Failure		409		{object}	responder.Response	"pet is not available or already in the cart"
+//	@Router			/store/cart/items [post]
+func (sc StoreController) AddCartItem(w http.ResponseWriter, r *http.Request) {
+	var item models.CartItem
+
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	cart, err := sc.storeService.AddCartItem(r.Context(), item)
+	if err != nil {
+		sc.respondError(w, err)
+		return
+	}
+
+	sc.respondCart(w, cart)
+}
+
+//	@id				12updateCartItem
+//	@Security		ApiKeyAuth
+//	@Summary		Changes quantity of an accessory in the cart
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			itemId	path		int				true	"ID of cart item"
+//	@Param			object	body		models.CartItem	true	"Only quantity is used"
+//	@Success		200		{object}	models.Cart
+//	@Router			/store/cart/items/{itemId} [put]
+func (sc StoreController) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "itemId"))
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	var item models.CartItem
+
+	err = json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	cart, err := sc.storeService.UpdateCartItem(r.Context(), id, item.Quantity)
+	if err != nil {
+		sc.respondError(w, err)
+		return
+	}
+
+	sc.respondCart(w, cart)
+}
+
+//	@id				13deleteCartItem
+//	@Security		ApiKeyAuth
+//	@Summary		Removes an item from the cart
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			itemId	path		int	true	"ID of cart item"
+//	@Success		200		{object}	models.Cart
+//	@Router			/store/cart/items/{itemId} [delete]
+func (sc StoreController) DeleteCartItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "itemId"))
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	cart, err := sc.storeService.DeleteCartItem(r.Context(), id)
+	if err != nil {
+		sc.respondError(w, err)
+		return
+	}
+
+	sc.respondCart(w, cart)
+}
+
+//	@id				14clearCart
+//	@Security		ApiKeyAuth
+//	@Summary		Removes all items from the cart
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	responder.Response
+//	@Router			/store/cart [delete]
+func (sc StoreController) ClearCart(w http.ResponseWriter, r *http.Request) {
+	err := sc.storeService.ClearCart(r.Context())
+	if err != nil {
+		sc.respondError(w, err)
+		return
+	}
+
+	sc.responder.Success(w, "cart cleared")
+}
+
+//	@id				15checkoutCart
+//	@Security		ApiKeyAuth
+//	@Summary		Places one order for everything in the cart
+//	@Description	In one transaction all pets are reserved (pending), accessories are taken from stock, the order is created with line items and total at current prices and the cart is emptied. If any item cannot be bought nothing changes. Repeating the request with the same Idempotency-Key returns the saved response instead of placing a second order
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			object			body		models.CartCheckout	true	"Checkout details"
+//	@Param			Idempotency-Key	header		string				false	"Unique key of the request, retries must reuse it"
+//	@Success		200				{object}	models.Order
+//	@Failure		409				{object}	responder.Response	"pet is not available, accessory is out of stock or a request with this key is in progress"
+//	@Failure		422				{object}	responder.Response	"key was used with a different request"
+//	@Router			/store/cart/checkout [post]
+func (sc StoreController) Checkout(w http.ResponseWriter, r *http.Request) {
+	var checkout models.CartCheckout
+
+	err := json.NewDecoder(r.Body).Decode(&checkout)
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	order, err := sc.storeService.Checkout(r.Context(), checkout)
+	if err != nil {
+		sc.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(order, "", "  ")
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				16listAccessories
+//	@Security		ApiKeyAuth
+//	@Summary		Lists accessories with prices and stock
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}	models.Accessory
+//	@Router			/store/accessories [get]
+func (sc StoreController) ListAccessories(w http.ResponseWriter, r *http.Request) {
+	accessories, err := sc.storeService.ListAccessories(r.Context())
+	if err != nil {
+		sc.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(accessories, "", "  ")
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+func (sc StoreController) respondCart(w http.ResponseWriter, cart models.Cart) {
+	jsonResp, err := json.MarshalIndent(cart, "", "  ")
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
@@ -5,9 +5,11 @@ import (
 	"app/internal/models"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 )
@@ -16,14 +18,38 @@ type StoreControllerer interface {
 	GetInventory(w http.ResponseWriter, r *http.Request)
 	PlaceOrder(w http.ResponseWriter, r *http.Request)
 	GetOrderById(w http.ResponseWriter, r *http.Request)
+	ListOrders(w http.ResponseWriter, r *http.Request)
 	DeleteOrder(w http.ResponseWriter, r *http.Request)
+	ApproveOrder(w http.ResponseWriter, r *http.Request)
+	ShipOrder(w http.ResponseWriter, r *http.Request)
+	DeliverOrder(w http.ResponseWriter, r *http.Request)
+	CancelOrder(w http.ResponseWriter, r *http.Request)
+	GetCart(w http.ResponseWriter, r *http.Request)
+	AddCartItem(w http.ResponseWriter, r *http.Request)
+	UpdateCartItem(w http.ResponseWriter, r *http.Request)
+	DeleteCartItem(w http.ResponseWriter, r *http.Request)
+	ClearCart(w http.ResponseWriter, r *http.Request)
+	Checkout(w http.ResponseWriter, r *http.Request)
+	ListAccessories(w http.ResponseWriter, r *http.Request)
 }
 
 type StoreServicer interface {
 	GetInventory(ctx context.Context) (map[string]int, error)
 	PlaceOrder(ctx context.Context, order models.Order) (models.Order, error)
 	GetOrderById(ctx context.Context, id int) (models.Order, error)
+	ListOrders(ctx context.Context, query models.OrderListQuery) (models.OrderPage, error)
 	DeleteOrder(ctx context.Context, id int) error
+	ApproveOrder(ctx context.Context, id int) (models.Order, error)
+	ShipOrder(ctx context.Context, id int) (models.Order, error)
+	DeliverOrder(ctx context.Context, id int) (models.Order, error)
+	CancelOrder(ctx context.Context, id int) (models.Order, error)
+	GetCart(ctx context.Context) (models.Cart, error)
+	AddCartItem(ctx context.Context, item models.CartItem) (models.Cart, error)
+	UpdateCartItem(ctx context.Context, itemID int, quantity int) (models.Cart, error)
+	DeleteCartItem(ctx context.Context, itemID int) (models.Cart, error)
+	ClearCart(ctx context.Context) error
+	Checkout(ctx context.Context, checkout models.CartCheckout) (models.Order, error)
+	ListAccessories(ctx context.Context) ([]models.Accessory, error)
 }
 
 type StoreController struct {
@@ -63,14 +89,20 @@ func (sc StoreController) GetInventory(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, string(jsonResp))
 }
 
-//	@id			2placeOrder
-//	@Summary	Place an order for a pet
-//	@Tags		store
-//	@Accept		json
-//	@Produce	json
-//	@Param		object	body		models.Order	true	"order placed for purchasing the pet"
-//	@Success	200		{object}	models.Order
-//	@Router		/store/order [post]
+//	@id				2placeOrder
+//	@Security		ApiKeyAuth
+//	@Summary		Place an order for a pet
+//	@Description	The order is placed and the pet becomes pending in one transaction. Only available pets can be ordered. The quantity is 1 or omitted. The order belongs to the user from the token. Repeating the request with the same Idempotency-Key returns the saved response instead of placing a second order
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			object			body		models.Order	true	"order placed for purchasing the pet"
+//	@Param			Idempotency-Key	header		string			false	"Unique key of the request, retries must reuse it"
+//	@Success		200				{object}	models.Order
+//	@Failure		403				{object}	responder.Response	"token has no user id"
+//	@Failure		409				{object}	responder.Response	"pet is not available, was reserved by another order or a request with this key is in progress"
+//	@Failure		422				{object}	responder.Response	"key was used with a different request"
+//	@Router			/store/order [post]
 func (sc StoreController) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 
 	var order models.Order
@@ -81,9 +113,12 @@ func (sc StoreController) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createOrder, err := sc.storeService.PlaceOrder(context.Background(), order)
+	// владельца берем из токена, а не из тела запроса
+	order.UserID = 0
+
+	createOrder, err := sc.storeService.PlaceOrder(r.Context(), order)
 	if err != nil {
-		sc.responder.ErrorBadRequest(w, err)
+		sc.respondError(w, err)
 		return
 	}
 
@@ -97,13 +132,15 @@ func (sc StoreController) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 }
 
 //	@id				3getOrderById
+//	@Security		ApiKeyAuth
 //	@Summary		Find purchase order by ID
-//	@Description	For valid response try integer IDs with value >= 1 and <= 10. Other values will generated exceptions
+//	@Description	Users can see only their own orders, admins can see any order
 //	@Tags			store
 //	@Accept			json
 //	@Produce		json
 //	@Param			orderId	path		int	true	"ID of pet that needs to be fetched"
 //	@Success		200		{object}	models.Order
+//	@Failure		403		{object}	responder.Response	"order belongs to another user"
 //	@Router			/store/order/{orderId} [get]
 func (sc StoreController) GetOrderById(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "orderId")
@@ -113,9 +150,9 @@ func (sc StoreController) GetOrderById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := sc.storeService.GetOrderById(context.Background(), id)
+	order, err := sc.storeService.GetOrderById(r.Context(), id)
 	if err != nil {
-		sc.responder.ErrorBadRequest(w, err)
+		sc.respondError(w, err)
 		return
 	}
 
@@ -128,14 +165,82 @@ func (sc StoreController) GetOrderById(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, string(jsonResp))
 }
 
+//	@id				9listOrders
+//	@Security		ApiKeyAuth
+//	@Summary		Lists orders page by page
+//	@Description	Newest orders first. Users see only their own orders, admins see all orders or the orders of userId. Pass pagination.nextCursor from the previous page as cursor, keeping the same filters. Links to the first and the next page are also returned in the Link header
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			status		query		[]string	false	"Status values to filter by"	collectionFormat(csv)
+//	@Param			shipFrom	query		string		false	"Ship date from, inclusive"		format(date)
+//	@Param			shipTo		query		string		false	"Ship date to, inclusive"		format(date)
+//	@Param			userId		query		int			false	"Owner ID, admin only"
+//	@Param			limit		query		int			false	"Page size"						minimum(1)	maximum(100)	Default(20)
+//	@Param			cursor		query		string		false	"Cursor of the page to return"
+//	@Success		200			{object}	models.OrderPage
+//	@Header			200			{string}	Link	"first and next page links"
+//	@Failure		403			{object}	responder.Response	"orders of another user"
+//	@Router			/store/orders [get]
+func (sc StoreController) ListOrders(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query := models.OrderListQuery{
+		Status:   splitList(params.Get("status")),
+		ShipFrom: params.Get("shipFrom"),
+		ShipTo:   params.Get("shipTo"),
+		Cursor:   params.Get("cursor"),
+	}
+
+	var err error
+	if rawUserID := params.Get("userId"); rawUserID != "" {
+		query.UserID, err = strconv.Atoi(rawUserID)
+		if err != nil {
+			sc.responder.ErrorBadRequest(w, fmt.Errorf("invalid user id %q", rawUserID))
+			return
+		}
+	}
+
+	if rawLimit := params.Get("limit"); rawLimit != "" {
+		query.Limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			sc.responder.ErrorBadRequest(w, fmt.Errorf("invalid limit %q", rawLimit))
+			return
+		}
+	}
+
+	page, err := sc.storeService.ListOrders(r.Context(), query)
+	if err != nil {
+		sc.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(page, "", "  ")
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(r, ""))}
+	if page.Pagination.HasMore {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, page.Pagination.NextCursor)))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
 //	@id				4deleteOrder
+//	@Security		ApiKeyAuth
 //	@Summary		Delete purchase order by ID
-//	@Description	For valid response try integer IDs with positive integer value. Negative or non-integer values will generate API errors
+//	@Description	Orders are not removed, the order is cancelled like POST /store/order/{orderId}/cancel
 //	@Tags			store
 //	@Accept			json
 //	@Produce		json
 //	@Param			orderId	path		int	true	"ID of pet that needs to be deleted"
 //	@Success		200		{object}	responder.Response
+//	@Failure		403		{object}	responder.Response	"order belongs to another user"
+//	@Failure		409		{object}	responder.Response	"order cannot be cancelled"
 //	@Router			/store/order/{orderId} [delete]
 func (sc StoreController) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "orderId")
@@ -145,11 +250,142 @@ func (sc StoreController) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}	
 
-	err = sc.storeService.DeleteOrder(context.Background(), id)
+	err = sc.storeService.DeleteOrder(r.Context(), id)
 	if err != nil {
-		sc.responder.ErrorBadRequest(w, err)
+		sc.respondError(w, err)
 		return
 	}
 
 	sc.responder.Success(w, fmt.Sprint(id))
-}
\ No newline at end of file
+}
+
+//	@id				5approveOrder
+//	@Security		ApiKeyAuth
+//	@Summary		Approves a placed order
+//	@Description	Admin only. placed -> approved
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			orderId	path		int	true	"ID of order to approve"
+//	@Success		200		{object}	models.Order
+//	@Failure		403		{object}	responder.Response	"admin role required"
+//	@Failure		409		{object}	responder.Response	"illegal status transition"
+//	@Router			/store/order/{orderId}/approve [post]
+func (sc StoreController) ApproveOrder(w http.ResponseWriter, r *http.Request) {
+	sc.transition(w, r, sc.storeService.ApproveOrder)
+}
+
+//	@id				6shipOrder
+//	@Security		ApiKeyAuth
+//	@Summary		Ships an approved order
+//	@Description	Admin only. approved -> shipped
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			orderId	path		int	true	"ID of order to ship"
+//	@Success		200		{object}	models.Order
+//	@Failure		403		{object}	responder.Response	"admin role required"
+//	@Failure		409		{object}	responder.Response	"illegal status transition"
+//	@Router			/store/order/{orderId}/ship [post]
+func (sc StoreController) ShipOrder(w http.ResponseWriter, r *http.Request) {
+	sc.transition(w, r, sc.storeService.ShipOrder)
+}
+
+//	@id				7deliverOrder
+//	@Security		ApiKeyAuth
+//	@Summary		Marks a shipped order as delivered
+//	@Description	Admin only. shipped -> delivered, the order becomes complete
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			orderId	path		int	true	"ID of order to deliver"
+//	@Success		200		{object}	models.Order
+//	@Failure		403		{object}	responder.Response	"admin role required"
+//	@Failure		409		{object}	responder.Response	"illegal status transition"
+//	@Router			/store/order/{orderId}/deliver [post]
+func (sc StoreController) DeliverOrder(w http.ResponseWriter, r *http.Request) {
+	sc.transition(w, r, sc.storeService.DeliverOrder)
+}
+
+//	@id				8cancelOrder
+//	@Security		ApiKeyAuth
+//	@Summary		Cancels an order
+//	@Description	Only placed and approved orders can be cancelled, by their owner or an admin. Reserved pets become available again, accessories return to stock
+//	@Tags			store
+//	@Accept			json
+//	@Produce		json
+//	@Param			orderId	path		int	true	"ID of order to cancel"
+//	@Success		200		{object}	models.Order
+//	@Failure		403		{object}	responder.Response	"order belongs to another user"
+//	@Failure		409		{object}	responder.Response	"illegal status transition"
+//	@Router			/store/order/{orderId}/cancel [post]
+func (sc StoreController) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	sc.transition(w, r, sc.storeService.CancelOrder)
+}
+
+// transition переводит заказ из URL в следующий статус с помощью change.
+func (sc StoreController) transition(w http.ResponseWriter, r *http.Request,
+	change func(ctx context.Context, id int) (models.Order, error)) {
+	id, err := strconv.Atoi(chi.URLParam(r, "orderId"))
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	order, err := change(r.Context(), id)
+	if err != nil {
+		sc.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(order, "", "  ")
+	if err != nil {
+		sc.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+// respondError отвечает 403 на чужие заказы, 409 на недопустимую смену статуса
+// и конфликты, 400 на остальные ошибки.
+func (sc StoreController) respondError(w http.ResponseWriter, err error) {
+	var transitionErr *models.StatusTransitionError
+	var conflictErr *models.ConflictError
+
+	switch {
+	case errors.Is(err, models.ErrForbidden):
+		sc.responder.ErrorForbidden(w, err)
+	case errors.As(err, &transitionErr), errors.As(err, &conflictErr):
+		sc.responder.ErrorConflict(w, err)
+	default:
+		sc.responder.ErrorBadRequest(w, err)
+	}
+}
+
+// pageURL - адрес текущего запроса с другим курсором, остальные параметры сохраняются.
+func pageURL(r *http.Request, cursor string) string {
+	u := *r.URL
+	query := u.Query()
+	if cursor == "" {
+		query.Del("cursor")
+	} else {
+		query.Set("cursor", cursor)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.RequestURI()
+}
+
+// splitList разбирает значения, перечисленные через запятую, пропуская пустые.
+func splitList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"app/internal/infrastructure/db"
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const cartTable = "cart_items"
+
+var (
+	errCartItemNotFound  = errors.New("cart item not found")
+	errAccessoryNotFound = errors.New("accessory not found")
+)
+
+// cartLine - позиция корзины вместе с текущим состоянием питомца или аксессуара.
+type cartLine struct {
+	item      models.CartItem
+	priced    bool
+	petStatus string
+	stock     int
+	found     bool
+}
+
+// selectCart выбирает корзину пользователя с текущими названиями, ценами,
+// статусами питомцев и остатками аксессуаров.
+func selectCart(userID int) sq.SelectBuilder {
+	return sq.Select(
+		"cart_items.id",
+		"cart_items.pet_id",
+		"cart_items.accessory_id",
+		"cart_items.quantity",
+		"COALESCE(pets.name, accessories.name)",
+		"COALESCE(pets.price, accessories.price)",
+		"pets.status",
+		"accessories.stock",
+	).
+		From(cartTable).
+		LeftJoin("pets ON pets.id = cart_items.pet_id").
+		LeftJoin("accessories ON accessories.id = cart_items.accessory_id").
+		Where(sq.Eq{"cart_items.user_id": userID}).
+		OrderBy("cart_items.id")
+}
+
+func scanCartLines(rows *sql.Rows) ([]cartLine, error) {
+	lines := []cartLine{}
+	for rows.Next() {
+		var line cartLine
+		var petID, accessoryID, price, stock sql.NullInt64
+		var name, petStatus sql.NullString
+
+		err := rows.Scan(&line.item.ID, &petID, &accessoryID, &line.item.Quantity, &name, &price, &petStatus, &stock)
+		if err != nil {
+			return nil, err
+		}
+
+		line.item.PetID = int(petID.Int64)
+		line.item.AccessoryID = int(accessoryID.Int64)
+		line.item.Name = name.String
+		line.item.UnitPrice = price.Int64
+		line.priced = price.Valid
+		line.found = name.Valid
+		line.petStatus = petStatus.String
+		line.stock = int(stock.Int64)
+
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// GetCart возвращает позиции корзины пользователя с текущими ценами.
+func (r StoreRepository) GetCart(ctx context.Context, userID int) ([]models.CartItem, error) {
+	rows, err := selectCart(userID).
+		RunWith(r.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines, err := scanCartLines(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.CartItem, len(lines))
+	for i, line := range lines {
+		items[i] = line.item
+	}
+
+	return items, nil
+}
+
+// AddCartItem кладет позицию в корзину. Количество аксессуара, который уже лежит
+// в корзине, увеличивается, но не больше maxQuantity; питомец, который уже в
+// корзине, дает ConflictError.
+func (r StoreRepository) AddCartItem(ctx context.Context, userID int, item models.CartItem, maxQuantity int) error {
+	insert := sq.Insert(cartTable).
+		Columns("user_id", "pet_id", "accessory_id", "quantity", "created_at")
+
+	if item.PetID != 0 {
+		insert = insert.
+			Values(userID, item.PetID, nil, item.Quantity, time.Now().UTC()).
+			Suffix("ON CONFLICT (user_id, pet_id) DO NOTHING")
+	} else {
+		// предел проверяется в том же запросе, иначе параллельные добавления могли бы его превысить
+		insert = insert.
+			Values(userID, nil, item.AccessoryID, item.Quantity, time.Now().UTC()).
+			Suffix("ON CONFLICT (user_id, accessory_id) DO UPDATE SET quantity = cart_items.quantity + excluded.quantity "+
+				"WHERE cart_items.quantity + excluded.quantity <= ?", maxQuantity)
+	}
+
+	res, err := insert.RunWith(r.conn(ctx)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		if item.PetID == 0 {
+			return fmt.Errorf("accessory %d: quantity in the cart cannot exceed %d", item.AccessoryID, maxQuantity)
+		}
+		return models.NewConflictError(fmt.Sprintf("pet %d is already in the cart", item.PetID))
+	}
+
+	return nil
+}
+
+func (r StoreRepository) UpdateCartItem(ctx context.Context, userID int, itemID int, quantity int) error {
+	res, err := sq.Update(cartTable).
+		Set("quantity", quantity).
+		Where(sq.Eq{"id": itemID, "user_id": userID}).
+		RunWith(r.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkCartItemAffected(res)
+}
+
+func (r StoreRepository) DeleteCartItem(ctx context.Context, userID int, itemID int) error {
+	res, err := sq.Delete(cartTable).
+		Where(sq.Eq{"id": itemID, "user_id": userID}).
+		RunWith(r.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkCartItemAffected(res)
+}
+
+func checkCartItemAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return errCartItemNotFound
+	}
+
+	return nil
+}
+
+func (r StoreRepository) ClearCart(ctx context.Context, userID int) error {
+	_, err := sq.Delete(cartTable).
+		Where(sq.Eq{"user_id": userID}).
+		RunWith(r.conn(ctx)).
+		ExecContext(ctx)
+
+	return err
+}
+
+// Checkout в одной транзакции оформляет корзину пользователя order.UserID в заказ:
+// бронирует питомцев (pending), списывает аксессуары со склада, создает заказ с
+// позициями по текущим ценам и очищает корзину. Если питомца уже нельзя купить или
+// аксессуара не хватает, ничего не меняется и возвращается ConflictError.
+// Возвращаются заказ и забронированные питомцы в состоянии до брони.
+func (r StoreRepository) Checkout(ctx context.Context, order models.Order) (models.Order, []models.Pet, error) {
+	tx, err := db.BeginTx(ctx, r.db)
+	if err != nil {
+		return models.Order{}, nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := selectCart(order.UserID).
+		RunWith(tx).
+		QueryContext(ctx)
+	if err != nil {
+		return models.Order{}, nil, err
+	}
+
+	lines, err := scanCartLines(rows)
+	rows.Close()
+	if err != nil {
+		return models.Order{}, nil, err
+	}
+
+	if len(lines) == 0 {
+		return models.Order{}, nil, errors.New("cart is empty")
+	}
+
+	order.Items = make([]models.OrderItem, 0, len(lines))
+	order.Quantity = 0
+	order.Total = 0
+
+	for _, line := range lines {
+		item := line.item
+
+		order.Items = append(order.Items, models.OrderItem{
+			PetID:       item.PetID,
+			AccessoryID: item.AccessoryID,
+			Name:        item.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Subtotal:    item.UnitPrice * int64(item.Quantity),
+		})
+		order.Quantity += item.Quantity
+		order.Total += item.UnitPrice * int64(item.Quantity)
+	}
+
+	res, err := sq.Insert("orders").
+		Columns("pet_id", "user_id", "quantity", "ship_date", "status", "complete", "total").
+		Values(nil, order.UserID, order.Quantity, order.ShipDate, order.Status, order.Complete, order.Total).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return models.Order{}, nil, err
+	}
+
+	orderID, err := res.LastInsertId()
+	if err != nil {
+		return models.Order{}, nil, err
+	}
+	order.ID = int(orderID)
+
+	// питомцы бронируются за созданным заказом
+	var reserved []models.Pet
+
+	for _, line := range lines {
+		if line.item.PetID != 0 {
+			pet, err := reserveCartPet(ctx, tx, line, order.ID)
+			if err != nil {
+				return models.Order{}, nil, err
+			}
+			reserved = append(reserved, pet)
+		} else {
+			err = takeAccessory(ctx, tx, line)
+			if err != nil {
+				return models.Order{}, nil, err
+			}
+		}
+	}
+
+	for i, item := range order.Items {
+		var petID, accessoryID interface{}
+		if item.PetID != 0 {
+			petID = item.PetID
+		} else {
+			accessoryID = item.AccessoryID
+		}
+
+		res, err = sq.Insert("order_items").
+			Columns("order_id", "pet_id", "accessory_id", "name", "quantity", "unit_price").
+			Values(order.ID, petID, accessoryID, item.Name, item.Quantity, item.UnitPrice).
+			RunWith(tx).
+			ExecContext(ctx)
+		if err != nil {
+			return models.Order{}, nil, err
+		}
+
+		itemID, err := res.LastInsertId()
+		if err != nil {
+			return models.Order{}, nil, err
+		}
+		order.Items[i].ID = int(itemID)
+	}
+
+	_, err = sq.Delete(cartTable).
+		Where(sq.Eq{"user_id": order.UserID}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return models.Order{}, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Order{}, nil, err
+	}
+
+	return order, reserved, nil
+}
+
+// reserveCartPet бронирует питомца из корзины за заказом orderID так же, как PlaceOrder.
+func reserveCartPet(ctx context.Context, tx *db.Tx, line cartLine, orderID int) (models.Pet, error) {
+	petID := line.item.PetID
+
+	if !line.found {
+		return models.Pet{}, models.NewConflictError(fmt.Sprintf("pet %d no longer exists, remove it from the cart", petID))
+	}
+
+	if line.petStatus != models.PetStatusAvailable {
+		return models.Pet{}, models.NewConflictError(
+			fmt.Sprintf("pet %d is %s, only available pets can be ordered", petID, line.petStatus))
+	}
+
+	if !line.priced {
+		return models.Pet{}, models.NewConflictError(fmt.Sprintf("pet %d has no price and cannot be ordered from the cart", petID))
+	}
+
+	pet, err := getOrderPet(ctx, tx, petID)
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	err = reservePet(ctx, tx, petID, orderID)
+	if err != nil {
+		return models.Pet{}, err
+	}
+
+	return pet, nil
+}
+
+// takeAccessory списывает аксессуар из корзины со склада.
+func takeAccessory(ctx context.Context, tx *db.Tx, line cartLine) error {
+	item := line.item
+
+	if !line.found {
+		return models.NewConflictError(fmt.Sprintf("accessory %d no longer exists, remove it from the cart", item.AccessoryID))
+	}
+
+	// условие на остаток защищает и там, где транзакции не блокируют базу сразу (Postgres)
+	res, err := sq.Update("accessories").
+		Set("stock", sq.Expr("stock - ?", item.Quantity)).
+		Where(sq.And{sq.Eq{"id": item.AccessoryID}, sq.GtOrEq{"stock": item.Quantity}}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.NewConflictError(
+			fmt.Sprintf("only %d of %s (accessory %d) left in stock, %d requested", line.stock, item.Name, item.AccessoryID, item.Quantity))
+	}
+
+	return nil
+}
+
+func (r StoreRepository) GetAccessory(ctx context.Context, id int) (models.Accessory, error) {
+	var accessory models.Accessory
+
+	err := sq.Select("id", "name", "price", "stock").
+		From("accessories").
+		Where(sq.Eq{"id": id}).
+		RunWith(r.conn(ctx)).
+		QueryRowContext(ctx).
+		Scan(&accessory.ID, &accessory.Name, &accessory.Price, &accessory.Stock)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Accessory{}, errAccessoryNotFound
+		}
+		return models.Accessory{}, err
+	}
+
+	return accessory, nil
+}
+
+func (r StoreRepository) ListAccessories(ctx context.Context) ([]models.Accessory, error) {
+	rows, err := sq.Select("id", "name", "price", "stock").
+		From("accessories").
+		OrderBy("id").
+		RunWith(r.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accessories := []models.Accessory{}
+	for rows.Next() {
+		var accessory models.Accessory
+		if err = rows.Scan(&accessory.ID, &accessory.Name, &accessory.Price, &accessory.Stock); err != nil {
+			return nil, err
+		}
+		accessories = append(accessories, accessory)
+	}
+
+	return accessories, rows.Err()
+}
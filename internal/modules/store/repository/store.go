@@ -1,20 +1,37 @@
 package repository
 
 import (
+	"app/internal/infrastructure/db"
 	"app/internal/models"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 )
 
 type StoreRepositoryer interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 	GetInventory(ctx context.Context) (map[string]int, error)
-	PlaceOrder(ctx context.Context, order models.Order) (models.Order, error)
+	PlaceOrder(ctx context.Context, order models.Order) (models.Order, models.Pet, error)
 	GetOrderById(ctx context.Context, id int) (models.Order, error)
-	DeleteOrder(ctx context.Context, id int) error
+	ListOrders(ctx context.Context, query models.OrderListQuery, afterID int) ([]models.Order, error)
+	UpdateOrderStatus(ctx context.Context, order models.Order, from string, petStatus string, restock bool) ([]models.Pet, error)
+	GetCart(ctx context.Context, userID int) ([]models.CartItem, error)
+	AddCartItem(ctx context.Context, userID int, item models.CartItem, maxQuantity int) error
+	UpdateCartItem(ctx context.Context, userID int, itemID int, quantity int) error
+	DeleteCartItem(ctx context.Context, userID int, itemID int) error
+	ClearCart(ctx context.Context, userID int) error
+	Checkout(ctx context.Context, order models.Order) (models.Order, []models.Pet, error)
+	GetAccessory(ctx context.Context, id int) (models.Accessory, error)
+	ListAccessories(ctx context.Context) ([]models.Accessory, error)
 }
 
+// dateLayout - формат дат в фильтрах заказов.
+const dateLayout = "2006-01-02"
+
 type StoreRepository struct {
 	db *sql.DB
 }
@@ -25,6 +42,17 @@ func NewStoreRepository(db *sql.DB) StoreRepositoryer {
 	}
 }
 
+// InTx выполняет fn в транзакции: методы репозитория, вызванные с контекстом fn,
+// работают в ней, как и методы других репозиториев той же базы.
+func (r StoreRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.InTx(ctx, r.db, fn)
+}
+
+// conn - транзакция InTx из ctx или, вне ее, сама база.
+func (r StoreRepository) conn(ctx context.Context) db.Conn {
+	return db.ConnFrom(ctx, r.db)
+}
+
 func (r StoreRepository) GetInventory(ctx context.Context) (map[string]int, error) {
 	var available, pending, sold sql.NullInt64
 
@@ -34,7 +62,7 @@ func (r StoreRepository) GetInventory(ctx context.Context) (map[string]int, erro
 		"COUNT(id) FILTER(WHERE status = 'sold')",
 		).
 		From("pets").
-		RunWith(r.db).
+		RunWith(r.conn(ctx)).
 		ScanContext(ctx, &available, &pending, &sold)
 	if err != nil {
 		return nil, err
@@ -49,58 +77,361 @@ func (r StoreRepository) GetInventory(ctx context.Context) (map[string]int, erro
 	return inventory, nil
 }	
 
-func (r StoreRepository) PlaceOrder(ctx context.Context, order models.Order) (models.Order, error) {
+// PlaceOrder в одной транзакции проверяет, что питомец в продаже, создает заказ и
+// бронирует питомца за ним (pending). Возвращает питомца в состоянии до брони.
+// Транзакции SQLite открываются как BEGIN IMMEDIATE (см. db.NewDataBaseSqlite), поэтому
+// из двух одновременных заказов второй дождется первого и увидит питомца уже забронированным.
+func (r StoreRepository) PlaceOrder(ctx context.Context, order models.Order) (models.Order, models.Pet, error) {
+	tx, err := db.BeginTx(ctx, r.db)
+	if err != nil {
+		return models.Order{}, models.Pet{}, err
+	}
+	defer tx.Rollback()
+
+	pet, err := getOrderPet(ctx, tx, order.PetID)
+	if err != nil {
+		return models.Order{}, models.Pet{}, err
+	}
+
+	if pet.Status != models.PetStatusAvailable {
+		return models.Order{}, models.Pet{}, models.NewConflictError(
+			fmt.Sprintf("pet %d is %s, only available pets can be ordered", pet.ID, pet.Status))
+	}
+
+	var userID interface{}
+	if order.UserID != 0 {
+		userID = order.UserID
+	}
+
 	res, err := sq.Insert("orders").
-		Columns("pet_id", "quantity", "ship_date", "status", "complete").
-		Values(order.PetID, order.Quantity, order.ShipDate, order.Status, order.Complete).
-		RunWith(r.db).
+		Columns("pet_id", "user_id", "quantity", "ship_date", "status", "complete").
+		Values(order.PetID, userID, order.Quantity, order.ShipDate, order.Status, order.Complete).
+		RunWith(tx).
 		ExecContext(ctx)
 	if err != nil {
-		return models.Order{}, err
+		return models.Order{}, models.Pet{}, err
 	}
 
 	id, err := res.LastInsertId()
 	if err != nil {
-		return models.Order{}, err
+		return models.Order{}, models.Pet{}, err
 	}
-
 	order.ID = int(id)
 
-	return order, nil
+	err = reservePet(ctx, tx, pet.ID, order.ID)
+	if err != nil {
+		return models.Order{}, models.Pet{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Order{}, models.Pet{}, err
+	}
+
+	return order, pet, nil
 }
 
-func (r StoreRepository) GetOrderById(ctx context.Context, id int) (models.Order, error) {
-	var order models.Order
-	err := sq.Select(
-		"id", 
+func selectOrders() sq.SelectBuilder {
+	return sq.Select(
+		"id",
 		"pet_id",
+		"user_id",
 		"quantity",
 		"ship_date",
 		"status",
 		"complete",
-		).
-		From("orders").
-		Where(sq.Eq{"id": id}).
-		RunWith(r.db).
-		ScanContext(ctx, &order.ID, &order.PetID, &order.Quantity, &order.ShipDate, &order.Status, &order.Complete)
+		"total",
+	).
+		From("orders")
+}
+
+func scanOrder(row sq.RowScanner) (models.Order, error) {
+	var order models.Order
+	var petID, userID, total sql.NullInt64
+
+	err := row.Scan(&order.ID, &petID, &userID, &order.Quantity, &order.ShipDate, &order.Status, &order.Complete, &total)
 	if err != nil {
 		return models.Order{}, err
 	}
 
+	order.PetID = int(petID.Int64)
+	order.UserID = int(userID.Int64)
+	order.Total = total.Int64
+
 	return order, nil
 }
 
-func (r StoreRepository) DeleteOrder(ctx context.Context, id int) error {
-	_, err := sq.Update("orders").
+func (r StoreRepository) GetOrderById(ctx context.Context, id int) (models.Order, error) {
+	order, err := scanOrder(selectOrders().
+		Where(sq.Eq{"id": id}).
+		RunWith(r.conn(ctx)).
+		QueryRowContext(ctx))
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	orders := []models.Order{order}
+	if err = r.loadOrderItems(ctx, orders); err != nil {
+		return models.Order{}, err
+	}
+
+	return orders[0], nil
+}
+
+// ListOrders возвращает не больше query.Limit заказов по фильтру, новые первыми.
+// С afterID - только заказы старше него (следующая страница).
+func (r StoreRepository) ListOrders(ctx context.Context, query models.OrderListQuery, afterID int) ([]models.Order, error) {
+	where := sq.And{}
+
+	if query.UserID != 0 {
+		where = append(where, sq.Eq{"user_id": query.UserID})
+	}
+	if len(query.Status) > 0 {
+		where = append(where, sq.Eq{"status": query.Status})
+	}
+	if query.ShipFrom != "" {
+		where = append(where, sq.GtOrEq{"ship_date": query.ShipFrom})
+	}
+	// ship_date может храниться со временем, поэтому верхняя граница - начало следующего дня
+	if query.ShipTo != "" {
+		shipTo, err := time.Parse(dateLayout, query.ShipTo)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, sq.Lt{"ship_date": shipTo.AddDate(0, 0, 1).Format(dateLayout)})
+	}
+	if afterID != 0 {
+		where = append(where, sq.Lt{"id": afterID})
+	}
+
+	rows, err := selectOrders().
+		Where(where).
+		OrderBy("id DESC").
+		Limit(uint64(query.Limit)).
+		RunWith(r.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = r.loadOrderItems(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// loadOrderItems одним запросом загружает позиции заказов из корзины.
+func (r StoreRepository) loadOrderItems(ctx context.Context, orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(orders))
+	byID := make(map[int]*models.Order, len(orders))
+	for i := range orders {
+		ids[i] = orders[i].ID
+		byID[orders[i].ID] = &orders[i]
+	}
+
+	rows, err := sq.Select("id", "order_id", "pet_id", "accessory_id", "name", "quantity", "unit_price").
+		From("order_items").
+		Where(sq.Eq{"order_id": ids}).
+		OrderBy("order_id", "id").
+		RunWith(r.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.OrderItem
+		var orderID int
+		var petID, accessoryID sql.NullInt64
+
+		err = rows.Scan(&item.ID, &orderID, &petID, &accessoryID, &item.Name, &item.Quantity, &item.UnitPrice)
+		if err != nil {
+			return err
+		}
+
+		item.PetID = int(petID.Int64)
+		item.AccessoryID = int(accessoryID.Int64)
+		item.Subtotal = item.UnitPrice * int64(item.Quantity)
+
+		order := byID[orderID]
+		order.Items = append(order.Items, item)
+	}
+
+	return rows.Err()
+}
+
+// UpdateOrderStatus сохраняет статус и признак завершения заказа, только если
+// заказ все еще в статусе from. Иначе его успели изменить, и возвращается ConflictError.
+// Если petStatus не пустой, в той же транзакции питомцы, которых забронировал этот
+// заказ, переводятся в petStatus; питомцев, забронированных другими заказами, заказ
+// не трогает. С restock аксессуары из позиций возвращаются на склад. Возвращаются
+// измененные питомцы в состоянии до изменения.
+func (r StoreRepository) UpdateOrderStatus(ctx context.Context, order models.Order, from string, petStatus string, restock bool) ([]models.Pet, error) {
+	tx, err := db.BeginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := sq.Update("orders").
 		SetMap(map[string]interface{}{
-			"status": "deleted",
+			"status":   order.Status,
+			"complete": order.Complete,
 		}).
-		Where(sq.Eq{"id": id}).
-		RunWith(r.db).
+		Where(sq.Eq{"id": order.ID, "status": from}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, models.NewConflictError("order status was changed concurrently, retry")
+	}
+
+	var changed []models.Pet
+
+	if petStatus != "" {
+		changed, err = releasePets(ctx, tx, order.ID, petStatus)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if restock {
+		for _, item := range order.Items {
+			if item.AccessoryID == 0 {
+				continue
+			}
+
+			_, err = sq.Update("accessories").
+				Set("stock", sq.Expr("stock + ?", item.Quantity)).
+				Where(sq.Eq{"id": item.AccessoryID}).
+				RunWith(tx).
+				ExecContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return changed, tx.Commit()
+}
+
+var errPetNotFound = errors.New("pet not found")
+
+// getOrderPet читает питомца заказа в транзакции tx.
+func getOrderPet(ctx context.Context, tx *db.Tx, petID int) (models.Pet, error) {
+	var pet models.Pet
+	var status sql.NullString
+
+	err := sq.Select("id", "name", "status", "version").
+		From("pets").
+		Where(sq.Eq{"id": petID}).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&pet.ID, &pet.Name, &status, &pet.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Pet{}, errPetNotFound
+		}
+		return models.Pet{}, err
+	}
+
+	pet.Status = status.String
+
+	return pet, nil
+}
+
+// reservePet бронирует питомца в продаже за заказом orderID.
+func reservePet(ctx context.Context, tx *db.Tx, petID int, orderID int) error {
+	// условие на статус защищает и там, где транзакции не блокируют базу сразу (Postgres)
+	res, err := sq.Update("pets").
+		Set("status", models.PetStatusPending).
+		Set("reserved_by_order", orderID).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": petID, "status": models.PetStatusAvailable}).
+		RunWith(tx).
 		ExecContext(ctx)
 	if err != nil {
 		return err
 	}
 
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.NewConflictError(fmt.Sprintf("pet %d is no longer %s", petID, models.PetStatusAvailable))
+	}
+
 	return nil
 }
+
+// releasePets переводит питомцев, забронированных заказом orderID, в статус to
+// и снимает бронь. Возвращает питомцев в состоянии до изменения.
+func releasePets(ctx context.Context, tx *db.Tx, orderID int, to string) ([]models.Pet, error) {
+	rows, err := sq.Select("id", "name", "status", "version").
+		From("pets").
+		Where(sq.Eq{"reserved_by_order": orderID, "status": models.PetStatusPending}).
+		OrderBy("id").
+		RunWith(tx).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pets []models.Pet
+	for rows.Next() {
+		var pet models.Pet
+		if err = rows.Scan(&pet.ID, &pet.Name, &pet.Status, &pet.Version); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pets = append(pets, pet)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, pet := range pets {
+		_, err = sq.Update("pets").
+			Set("status", to).
+			Set("reserved_by_order", nil).
+			Set("version", sq.Expr("version + 1")).
+			Where(sq.Eq{"id": pet.ID, "reserved_by_order": orderID}).
+			RunWith(tx).
+			ExecContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pets, nil
+}
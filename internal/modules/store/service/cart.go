@@ -0,0 +1,209 @@
+package service
+
+import (
+	"app/internal/models"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// maxCartQuantity - ограничение количества одного аксессуара в позиции корзины.
+const maxCartQuantity = 99
+
+// GetCart возвращает корзину пользователя из JWT с текущими ценами и суммой.
+func (s StoreService) GetCart(ctx context.Context) (models.Cart, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return s.cart(ctx, userID)
+}
+
+// AddCartItem кладет в корзину питомца или аксессуар. Питомца можно положить, только
+// если он в продаже и у него есть цена; бронируется он лишь при оформлении заказа.
+func (s StoreService) AddCartItem(ctx context.Context, item models.CartItem) (models.Cart, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	if (item.PetID == 0) == (item.AccessoryID == 0) {
+		return models.Cart{}, errors.New("cart item must have either petId or accessoryId")
+	}
+
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+
+	if item.PetID != 0 {
+		if item.Quantity != 1 {
+			return models.Cart{}, errors.New("a pet can be added to the cart only once")
+		}
+
+		pet, err := s.petService.GetPetById(ctx, item.PetID)
+		if err != nil {
+			return models.Cart{}, err
+		}
+
+		if pet.Status != models.PetStatusAvailable {
+			return models.Cart{}, models.NewConflictError(
+				fmt.Sprintf("pet %d is %s, only available pets can be ordered", pet.ID, pet.Status))
+		}
+
+		if pet.Price == nil {
+			return models.Cart{}, fmt.Errorf("pet %d has no price and cannot be added to the cart", pet.ID)
+		}
+	} else {
+		if err = checkCartQuantity(item.Quantity); err != nil {
+			return models.Cart{}, err
+		}
+
+		if _, err = s.storeRepository.GetAccessory(ctx, item.AccessoryID); err != nil {
+			return models.Cart{}, err
+		}
+	}
+
+	if err = s.storeRepository.AddCartItem(ctx, userID, item, maxCartQuantity); err != nil {
+		return models.Cart{}, err
+	}
+
+	return s.cart(ctx, userID)
+}
+
+// UpdateCartItem меняет количество аксессуара в корзине.
+func (s StoreService) UpdateCartItem(ctx context.Context, itemID int, quantity int) (models.Cart, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	cart, err := s.cart(ctx, userID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	item, ok := findCartItem(cart, itemID)
+	if !ok {
+		return models.Cart{}, errors.New("cart item not found")
+	}
+
+	if item.PetID != 0 {
+		return models.Cart{}, errors.New("a pet is always ordered alone, its quantity cannot be changed")
+	}
+
+	if err = checkCartQuantity(quantity); err != nil {
+		return models.Cart{}, err
+	}
+
+	if err = s.storeRepository.UpdateCartItem(ctx, userID, itemID, quantity); err != nil {
+		return models.Cart{}, err
+	}
+
+	return s.cart(ctx, userID)
+}
+
+func (s StoreService) DeleteCartItem(ctx context.Context, itemID int) (models.Cart, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	if err = s.storeRepository.DeleteCartItem(ctx, userID, itemID); err != nil {
+		return models.Cart{}, err
+	}
+
+	return s.cart(ctx, userID)
+}
+
+func (s StoreService) ClearCart(ctx context.Context) error {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.storeRepository.ClearCart(ctx, userID)
+}
+
+// Checkout оформляет корзину в один заказ со статусом placed: в одной транзакции
+// бронирует всех питомцев, списывает аксессуары со склада и очищает корзину.
+// Если хоть одну позицию купить нельзя, заказ не создается (ConflictError).
+func (s StoreService) Checkout(ctx context.Context, checkout models.CartCheckout) (models.Order, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if err = validateShipDate(checkout.ShipDate); err != nil {
+		return models.Order{}, err
+	}
+
+	var order models.Order
+	var pets []models.Pet
+	err = s.storeRepository.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		order, pets, err = s.storeRepository.Checkout(ctx, models.Order{
+			UserID:   userID,
+			ShipDate: checkout.ShipDate,
+			Status:   models.OrderStatusPlaced,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, pet := range pets {
+			if err = s.recordPetStatus(ctx, pet, models.PetStatusPending); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	for _, pet := range pets {
+		s.petStatusChanged(ctx, pet, models.PetStatusPending)
+	}
+
+	return order, nil
+}
+
+func (s StoreService) ListAccessories(ctx context.Context) ([]models.Accessory, error) {
+	return s.storeRepository.ListAccessories(ctx)
+}
+
+// cart собирает корзину и считает суммы по текущим ценам.
+func (s StoreService) cart(ctx context.Context, userID int) (models.Cart, error) {
+	items, err := s.storeRepository.GetCart(ctx, userID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	cart := models.Cart{Items: items}
+	for i := range cart.Items {
+		cart.Items[i].Subtotal = cart.Items[i].UnitPrice * int64(cart.Items[i].Quantity)
+		cart.Total += cart.Items[i].Subtotal
+	}
+
+	return cart, nil
+}
+
+func findCartItem(cart models.Cart, itemID int) (models.CartItem, bool) {
+	for _, item := range cart.Items {
+		if item.ID == itemID {
+			return item, true
+		}
+	}
+
+	return models.CartItem{}, false
+}
+
+func checkCartQuantity(quantity int) error {
+	if quantity < 1 || quantity > maxCartQuantity {
+		return fmt.Errorf("quantity must be between 1 and %d", maxCartQuantity)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"app/internal/models"
+	"fmt"
+)
+
+// orderStatusTransitions - жизненный цикл заказа: оформлен -> подтвержден -> отправлен ->
+// доставлен. Отменить можно оформленный или подтвержденный заказ, доставленный
+// и отмененный заказы больше не меняются.
+var orderStatusTransitions = map[string][]string{
+	models.OrderStatusPlaced:    {models.OrderStatusApproved, models.OrderStatusCancelled},
+	models.OrderStatusApproved:  {models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusShipped:   {models.OrderStatusDelivered},
+	models.OrderStatusDelivered: {},
+	models.OrderStatusCancelled: {},
+}
+
+// checkOrderStatusTransition проверяет смену статуса заказа from -> to. В отличие от
+// питомца, повторная установка того же статуса - ошибка: заказ нельзя дважды отправить
+// или отменить. Заказ со статусом вне жизненного цикла (такие могли остаться с тех пор,
+// как статус был произвольной строкой) можно перевести в любой статус.
+func checkOrderStatusTransition(from string, to string) error {
+	if _, ok := orderStatusTransitions[to]; !ok {
+		return fmt.Errorf("invalid order status %q", to)
+	}
+
+	allowed, ok := orderStatusTransitions[from]
+	if !ok {
+		return nil
+	}
+
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+
+	return &models.StatusTransitionError{Entity: "order", From: from, To: to}
+}
@@ -1,33 +1,85 @@
 package service
 
 import (
+	customMiddleware "app/internal/infrastructure/middleware"
 	"app/internal/models"
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+
+	// dateLayout - формат дат в фильтрах списка заказов.
+	dateLayout = "2006-01-02"
 )
 
 type StoreServicer interface {
 	GetInventory(ctx context.Context) (map[string]int, error)
 	PlaceOrder(ctx context.Context, order models.Order) (models.Order, error)
 	GetOrderById(ctx context.Context, id int) (models.Order, error)
+	ListOrders(ctx context.Context, query models.OrderListQuery) (models.OrderPage, error)
 	DeleteOrder(ctx context.Context, id int) error
+	ApproveOrder(ctx context.Context, id int) (models.Order, error)
+	ShipOrder(ctx context.Context, id int) (models.Order, error)
+	DeliverOrder(ctx context.Context, id int) (models.Order, error)
+	CancelOrder(ctx context.Context, id int) (models.Order, error)
+	GetCart(ctx context.Context) (models.Cart, error)
+	AddCartItem(ctx context.Context, item models.CartItem) (models.Cart, error)
+	UpdateCartItem(ctx context.Context, itemID int, quantity int) (models.Cart, error)
+	DeleteCartItem(ctx context.Context, itemID int) (models.Cart, error)
+	ClearCart(ctx context.Context) error
+	Checkout(ctx context.Context, checkout models.CartCheckout) (models.Order, error)
+	ListAccessories(ctx context.Context) ([]models.Accessory, error)
 }
 
 type StoreRepositoryer interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 	GetInventory(ctx context.Context) (map[string]int, error)
-	PlaceOrder(ctx context.Context, order models.Order) (models.Order, error)
+	PlaceOrder(ctx context.Context, order models.Order) (models.Order, models.Pet, error)
 	GetOrderById(ctx context.Context, id int) (models.Order, error)
-	DeleteOrder(ctx context.Context, id int) error
+	ListOrders(ctx context.Context, query models.OrderListQuery, afterID int) ([]models.Order, error)
+	UpdateOrderStatus(ctx context.Context, order models.Order, from string, petStatus string, restock bool) ([]models.Pet, error)
+	GetCart(ctx context.Context, userID int) ([]models.CartItem, error)
+	AddCartItem(ctx context.Context, userID int, item models.CartItem, maxQuantity int) error
+	UpdateCartItem(ctx context.Context, userID int, itemID int, quantity int) error
+	DeleteCartItem(ctx context.Context, userID int, itemID int) error
+	ClearCart(ctx context.Context, userID int) error
+	Checkout(ctx context.Context, order models.Order) (models.Order, []models.Pet, error)
+	GetAccessory(ctx context.Context, id int) (models.Accessory, error)
+	ListAccessories(ctx context.Context) ([]models.Accessory, error)
+}
+
+// PetServicer - то, что заказам нужно от питомцев. Статус питомца заказ меняет
+// сам в своей транзакции, PetService в той же транзакции записывает это в историю,
+// а после фиксации уведомляет лист ожидания.
+type PetServicer interface {
+	GetPetById(ctx context.Context, id int) (models.Pet, error)
+	RecordPetStatusChange(ctx context.Context, before models.Pet, after models.Pet) error
+	PetStatusChanged(ctx context.Context, before models.Pet, after models.Pet)
+}
+
+// orderPetStatuses - в какой статус переходит забронированный питомец вместе с заказом.
+var orderPetStatuses = map[string]string{
+	models.OrderStatusDelivered: models.PetStatusSold,
+	models.OrderStatusCancelled: models.PetStatusAvailable,
 }
 
 type StoreService struct {
 	storeRepository StoreRepositoryer
+	petService      PetServicer
 }
 
-func NewStoreService(storeRepository StoreRepositoryer) StoreServicer {
+func NewStoreService(storeRepository StoreRepositoryer, petService PetServicer) StoreServicer {
 	return &StoreService{
 		storeRepository: storeRepository,
+		petService:      petService,
 	}
 }
 
@@ -35,30 +87,304 @@ func (s StoreService) GetInventory(ctx context.Context) (map[string]int, error)
 	return s.storeRepository.GetInventory(ctx)
 }
 
+// PlaceOrder оформляет заказ и в той же транзакции бронирует питомца (pending).
+// Заказать можно только питомца в продаже: если его продали, удалили или уже
+// забронировал другой заказ, возвращается ConflictError. Новый заказ всегда
+// начинается со статуса placed, дальше он меняется только через переходы.
+// Владелец заказа - пользователь из JWT; администратор может оформить заказ
+// на другого пользователя, указав order.UserID (так делают заявки на усыновление).
+// Питомец в заказе один: пустое количество считается единицей, другое отклоняется.
 func (s StoreService) PlaceOrder(ctx context.Context, order models.Order) (models.Order, error) {
-	return s.storeRepository.PlaceOrder(ctx, order)
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if order.UserID == 0 {
+		order.UserID = userID
+	}
+
+	if order.UserID != userID && !isAdmin(ctx) {
+		return models.Order{}, models.ErrForbidden
+	}
+
+	if order.Status == "" {
+		order.Status = models.OrderStatusPlaced
+	}
+
+	if order.Status != models.OrderStatusPlaced {
+		return models.Order{}, fmt.Errorf("new order must have status %q, got %q", models.OrderStatusPlaced, order.Status)
+	}
+
+	if order.Quantity == 0 {
+		order.Quantity = 1
+	}
+
+	if order.Quantity != 1 {
+		return models.Order{}, errors.New("a pet is ordered only once, quantity must be 1")
+	}
+
+	if err = validateShipDate(order.ShipDate); err != nil {
+		return models.Order{}, err
+	}
+
+	order.Complete = false
+
+	var pet models.Pet
+	err = s.storeRepository.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		order, pet, err = s.storeRepository.PlaceOrder(ctx, order)
+		if err != nil {
+			return err
+		}
+
+		return s.recordPetStatus(ctx, pet, models.PetStatusPending)
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	s.petStatusChanged(ctx, pet, models.PetStatusPending)
+
+	return order, nil
 }
 
+// GetOrderById возвращает заказ его владельцу или администратору.
 func (s StoreService) GetOrderById(ctx context.Context, id int) (models.Order, error) {
 	order, err := s.storeRepository.GetOrderById(ctx, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.Order{}, errors.New("order not found")
 		}
+		return models.Order{}, err
+	}
+
+	if !canAccess(ctx, order) {
+		return models.Order{}, models.ErrForbidden
 	}
 
 	return order, nil
 }
 
+// ListOrders возвращает страницу заказов по фильтру, новые первыми. Администратор
+// видит заказы всех пользователей (или одного, если задан query.UserID), остальные - только свои.
+func (s StoreService) ListOrders(ctx context.Context, query models.OrderListQuery) (models.OrderPage, error) {
+	if !isAdmin(ctx) {
+		userID, err := currentUserID(ctx)
+		if err != nil {
+			return models.OrderPage{}, err
+		}
+		if query.UserID != 0 && query.UserID != userID {
+			return models.OrderPage{}, models.ErrForbidden
+		}
+		query.UserID = userID
+	}
+
+	for _, status := range query.Status {
+		if _, ok := orderStatusTransitions[status]; !ok {
+			return models.OrderPage{}, fmt.Errorf("invalid order status %q", status)
+		}
+	}
+
+	for _, date := range []string{query.ShipFrom, query.ShipTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return models.OrderPage{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+		}
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultPageLimit
+	}
+	if query.Limit < 0 || query.Limit > maxPageLimit {
+		return models.OrderPage{}, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+
+	var afterID int
+	if query.Cursor != "" {
+		var err error
+		afterID, err = decodeCursor(query.Cursor)
+		if err != nil {
+			return models.OrderPage{}, err
+		}
+	}
+
+	// запрашиваем на один больше, чтобы узнать, есть ли следующая страница
+	pageQuery := query
+	pageQuery.Limit++
+
+	orders, err := s.storeRepository.ListOrders(ctx, pageQuery, afterID)
+	if err != nil {
+		return models.OrderPage{}, err
+	}
+
+	page := models.OrderPage{
+		Items: orders,
+		Pagination: models.Pagination{
+			Limit: query.Limit,
+			Sort:  "-id",
+		},
+	}
+
+	if len(orders) > query.Limit {
+		page.Items = orders[:query.Limit]
+		page.Pagination.HasMore = true
+		page.Pagination.NextCursor = encodeCursor(page.Items[len(page.Items)-1].ID)
+	}
+
+	return page, nil
+}
+
+// DeleteOrder отменяет заказ: заказы не удаляются, а переходят в cancelled.
 func (s StoreService) DeleteOrder(ctx context.Context, id int) error {
+	_, err := s.CancelOrder(ctx, id)
+	return err
+}
+
+func (s StoreService) ApproveOrder(ctx context.Context, id int) (models.Order, error) {
+	return s.changeStatus(ctx, id, models.OrderStatusApproved)
+}
+
+func (s StoreService) ShipOrder(ctx context.Context, id int) (models.Order, error) {
+	return s.changeStatus(ctx, id, models.OrderStatusShipped)
+}
+
+func (s StoreService) DeliverOrder(ctx context.Context, id int) (models.Order, error) {
+	return s.changeStatus(ctx, id, models.OrderStatusDelivered)
+}
+
+func (s StoreService) CancelOrder(ctx context.Context, id int) (models.Order, error) {
+	return s.changeStatus(ctx, id, models.OrderStatusCancelled)
+}
+
+// changeStatus переводит заказ в статус to, если переход допустим. Complete
+// пересчитывается из статуса: заказ завершен, только когда доставлен. При доставке
+// забронированные питомцы продаются, при отмене - возвращаются в продажу, а аксессуары
+// из корзины - на склад.
+func (s StoreService) changeStatus(ctx context.Context, id int, to string) (models.Order, error) {
 	order, err := s.GetOrderById(ctx, id)
 	if err != nil {
-		return err
+		return models.Order{}, err
+	}
+
+	err = checkOrderStatusTransition(order.Status, to)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	from := order.Status
+	order.Status = to
+	order.Complete = to == models.OrderStatusDelivered
+
+	var pets []models.Pet
+	err = s.storeRepository.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		pets, err = s.storeRepository.UpdateOrderStatus(ctx, order, from, orderPetStatuses[to], to == models.OrderStatusCancelled)
+		if err != nil {
+			return err
+		}
+
+		for _, pet := range pets {
+			if err = s.recordPetStatus(ctx, pet, orderPetStatuses[to]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	for _, pet := range pets {
+		s.petStatusChanged(ctx, pet, orderPetStatuses[to])
+	}
+
+	return order, nil
+}
+
+// recordPetStatus записывает в историю питомца before переход в status, сделанный
+// заказом; вызывается в транзакции заказа.
+func (s StoreService) recordPetStatus(ctx context.Context, before models.Pet, status string) error {
+	return s.petService.RecordPetStatusChange(ctx, before, petWithStatus(before, status))
+}
+
+// petStatusChanged сообщает PetService о зафиксированной смене статуса питомца.
+func (s StoreService) petStatusChanged(ctx context.Context, before models.Pet, status string) {
+	s.petService.PetStatusChanged(ctx, before, petWithStatus(before, status))
+}
+
+func petWithStatus(before models.Pet, status string) models.Pet {
+	after := before
+	after.Status = status
+	after.Version++
+
+	return after
+}
+
+// currentUserID возвращает ID пользователя из JWT. Токены, выданные до того, как
+// в них стали записывать ID, не подходят.
+func currentUserID(ctx context.Context) (int, error) {
+	userID := customMiddleware.UserIDFromContext(ctx)
+	if userID == 0 {
+		return 0, fmt.Errorf("%w: token has no user id, log in again", models.ErrForbidden)
+	}
+
+	return userID, nil
+}
+
+func isAdmin(ctx context.Context) bool {
+	return customMiddleware.RoleFromContext(ctx) == models.UserRoleAdmin
+}
+
+// canAccess - заказ видят его владелец и администраторы. Заказы без владельца,
+// оформленные до появления владельцев, - только администраторы.
+func canAccess(ctx context.Context, order models.Order) bool {
+	if isAdmin(ctx) {
+		return true
+	}
+
+	return order.UserID != 0 && order.UserID == customMiddleware.UserIDFromContext(ctx)
+}
+
+// orderCursor - ID последнего заказа предыдущей страницы.
+type orderCursor struct {
+	ID int `json:"id"`
+}
+
+// encodeCursor упаковывает курсор в непрозрачную для клиента строку.
+func encodeCursor(id int) string {
+	data, _ := json.Marshal(orderCursor{ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (int, error) {
+	var cursor orderCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return 0, errors.New("invalid cursor")
+	}
+
+	return cursor.ID, nil
+}
+
+// validateShipDate проверяет дату доставки нового заказа: дата (YYYY-MM-DD)
+// или дата со временем в RFC 3339.
+func validateShipDate(shipDate string) error {
+	if _, err := time.Parse(dateLayout, shipDate); err == nil {
+		return nil
 	}
 
-	if order.Status == "deleted" {
-		return errors.New("order already deleted")
+	if _, err := time.Parse(time.RFC3339, shipDate); err == nil {
+		return nil
 	}
 
-	return s.storeRepository.DeleteOrder(ctx, id)
+	return fmt.Errorf("invalid shipDate %q, expected YYYY-MM-DD or RFC 3339 date-time", shipDate)
 }
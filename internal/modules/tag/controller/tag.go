@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"app/internal/infrastructure/responder"
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type TagControllerer interface {
+	ListTags(w http.ResponseWriter, r *http.Request)
+	GetTagById(w http.ResponseWriter, r *http.Request)
+	CreateTag(w http.ResponseWriter, r *http.Request)
+	RenameTag(w http.ResponseWriter, r *http.Request)
+	MergeTags(w http.ResponseWriter, r *http.Request)
+	DeleteTag(w http.ResponseWriter, r *http.Request)
+}
+
+type TagServicer interface {
+	ListTags(ctx context.Context) ([]models.TagUsage, error)
+	GetTagById(ctx context.Context, id int) (models.TagUsage, error)
+	CreateTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	RenameTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	MergeTags(ctx context.Context, sourceID int, targetID int) (models.TagUsage, error)
+	DeleteTag(ctx context.Context, id int) error
+}
+
+type TagController struct {
+	tagService TagServicer
+	responder  responder.Responder
+}
+
+func NewTagController(tagService TagServicer, responder responder.Responder) TagControllerer {
+	return &TagController{
+		tagService: tagService,
+		responder:  responder,
+	}
+}
+
+//	@id				1listTags
+//	@Security		ApiKeyAuth
+//	@Summary		Returns all tags
+//	@Description	petCount is the number of pets (except deleted ones) that use the tag
+//	@Tags			tag
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	[]models.TagUsage
+//	@Router			/tag [get]
+func (c *TagController) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := c.tagService.ListTags(r.Context())
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id			2getTagById
+//	@Security	ApiKeyAuth
+//	@Summary	Find tag by ID
+//	@Tags		tag
+//	@Accept		json
+//	@Produce	json
+//	@Param		tagId	path		int	true	"ID of tag to return"
+//	@Success	200		{object}	models.TagUsage
+//	@Failure	404		{object}	responder.Response
+//	@Router		/tag/{tagId} [get]
+func (c *TagController) GetTagById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "tagId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	tag, err := c.tagService.GetTagById(r.Context(), id)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(tag, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id			3createTag
+//	@Security	ApiKeyAuth
+//	@Summary	Create a new tag
+//	@Tags		tag
+//	@Accept		json
+//	@Produce	json
+//	@Param		object	body		models.Tag	true	"Tag to create, id is ignored"
+//	@Success	200		{object}	models.Tag
+//	@Failure	409		{object}	responder.Response
+//	@Router		/tag [post]
+func (c *TagController) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var tag models.Tag
+	err := json.NewDecoder(r.Body).Decode(&tag)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	createTag, err := c.tagService.CreateTag(r.Context(), tag)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(createTag, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				4renameTag
+//	@Security		ApiKeyAuth
+//	@Summary		Rename a tag
+//	@Description	Admin only
+//	@Tags			tag
+//	@Accept			json
+//	@Produce		json
+//	@Param			tagId	path		int			true	"ID of tag to rename"
+//	@Param			object	body		models.Tag	true	"Tag with the new name, id is taken from the path"
+//	@Success		200		{object}	models.Tag
+//	@Failure		403		{object}	responder.Response	"admin role required"
+//	@Failure		404		{object}	responder.Response
+//	@Failure		409		{object}	responder.Response
+//	@Router			/tag/{tagId} [put]
+func (c *TagController) RenameTag(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "tagId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	var tag models.Tag
+	err = json.NewDecoder(r.Body).Decode(&tag)
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+	tag.ID = id
+
+	renameTag, err := c.tagService.RenameTag(r.Context(), tag)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(renameTag, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				5mergeTags
+//	@Security		ApiKeyAuth
+//	@Summary		Merge one tag into another
+//	@Description	Admin only. All pets tagged with tagId get targetId instead, then tagId is deleted
+//	@Tags			tag
+//	@Accept			json
+//	@Produce		json
+//	@Param			tagId		path		int	true	"ID of tag to merge and delete"
+//	@Param			targetId	path		int	true	"ID of tag that remains"
+//	@Success		200			{object}	models.TagUsage
+//	@Failure		403			{object}	responder.Response	"admin role required"
+//	@Failure		404			{object}	responder.Response
+//	@Router			/tag/{tagId}/merge/{targetId} [post]
+func (c *TagController) MergeTags(w http.ResponseWriter, r *http.Request) {
+	sourceID, err := strconv.Atoi(chi.URLParam(r, "tagId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	targetID, err := strconv.Atoi(chi.URLParam(r, "targetId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	tag, err := c.tagService.MergeTags(r.Context(), sourceID, targetID)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	jsonResp, err := json.MarshalIndent(tag, "", "  ")
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, string(jsonResp))
+}
+
+//	@id				6deleteTag
+//	@Security		ApiKeyAuth
+//	@Summary		Deletes a tag
+//	@Description	Admin only. The tag is removed from all pets that use it
+//	@Tags			tag
+//	@Accept			json
+//	@Produce		json
+//	@Param			tagId	path		int	true	"ID of tag to delete"
+//	@Success		200		{object}	responder.Response
+//	@Failure		403		{object}	responder.Response	"admin role required"
+//	@Failure		404		{object}	responder.Response
+//	@Router			/tag/{tagId} [delete]
+func (c *TagController) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "tagId"))
+	if err != nil {
+		c.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = c.tagService.DeleteTag(r.Context(), id)
+	if err != nil {
+		c.respondError(w, err)
+		return
+	}
+
+	c.responder.Success(w, fmt.Sprint(id))
+}
+
+func (c *TagController) respondError(w http.ResponseWriter, err error) {
+	var conflictErr *models.ConflictError
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.responder.ErrorNotFound(w, errors.New("tag not found"))
+	case errors.As(err, &conflictErr):
+		c.responder.ErrorConflict(w, err)
+	default:
+		c.responder.ErrorBadRequest(w, err)
+	}
+}
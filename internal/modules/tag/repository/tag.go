@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"app/internal/infrastructure/db"
+	"app/internal/models"
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	tagsTable    = "tags"
+	tagPetsTable = "tag_pets"
+)
+
+type TagRepositoryer interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	ListTags(ctx context.Context) ([]models.TagUsage, error)
+	GetTagById(ctx context.Context, id int) (models.TagUsage, error)
+	GetTagByName(ctx context.Context, name string) (models.Tag, error)
+	CreateTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	UpdateTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	MergeTags(ctx context.Context, sourceID int, targetID int) (map[int][]models.Tag, error)
+	DeleteTag(ctx context.Context, id int) (map[int][]models.Tag, error)
+}
+
+type TagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) TagRepositoryer {
+	return &TagRepository{
+		db: db,
+	}
+}
+
+// InTx выполняет fn в транзакции: методы репозитория, вызванные с контекстом fn,
+// работают в ней, как и методы других репозиториев той же базы.
+func (r TagRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.InTx(ctx, r.db, fn)
+}
+
+// conn - транзакция InTx из ctx или, вне ее, сама база.
+func (r TagRepository) conn(ctx context.Context) db.Conn {
+	return db.ConnFrom(ctx, r.db)
+}
+
+// selectTagUsage - теги с количеством питомцев, удаленные питомцы не считаются.
+func selectTagUsage() sq.SelectBuilder {
+	return sq.Select(
+		"tags.id",
+		"tags.name",
+		"COUNT(DISTINCT pets.id)",
+	).
+		From(tagsTable).
+		LeftJoin("tag_pets ON tag_pets.tag_id = tags.id").
+		LeftJoin("pets ON pets.id = tag_pets.pet_id AND pets.status <> 'deleted'").
+		GroupBy("tags.id", "tags.name")
+}
+
+func (r TagRepository) ListTags(ctx context.Context) ([]models.TagUsage, error) {
+	rows, err := selectTagUsage().
+		OrderBy("tags.id").
+		RunWith(r.conn(ctx)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.TagUsage{}
+	for rows.Next() {
+		var tag models.TagUsage
+		if err = rows.Scan(&tag.ID, &tag.Name, &tag.PetCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r TagRepository) GetTagById(ctx context.Context, id int) (models.TagUsage, error) {
+	var tag models.TagUsage
+
+	err := selectTagUsage().
+		Where(sq.Eq{"tags.id": id}).
+		RunWith(r.conn(ctx)).
+		QueryRowContext(ctx).
+		Scan(&tag.ID, &tag.Name, &tag.PetCount)
+	if err != nil {
+		return models.TagUsage{}, err
+	}
+
+	return tag, nil
+}
+
+func (r TagRepository) GetTagByName(ctx context.Context, name string) (models.Tag, error) {
+	var tag models.Tag
+
+	err := sq.Select("id", "name").
+		From(tagsTable).
+		Where(sq.Eq{"name": name}).
+		Limit(1).
+		RunWith(r.conn(ctx)).
+		QueryRowContext(ctx).
+		Scan(&tag.ID, &tag.Name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+func (r TagRepository) CreateTag(ctx context.Context, tag models.Tag) (models.Tag, error) {
+	res, err := sq.Insert(tagsTable).
+		Columns("name").
+		Values(tag.Name).
+		RunWith(r.conn(ctx)).
+		ExecContext(ctx)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	tag.ID = int(id)
+
+	return tag, nil
+}
+
+// UpdateTag переименовывает тег. Питомцы отдают теги с именами, поэтому их версия
+// тоже увеличивается.
+func (r TagRepository) UpdateTag(ctx context.Context, tag models.Tag) (models.Tag, error) {
+	tx, err := db.BeginTx(ctx, r.db)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	defer tx.Rollback()
+
+	err = bumpPetVersions(ctx, tx, tag.ID)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	_, err = sq.Update(tagsTable).
+		Set("name", tag.Name).
+		Where(sq.Eq{"id": tag.ID}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	return tag, tx.Commit()
+}
+
+// MergeTags переносит питомцев с тега sourceID на targetID и удаляет sourceID.
+// Возвращает теги затронутых питомцев до слияния.
+func (r TagRepository) MergeTags(ctx context.Context, sourceID int, targetID int) (map[int][]models.Tag, error) {
+	tx, err := db.BeginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := petTags(ctx, tx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bumpPetVersions(ctx, tx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// переносим питомцев на целевой тег, пропуская тех, у кого он уже есть
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tag_pets (pet_id, tag_id)
+		SELECT DISTINCT pet_id, ? FROM tag_pets
+		WHERE tag_id = ? AND pet_id NOT IN (SELECT pet_id FROM tag_pets WHERE tag_id = ?)`,
+		targetID, sourceID, targetID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = sq.Delete(tagPetsTable).
+		Where(sq.Eq{"tag_id": sourceID}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = sq.Delete(tagsTable).
+		Where(sq.Eq{"id": sourceID}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return before, tx.Commit()
+}
+
+// DeleteTag снимает тег с питомцев и удаляет его. Возвращает теги затронутых
+// питомцев до удаления.
+func (r TagRepository) DeleteTag(ctx context.Context, id int) (map[int][]models.Tag, error) {
+	tx, err := db.BeginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := petTags(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bumpPetVersions(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// сначала снимаем тег с питомцев
+	_, err = sq.Delete(tagPetsTable).
+		Where(sq.Eq{"tag_id": id}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = sq.Delete(tagsTable).
+		Where(sq.Eq{"id": id}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return before, tx.Commit()
+}
+
+// bumpPetVersions увеличивает версию питомцев с тегом: набор их тегов меняется.
+func bumpPetVersions(ctx context.Context, tx *db.Tx, tagID int) error {
+	_, err := sq.Update("pets").
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Expr("id IN (SELECT pet_id FROM tag_pets WHERE tag_id = ?)", tagID)).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	return err
+}
+
+// petTags возвращает все теги питомцев, у которых есть тег tagID.
+func petTags(ctx context.Context, tx *db.Tx, tagID int) (map[int][]models.Tag, error) {
+	rows, err := sq.Select("tag_pets.pet_id", "tags.id", "tags.name").
+		From(tagPetsTable).
+		Join("tags ON tags.id = tag_pets.tag_id").
+		Where(sq.Expr("tag_pets.pet_id IN (SELECT pet_id FROM tag_pets WHERE tag_id = ?)", tagID)).
+		OrderBy("tag_pets.pet_id", "tags.id").
+		RunWith(tx).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[int][]models.Tag)
+	for rows.Next() {
+		var petID int
+		var tag models.Tag
+		if err = rows.Scan(&petID, &tag.ID, &tag.Name); err != nil {
+			return nil, err
+		}
+		tags[petID] = append(tags[petID], tag)
+	}
+
+	return tags, rows.Err()
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"app/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type TagServicer interface {
+	ListTags(ctx context.Context) ([]models.TagUsage, error)
+	GetTagById(ctx context.Context, id int) (models.TagUsage, error)
+	CreateTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	RenameTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	MergeTags(ctx context.Context, sourceID int, targetID int) (models.TagUsage, error)
+	DeleteTag(ctx context.Context, id int) error
+}
+
+type TagRepositoryer interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	ListTags(ctx context.Context) ([]models.TagUsage, error)
+	GetTagById(ctx context.Context, id int) (models.TagUsage, error)
+	GetTagByName(ctx context.Context, name string) (models.Tag, error)
+	CreateTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	UpdateTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	MergeTags(ctx context.Context, sourceID int, targetID int) (map[int][]models.Tag, error)
+	DeleteTag(ctx context.Context, id int) (map[int][]models.Tag, error)
+}
+
+// PetServicer - то, что тегам нужно от питомцев: слияние и удаление тега меняют
+// теги питомцев, и это записывается в их историю в той же транзакции.
+type PetServicer interface {
+	RecordPetTagsChange(ctx context.Context, petID int, action string, before []models.Tag, after []models.Tag) error
+}
+
+type TagService struct {
+	tagRepository TagRepositoryer
+	petService    PetServicer
+}
+
+func NewTagService(tagRepository TagRepositoryer, petService PetServicer) TagServicer {
+	return &TagService{
+		tagRepository: tagRepository,
+		petService:    petService,
+	}
+}
+
+func (s *TagService) ListTags(ctx context.Context) ([]models.TagUsage, error) {
+	return s.tagRepository.ListTags(ctx)
+}
+
+func (s *TagService) GetTagById(ctx context.Context, id int) (models.TagUsage, error) {
+	return s.tagRepository.GetTagById(ctx, id)
+}
+
+func (s *TagService) CreateTag(ctx context.Context, tag models.Tag) (models.Tag, error) {
+	tag.Name = strings.TrimSpace(tag.Name)
+
+	err := s.checkName(ctx, tag)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	return s.tagRepository.CreateTag(ctx, tag)
+}
+
+func (s *TagService) RenameTag(ctx context.Context, tag models.Tag) (models.Tag, error) {
+	_, err := s.GetTagById(ctx, tag.ID)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	tag.Name = strings.TrimSpace(tag.Name)
+
+	err = s.checkName(ctx, tag)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	return s.tagRepository.UpdateTag(ctx, tag)
+}
+
+// MergeTags переносит всех питомцев с тега sourceID на targetID и удаляет sourceID.
+func (s *TagService) MergeTags(ctx context.Context, sourceID int, targetID int) (models.TagUsage, error) {
+	if sourceID == targetID {
+		return models.TagUsage{}, errors.New("can not merge a tag into itself")
+	}
+
+	_, err := s.GetTagById(ctx, sourceID)
+	if err != nil {
+		return models.TagUsage{}, err
+	}
+
+	target, err := s.GetTagById(ctx, targetID)
+	if err != nil {
+		return models.TagUsage{}, err
+	}
+
+	err = s.tagRepository.InTx(ctx, func(ctx context.Context) error {
+		before, err := s.tagRepository.MergeTags(ctx, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+
+		return s.recordPetTags(ctx, models.PetActionMergeTag, before, sourceID, &models.Tag{ID: target.ID, Name: target.Name})
+	})
+	if err != nil {
+		return models.TagUsage{}, err
+	}
+
+	return s.GetTagById(ctx, targetID)
+}
+
+func (s *TagService) DeleteTag(ctx context.Context, id int) error {
+	_, err := s.GetTagById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.tagRepository.InTx(ctx, func(ctx context.Context) error {
+		before, err := s.tagRepository.DeleteTag(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.recordPetTags(ctx, models.PetActionDeleteTag, before, id, nil)
+	})
+}
+
+// recordPetTags записывает в историю питомцев, что тег removedID у них заменен
+// на replacement (при слиянии) или снят (replacement == nil). before - теги
+// питомцев до изменения.
+func (s *TagService) recordPetTags(ctx context.Context, action string, before map[int][]models.Tag, removedID int, replacement *models.Tag) error {
+	petIDs := make([]int, 0, len(before))
+	for petID := range before {
+		petIDs = append(petIDs, petID)
+	}
+	sort.Ints(petIDs)
+
+	for _, petID := range petIDs {
+		after := make([]models.Tag, 0, len(before[petID]))
+		for _, tag := range before[petID] {
+			if tag.ID != removedID {
+				after = append(after, tag)
+			}
+		}
+
+		if replacement != nil && !hasTag(after, replacement.ID) {
+			after = append(after, *replacement)
+		}
+
+		err := s.petService.RecordPetTagsChange(ctx, petID, action, before[petID], after)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasTag(tags []models.Tag, id int) bool {
+	for _, tag := range tags {
+		if tag.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkName проверяет, что имя задано и не занято другим тегом.
+func (s *TagService) checkName(ctx context.Context, tag models.Tag) error {
+	if tag.Name == "" {
+		return errors.New("tag name is required")
+	}
+
+	existing, err := s.tagRepository.GetTagByName(ctx, tag.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if existing.ID != tag.ID {
+		return models.NewConflictError(fmt.Sprintf("tag %q already exists", tag.Name))
+	}
+
+	return nil
+}
@@ -43,6 +43,7 @@ func (r *userRepository) GetUserByName(ctx context.Context, userName string) (mo
 		Password   sql.NullString
 		Phone      sql.NullString
 		UserStatus sql.NullInt64
+		Role       sql.NullString
 	}
 
 	var u userRow
@@ -56,6 +57,7 @@ func (r *userRepository) GetUserByName(ctx context.Context, userName string) (mo
 		"password",
 		"phone",
 		"user_status",
+		"role",
 	).
 		From(usersTable).
 		/* Where(sq.And{
@@ -74,6 +76,7 @@ func (r *userRepository) GetUserByName(ctx context.Context, userName string) (mo
 			&u.Password,
 			&u.Phone,
 			&u.UserStatus,
+			&u.Role,
 		)
 	if err != nil {
 		return models.User{}, err
@@ -87,6 +90,7 @@ func (r *userRepository) GetUserByName(ctx context.Context, userName string) (mo
 	user.Password = u.Password.String
 	user.Phone = u.Phone.String
 	user.UserStatus = int(u.UserStatus.Int64)
+	user.Role = u.Role.String
 
 	return user, nil
 }
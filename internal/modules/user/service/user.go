@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"errors"
 	"os"
+	"strconv"
 
 	"github.com/go-chi/jwtauth"
 )
@@ -98,7 +99,7 @@ func (s *UserService) LoginUser(ctx context.Context, userName string, password s
 
 	tokenAuth := jwtauth.New("HS256", []byte(signKey), nil)
 
-	_, tokenString, err := tokenAuth.Encode(map[string]interface{}{"username": userName, "password": password})
+	_, tokenString, err := tokenAuth.Encode(map[string]interface{}{"sub": strconv.Itoa(user.ID), "username": userName, "password": password, "role": user.Role})
 	if err != nil {
 		return "", err
 	}
@@ -17,6 +17,12 @@ package main
 //	@tag.description	Access to Petstore orders
 //	@tag.name			user	
 //	@tag.description	Operations about users
+//	@tag.name			category
+//	@tag.description	Pet categories
+//	@tag.name			tag
+//	@tag.description	Pet tags vocabulary
+//	@tag.name			adoption
+//	@tag.description	Adoption applications for pets
 
 // main runs the server on the given address.
 func main() {
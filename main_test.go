@@ -1,17 +1,54 @@
 package main
 
 import (
+	"app/internal/infrastructure/db"
+	"app/internal/infrastructure/idempotency"
+	"app/internal/infrastructure/imaging"
+	"app/internal/infrastructure/jsonpatch"
+	customMiddleware "app/internal/infrastructure/middleware"
+	"app/internal/infrastructure/notify"
 	"app/internal/infrastructure/responder"
+	"app/internal/infrastructure/storage"
 	"app/internal/models"
+	"app/internal/modules"
+	adoptionRepository "app/internal/modules/adoption/repository"
+	adoptionService "app/internal/modules/adoption/service"
+	categoryRepository "app/internal/modules/category/repository"
+	petController "app/internal/modules/pet/controller"
+	petRepository "app/internal/modules/pet/repository"
+	petService "app/internal/modules/pet/service"
+	storeRepository "app/internal/modules/store/repository"
+	storeService "app/internal/modules/store/service"
 	"app/internal/modules/user/controller"
+	userService "app/internal/modules/user/service"
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/jwtauth"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -182,4 +219,3256 @@ func TestUser2(t *testing.T) {
 			//assert.Equal(t, tt.wantBody, w.Body.String())
 		})
 	}
-}
\ No newline at end of file
+}
+
+// benchPhotos и benchTags - сколько фото и тегов у каждого питомца в бенчмарках чтения.
+// При соединении питомцев с фото и тегами это давало бы 25 строк на питомца.
+const (
+	benchPhotos = 5
+	benchTags   = 5
+)
+
+// newPetBenchRepository создает базу с pets питомцами, у каждого benchPhotos фото и benchTags тегов.
+func newPetBenchRepository(b *testing.B, pets int) petRepository.PetRepositoryer {
+	b.Helper()
+
+	bd, err := db.NewDataBaseSqlite(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { bd.DB.Close() })
+
+	if err = bd.Migrate(); err != nil {
+		b.Fatal(err)
+	}
+
+	tx, err := bd.DB.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	tagIDs := make([]int64, benchTags)
+	for i := range tagIDs {
+		res, err := tx.Exec("INSERT INTO tags (name) VALUES (?)", fmt.Sprintf("bench-tag-%d", i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if tagIDs[i], err = res.LastInsertId(); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for i := 0; i < pets; i++ {
+		res, err := tx.Exec("INSERT INTO pets (category_id, name, status) VALUES (1, ?, 'available')", fmt.Sprintf("bench-%d", i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		petID, err := res.LastInsertId()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for j := 0; j < benchPhotos; j++ {
+			_, err = tx.Exec("INSERT INTO pet_photos (pet_id, photo_url) VALUES (?, ?)", petID, fmt.Sprintf("https://example.com/%d/%d.jpg", petID, j))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		for _, tagID := range tagIDs {
+			_, err = tx.Exec("INSERT INTO tag_pets (tag_id, pet_id) VALUES (?, ?)", tagID, petID)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+
+	return petRepository.NewPetRepository(bd.DB)
+}
+
+func BenchmarkFindPetsByStatus(b *testing.B) {
+	for _, pets := range []int{1000, 10000, 30000} {
+		b.Run(fmt.Sprintf("pets=%d", pets), func(b *testing.B) {
+			repo := newPetBenchRepository(b, pets)
+			ctx := context.Background()
+
+			b.ResetTimer()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				result, err := repo.FindPetsByStatus(ctx, []string{models.PetStatusAvailable})
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				// кроме питомцев из начальных данных
+				last := result[len(result)-1]
+				if len(result) < pets || len(last.PhotoUrls) != benchPhotos || len(last.Tags) != benchTags {
+					b.Fatalf("got %d pets, last has %d photos and %d tags", len(result), len(last.PhotoUrls), len(last.Tags))
+				}
+			}
+
+			// при линейном чтении время на питомца не зависит от размера каталога
+			b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*pets), "ns/pet")
+		})
+	}
+}
+
+func BenchmarkGetPetById(b *testing.B) {
+	repo := newPetBenchRepository(b, 10000)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pet, err := repo.GetPetById(ctx, 1000+i%5000)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if len(pet.PhotoUrls) != benchPhotos || len(pet.Tags) != benchTags {
+			b.Fatalf("pet %d has %d photos and %d tags", pet.ID, len(pet.PhotoUrls), len(pet.Tags))
+		}
+	}
+}
+
+type mockPetStatusService struct {
+	mu         sync.Mutex
+	changes    []models.Pet
+	getPetById func(ctx context.Context, id int) (models.Pet, error)
+	recordErr  error
+}
+
+func (m *mockPetStatusService) GetPetById(ctx context.Context, id int) (models.Pet, error) {
+	return m.getPetById(ctx, id)
+}
+
+func (m *mockPetStatusService) RecordPetStatusChange(ctx context.Context, before models.Pet, after models.Pet) error {
+	return m.recordErr
+}
+
+func (m *mockPetStatusService) PetStatusChanged(ctx context.Context, before models.Pet, after models.Pet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.changes = append(m.changes, after)
+}
+
+// TestPlaceOrderConcurrent: одновременные заказы одного питомца на настоящей SQLite -
+// ровно один заказ проходит и бронирует питомца, остальные получают конфликт, а не
+// "database is locked". Гонка повторяется на нескольких питомцах, чтобы она точно случилась.
+func TestPlaceOrderConcurrent(t *testing.T) {
+	const (
+		rounds  = 20
+		clients = 50
+	)
+
+	bd := newTestDataBase(t)
+
+	petService := &mockPetStatusService{}
+	store := storeService.NewStoreService(storeRepository.NewStoreRepository(bd.DB), petService)
+
+	// заказ оформляет пользователь из токена
+	ctx := userContext(t, 1)
+
+	for round := 0; round < rounds; round++ {
+		res, err := bd.DB.Exec("INSERT INTO pets (category_id, name, status) VALUES (1, ?, 'available')", fmt.Sprintf("race-%d", round))
+		if err != nil {
+			t.Fatal(err)
+		}
+		petID, err := res.LastInsertId()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		order := models.Order{PetID: int(petID), Quantity: 1, ShipDate: "2024-01-01"}
+
+		start := make(chan struct{})
+		errs := make([]error, clients)
+
+		var wg sync.WaitGroup
+		for i := 0; i < clients; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+
+				_, errs[i] = store.PlaceOrder(ctx, order)
+			}(i)
+		}
+
+		close(start)
+		wg.Wait()
+
+		placed := 0
+		for _, err := range errs {
+			if err == nil {
+				placed++
+				continue
+			}
+
+			var conflictErr *models.ConflictError
+			assert.True(t, errors.As(err, &conflictErr), "unexpected error: %v", err)
+		}
+
+		var status string
+		var orders int
+		err = bd.DB.QueryRow("SELECT status FROM pets WHERE id = ?", petID).Scan(&status)
+		assert.NoError(t, err)
+		err = bd.DB.QueryRow("SELECT COUNT(*) FROM orders WHERE pet_id = ?", petID).Scan(&orders)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, placed)
+		assert.Equal(t, 1, orders)
+		assert.Equal(t, models.PetStatusPending, status)
+
+		// забронированного питомца заказать больше нельзя
+		_, err = store.PlaceOrder(ctx, order)
+		var conflictErr *models.ConflictError
+		assert.True(t, errors.As(err, &conflictErr))
+	}
+
+	assert.Len(t, petService.changes, rounds)
+}
+
+// TestOrderReleasesOnlyOwnReservation: отмена или доставка старого заказа на питомца,
+// которого забронировал другой заказ, не снимает чужую бронь.
+func TestOrderReleasesOnlyOwnReservation(t *testing.T) {
+	bd := newTestDataBase(t)
+
+	store := storeService.NewStoreService(storeRepository.NewStoreRepository(bd.DB), &mockPetStatusService{})
+	admin := adminContext(t, 1)
+
+	petStatus := func(id int) string {
+		var status string
+		assert.NoError(t, bd.DB.QueryRow("SELECT status FROM pets WHERE id = ?", id).Scan(&status))
+		return status
+	}
+
+	// в сиде у питомца 2 в продаже много незавершенных заказов, оформленных до броней
+	order, err := store.PlaceOrder(userContext(t, 1), models.Order{PetID: 2, Quantity: 1, ShipDate: "2024-01-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.PetStatusPending, petStatus(2))
+
+	_, err = store.CancelOrder(admin, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PetStatusPending, petStatus(2))
+
+	_, err = store.ShipOrder(admin, 6)
+	assert.NoError(t, err)
+	_, err = store.DeliverOrder(admin, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PetStatusPending, petStatus(2))
+
+	_, err = store.CancelOrder(admin, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PetStatusAvailable, petStatus(2))
+}
+
+// TestCheckout: корзина оформляется в один заказ с позициями и суммой, питомцы бронируются,
+// аксессуары списываются со склада; при нехватке товара не меняется ничего, а отмена
+// заказа возвращает питомцев в продажу и аксессуары на склад.
+func TestCheckout(t *testing.T) {
+	bd := newTestDataBase(t)
+
+	pets := petRepository.NewPetRepository(bd.DB)
+	petService := &mockPetStatusService{getPetById: pets.GetPetById}
+	store := storeService.NewStoreService(storeRepository.NewStoreRepository(bd.DB), petService)
+	ctx := userContext(t, 1)
+
+	var petIDs []int
+	for _, name := range []string{"Rex", "Tom"} {
+		res, err := bd.DB.Exec("INSERT INTO pets (category_id, name, status, price) VALUES (1, ?, 'available', 10000)", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := res.LastInsertId()
+		petIDs = append(petIDs, int(id))
+	}
+
+	stock := func() int {
+		var stock int
+		assert.NoError(t, bd.DB.QueryRow("SELECT stock FROM accessories WHERE id = 4").Scan(&stock))
+		return stock
+	}
+	petStatus := func(id int) string {
+		var status string
+		assert.NoError(t, bd.DB.QueryRow("SELECT status FROM pets WHERE id = ?", id).Scan(&status))
+		return status
+	}
+
+	for _, id := range petIDs {
+		_, err := store.AddCartItem(ctx, models.CartItem{PetID: id})
+		assert.NoError(t, err)
+	}
+	_, err := store.AddCartItem(ctx, models.CartItem{AccessoryID: 4, Quantity: 2})
+	assert.NoError(t, err)
+	cart, err := store.AddCartItem(ctx, models.CartItem{AccessoryID: 4})
+	assert.NoError(t, err)
+
+	// питомец кладется один раз, количество аксессуара складывается
+	_, err = store.AddCartItem(ctx, models.CartItem{PetID: petIDs[0]})
+	var conflictErr *models.ConflictError
+	assert.True(t, errors.As(err, &conflictErr))
+	assert.Len(t, cart.Items, 3)
+	assert.Equal(t, int64(2*10000+3*4500), cart.Total)
+
+	// количество аксессуара в корзине не превышает предел и при добавлении к уже лежащему
+	_, err = store.AddCartItem(ctx, models.CartItem{AccessoryID: 4, Quantity: 97})
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &conflictErr))
+
+	_, err = store.Checkout(ctx, models.CartCheckout{ShipDate: "tomorrow"})
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &conflictErr))
+	assert.Equal(t, models.PetStatusAvailable, petStatus(petIDs[0]))
+
+	_, err = store.PlaceOrder(ctx, models.Order{PetID: petIDs[1], Quantity: 1, ShipDate: "tomorrow"})
+	assert.Error(t, err)
+	assert.Equal(t, models.PetStatusAvailable, petStatus(petIDs[1]))
+
+	// питомец в заказе один
+	for _, quantity := range []int{2, -1} {
+		_, err = store.PlaceOrder(ctx, models.Order{PetID: petIDs[1], Quantity: quantity, ShipDate: "2024-01-01"})
+		assert.Error(t, err)
+		assert.False(t, errors.As(err, &conflictErr))
+	}
+	assert.Equal(t, models.PetStatusAvailable, petStatus(petIDs[1]))
+
+	// аксессуаров на складе меньше, чем в корзине: заказ не создается, питомцы не бронируются
+	_, err = bd.DB.Exec("UPDATE accessories SET stock = 2 WHERE id = 4")
+	assert.NoError(t, err)
+	_, err = store.Checkout(ctx, models.CartCheckout{ShipDate: "2024-01-01"})
+	assert.True(t, errors.As(err, &conflictErr))
+	assert.Equal(t, models.PetStatusAvailable, petStatus(petIDs[0]))
+	assert.Equal(t, 2, stock())
+
+	_, err = bd.DB.Exec("UPDATE accessories SET stock = 10 WHERE id = 4")
+	assert.NoError(t, err)
+	order, err := store.Checkout(ctx, models.CartCheckout{ShipDate: "2024-01-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPlaced, order.Status)
+	assert.Equal(t, 1, order.UserID)
+	assert.Equal(t, 5, order.Quantity)
+	assert.Equal(t, cart.Total, order.Total)
+	assert.Len(t, order.Items, 3)
+	assert.Equal(t, models.PetStatusPending, petStatus(petIDs[0]))
+	assert.Equal(t, models.PetStatusPending, petStatus(petIDs[1]))
+	assert.Equal(t, 7, stock())
+	assert.Len(t, petService.changes, 2)
+
+	cart, err = store.GetCart(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, cart.Items)
+
+	saved, err := store.GetOrderById(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, order.Items, saved.Items)
+
+	_, err = store.CancelOrder(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PetStatusAvailable, petStatus(petIDs[0]))
+	assert.Equal(t, models.PetStatusAvailable, petStatus(petIDs[1]))
+	assert.Equal(t, 10, stock())
+}
+
+// TestPurgePet: питомца, который был в заказе из корзины, удалить навсегда нельзя,
+// а питомец из чужой корзины удаляется вместе с позицией корзины.
+func TestPurgePet(t *testing.T) {
+	bd := newTestDataBase(t)
+	pets := petRepository.NewPetRepository(bd.DB)
+	service := newTestPetService(t, pets)
+	store := storeService.NewStoreService(storeRepository.NewStoreRepository(bd.DB), &mockPetStatusService{getPetById: pets.GetPetById})
+	ctx := context.Background()
+
+	var petIDs []int
+	for _, name := range []string{"Rex", "Tom"} {
+		res, err := bd.DB.Exec("INSERT INTO pets (category_id, name, status, price) VALUES (1, ?, 'available', 10000)", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := res.LastInsertId()
+		petIDs = append(petIDs, int(id))
+	}
+	ordered, inCart := petIDs[0], petIDs[1]
+
+	// заказ отменен, но питомец остался в его позициях
+	_, err := store.AddCartItem(userContext(t, 1), models.CartItem{PetID: ordered})
+	assert.NoError(t, err)
+	order, err := store.Checkout(userContext(t, 1), models.CartCheckout{ShipDate: "2024-01-01"})
+	assert.NoError(t, err)
+	_, err = store.CancelOrder(userContext(t, 1), order.ID)
+	assert.NoError(t, err)
+
+	_, err = store.AddCartItem(userContext(t, 2), models.CartItem{PetID: inCart})
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.DeletePet(ctx, ordered))
+	err = service.PurgePet(ctx, ordered)
+	var conflictErr *models.ConflictError
+	assert.True(t, errors.As(err, &conflictErr), "unexpected error: %v", err)
+
+	assert.NoError(t, service.DeletePet(ctx, inCart))
+	assert.NoError(t, service.PurgePet(ctx, inCart))
+
+	cart, err := store.GetCart(userContext(t, 2))
+	assert.NoError(t, err)
+	assert.Empty(t, cart.Items)
+}
+
+// userContext возвращает контекст запроса с JWT пользователя userID, как после jwtauth.Verifier.
+func userContext(t *testing.T, userID int) context.Context {
+	return jwtContext(t, map[string]interface{}{"sub": fmt.Sprint(userID)})
+}
+
+// adminContext - то же для администратора.
+func adminContext(t *testing.T, userID int) context.Context {
+	return jwtContext(t, map[string]interface{}{"sub": fmt.Sprint(userID), "role": models.UserRoleAdmin})
+}
+
+func jwtContext(t *testing.T, claims map[string]interface{}) context.Context {
+	t.Helper()
+
+	token, _, err := jwtauth.New("HS256", []byte("test"), nil).Encode(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return jwtauth.NewContext(context.Background(), token, nil)
+}
+
+// TestIdempotency: повтор с тем же Idempotency-Key получает сохраненный ответ и не выполняет
+// обработчик снова, тот же ключ с другим телом отклоняется, после ответа не 2xx ключ освобождается.
+func TestIdempotency(t *testing.T) {
+	bd := newTestDataBase(t)
+
+	calls := 0
+	status := http.StatusOK
+	handler := customMiddleware.Idempotency(idempotency.NewSQLStore(bd.DB, time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"id":%d}`, calls)
+	}))
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v2/store/order", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(customMiddleware.IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	first := post("order-1", `{"petId":1}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, `{"id":1}`, first.Body.String())
+
+	replayed := post("order-1", `{"petId":1}`)
+	assert.Equal(t, http.StatusOK, replayed.Code)
+	assert.Equal(t, `{"id":1}`, replayed.Body.String())
+	assert.Equal(t, "application/json", replayed.Header().Get("Content-Type"))
+	assert.Equal(t, "true", replayed.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, post("order-1", `{"petId":2}`).Code)
+	assert.Equal(t, 1, calls)
+
+	// без ключа запрос выполняется каждый раз
+	post("", `{"petId":1}`)
+	post("", `{"petId":1}`)
+	assert.Equal(t, 3, calls)
+
+	// ошибка не сохраняется: повтор выполняет запрос снова
+	status = http.StatusBadRequest
+	assert.Equal(t, http.StatusBadRequest, post("order-2", `{"petId":1}`).Code)
+	status = http.StatusOK
+	assert.Equal(t, http.StatusOK, post("order-2", `{"petId":1}`).Code)
+	assert.Equal(t, 5, calls)
+
+	// сохраняются только 2xx: перенаправление тоже не повторяется из хранилища
+	status = http.StatusSeeOther
+	assert.Equal(t, http.StatusSeeOther, post("order-3", `{"petId":1}`).Code)
+	assert.Equal(t, http.StatusSeeOther, post("order-3", `{"petId":1}`).Code)
+	assert.Equal(t, 7, calls)
+}
+
+// TestCreateUserIdempotencyKey: POST /v2/user выполняется без JWT, ключи анонимных
+// клиентов были бы общими, поэтому Idempotency-Key к нему не применяется.
+func TestCreateUserIdempotencyKey(t *testing.T) {
+	app := newTestApp(t)
+
+	for _, name := range []string{"anna", "bob"} {
+		req := httptest.NewRequest(http.MethodPost, "/v2/user", strings.NewReader(fmt.Sprintf(`{"username":%q,"password":"secret"}`, name)))
+		req.Header.Set(customMiddleware.IdempotencyKeyHeader, "signup")
+		w := app.do(t, req, "")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	}
+
+	var users int
+	assert.NoError(t, app.db.DB.QueryRow("SELECT COUNT(*) FROM users WHERE username IN ('anna', 'bob')").Scan(&users))
+	assert.Equal(t, 2, users)
+}
+
+// newTestDataBase создает базу SQLite с миграциями и сидом во временном каталоге теста.
+func newTestDataBase(t *testing.T) *db.DataBaseSqlite {
+	t.Helper()
+
+	bd, err := db.NewDataBaseSqlite(filepath.Join(t.TempDir(), "petstore.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bd.DB.Close() })
+
+	if err = bd.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bd
+}
+
+type mockNotifier struct {
+	mu       sync.Mutex
+	messages []notify.Message
+}
+
+func (m *mockNotifier) Notify(msg notify.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+}
+
+// newTestPetService создает PetService поверх repo с файлами во временном каталоге.
+func newTestPetService(t *testing.T, repo petService.PetRepositoryer) petService.PetServicer {
+	t.Helper()
+
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return petService.NewPetService(repo, blobStore, []int{64}, &mockNotifier{})
+}
+
+// TestPetStatusTransitions: смена статуса через форму проверяет жизненный цикл питомца.
+func TestPetStatusTransitions(t *testing.T) {
+	bd := newTestDataBase(t)
+	repo := petRepository.NewPetRepository(bd.DB)
+	service := newTestPetService(t, repo)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		from       string
+		to         string
+		transition bool
+		invalid    bool
+	}{
+		{name: "reserve", from: models.PetStatusAvailable, to: models.PetStatusPending},
+		{name: "sell reserved", from: models.PetStatusPending, to: models.PetStatusSold},
+		{name: "cancel reservation", from: models.PetStatusPending, to: models.PetStatusAvailable},
+		{name: "delete sold", from: models.PetStatusSold, to: models.PetStatusDeleted},
+		{name: "same status", from: models.PetStatusSold, to: models.PetStatusSold},
+		{name: "legacy status", from: "on hold", to: models.PetStatusSold},
+		{name: "sell without reservation", from: models.PetStatusAvailable, to: models.PetStatusSold, transition: true},
+		{name: "return sold", from: models.PetStatusSold, to: models.PetStatusAvailable, transition: true},
+		{name: "reserve sold", from: models.PetStatusSold, to: models.PetStatusPending, transition: true},
+		{name: "unknown status", from: models.PetStatusAvailable, to: "lost", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pet, err := repo.AddPet(ctx, models.Pet{Name: tt.name, Category: models.Category{ID: 1}, Status: tt.from})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = service.UpdatePetWithForm(ctx, pet.ID, "", tt.to, 0)
+
+			var transitionErr *models.StatusTransitionError
+
+			switch {
+			case tt.transition:
+				assert.True(t, errors.As(err, &transitionErr), "unexpected error: %v", err)
+			case tt.invalid:
+				assert.Error(t, err)
+				assert.False(t, errors.As(err, &transitionErr), "unknown status must not be a transition error")
+			default:
+				assert.NoError(t, err)
+			}
+
+			current, err := repo.GetPetById(ctx, pet.ID)
+			assert.NoError(t, err)
+
+			want := tt.to
+			if tt.transition || tt.invalid {
+				want = tt.from
+			}
+			assert.Equal(t, want, current.Status)
+		})
+	}
+}
+
+// staleReadPetRepository отдает сервису питомца в состоянии stale, как будто его
+// изменили между чтением и записью запроса.
+type staleReadPetRepository struct {
+	petRepository.PetRepositoryer
+	stale models.Pet
+}
+
+func (r staleReadPetRepository) GetPetById(ctx context.Context, id int) (models.Pet, error) {
+	return r.stale, nil
+}
+
+// TestPetUpdateDoesNotOverwriteReservation: изменение питомца, прочитанного до брони
+// заказа, не возвращает его в продажу. Без If-Match это 409, с устаревшим If-Match - 412.
+func TestPetUpdateDoesNotOverwriteReservation(t *testing.T) {
+	bd := newTestDataBase(t)
+	repo := petRepository.NewPetRepository(bd.DB)
+	ctx := context.Background()
+
+	added, err := repo.AddPet(ctx, models.Pet{Name: "Rex", Category: models.Category{ID: 1}, Status: models.PetStatusAvailable})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pet, err := repo.GetPetById(ctx, added.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := storeService.NewStoreService(storeRepository.NewStoreRepository(bd.DB), &mockPetStatusService{})
+	_, err = store.PlaceOrder(userContext(t, 1), models.Order{PetID: pet.ID, Quantity: 1, ShipDate: "2024-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// сервис видит питомца таким, каким он был до брони
+	service := newTestPetService(t, staleReadPetRepository{PetRepositoryer: repo, stale: pet})
+
+	var conflictErr *models.ConflictError
+
+	err = service.UpdatePetWithForm(ctx, pet.ID, "", models.PetStatusAvailable, 0)
+	assert.True(t, errors.As(err, &conflictErr), "unexpected error: %v", err)
+
+	updated := pet
+	updated.Version = 0
+	_, err = service.UpdatePet(ctx, updated)
+	assert.True(t, errors.As(err, &conflictErr), "unexpected error: %v", err)
+
+	err = service.UpdatePetWithForm(ctx, pet.ID, "", models.PetStatusAvailable, pet.Version)
+	assert.ErrorIs(t, err, models.ErrVersionMismatch)
+
+	err = service.DeletePet(ctx, pet.ID)
+	assert.True(t, errors.As(err, &conflictErr), "unexpected error: %v", err)
+
+	current, err := repo.GetPetById(ctx, pet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PetStatusPending, current.Status)
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "~1 in pointer is /",
+			doc:   `{"a/b":1}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":2}]`,
+			want:  `{"a/b":2}`,
+		},
+		{
+			name:  "~0 in pointer is ~",
+			doc:   `{"m~n":1,"m":2}`,
+			patch: `[{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"m":2}`,
+		},
+		{
+			name:  "~01 is ~1, not /",
+			doc:   `{"~1":1,"/":2}`,
+			patch: `[{"op":"remove","path":"/~01"}]`,
+			want:  `{"/":2}`,
+		},
+		{
+			name:  "- appends to array",
+			doc:   `{"tags":[1,2]}`,
+			patch: `[{"op":"add","path":"/tags/-","value":3}]`,
+			want:  `{"tags":[1,2,3]}`,
+		},
+		{
+			name:  "add inserts before index",
+			doc:   `{"tags":[1,2]}`,
+			patch: `[{"op":"add","path":"/tags/0","value":0}]`,
+			want:  `{"tags":[0,1,2]}`,
+		},
+		{
+			name:  "add at array length appends",
+			doc:   `{"tags":[1,2]}`,
+			patch: `[{"op":"add","path":"/tags/2","value":3}]`,
+			want:  `{"tags":[1,2,3]}`,
+		},
+		{
+			name:    "- only for add",
+			doc:     `{"tags":[1,2]}`,
+			patch:   `[{"op":"replace","path":"/tags/-","value":3}]`,
+			wantErr: jsonpatch.ErrInvalidPatch,
+		},
+		{
+			name:    "leading zero index",
+			doc:     `{"tags":[1,2]}`,
+			patch:   `[{"op":"remove","path":"/tags/01"}]`,
+			wantErr: jsonpatch.ErrInvalidPatch,
+		},
+		{
+			name:    "index out of range",
+			doc:     `{"tags":[1,2]}`,
+			patch:   `[{"op":"remove","path":"/tags/2"}]`,
+			wantErr: jsonpatch.ErrInvalidPatch,
+		},
+		{
+			name:    "move into own child",
+			doc:     `{"a":{"b":{}}}`,
+			patch:   `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			wantErr: jsonpatch.ErrInvalidPatch,
+		},
+		{
+			name:  "move to sibling",
+			doc:   `{"a":{"x":1},"b":{}}`,
+			patch: `[{"op":"move","from":"/a/x","path":"/b/x"}]`,
+			want:  `{"a":{},"b":{"x":1}}`,
+		},
+		{
+			name:  "copy is independent",
+			doc:   `{"a":{"x":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/b"},{"op":"replace","path":"/b/x","value":2}]`,
+			want:  `{"a":{"x":1},"b":{"x":2}}`,
+		},
+		{
+			name:  "test compares numbers by value",
+			doc:   `{"n":10}`,
+			patch: `[{"op":"test","path":"/n","value":1e1}]`,
+			want:  `{"n":10}`,
+		},
+		{
+			name:    "test on different number",
+			doc:     `{"n":10}`,
+			patch:   `[{"op":"test","path":"/n","value":10.5}]`,
+			wantErr: jsonpatch.ErrTestFailed,
+		},
+		{
+			name:    "test on number and string",
+			doc:     `{"n":10}`,
+			patch:   `[{"op":"test","path":"/n","value":"10"}]`,
+			wantErr: jsonpatch.ErrTestFailed,
+		},
+		{
+			name:  "test ignores key order in objects",
+			doc:   `{"o":{"a":1,"b":[1,2]}}`,
+			patch: `[{"op":"test","path":"/o","value":{"b":[1,2],"a":1.0}}]`,
+			want:  `{"o":{"a":1,"b":[1,2]}}`,
+		},
+		{
+			name:    "test on object with extra key",
+			doc:     `{"o":{"a":1}}`,
+			patch:   `[{"op":"test","path":"/o","value":{"a":1,"b":2}}]`,
+			wantErr: jsonpatch.ErrTestFailed,
+		},
+		{
+			name:    "failed test after add",
+			doc:     `{"n":1}`,
+			patch:   `[{"op":"add","path":"/x","value":1},{"op":"test","path":"/n","value":2}]`,
+			wantErr: jsonpatch.ErrTestFailed,
+		},
+		{
+			name:    "unknown op",
+			doc:     `{}`,
+			patch:   `[{"op":"increment","path":"/n"}]`,
+			wantErr: jsonpatch.ErrInvalidPatch,
+		},
+		{
+			name:    "add without value",
+			doc:     `{}`,
+			patch:   `[{"op":"add","path":"/n"}]`,
+			wantErr: jsonpatch.ErrInvalidPatch,
+		},
+		{
+			name:    "pointer without /",
+			doc:     `{"n":1}`,
+			patch:   `[{"op":"remove","path":"n"}]`,
+			wantErr: jsonpatch.ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := jsonpatch.DecodePatch([]byte(tt.patch))
+
+			var doc []byte
+			if err == nil {
+				doc, err = patch.Apply([]byte(tt.doc))
+			}
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(doc))
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{name: "null removes member", doc: `{"a":1,"b":2}`, patch: `{"a":null}`, want: `{"b":2}`},
+		{name: "null removes nested member", doc: `{"o":{"a":1,"b":2}}`, patch: `{"o":{"a":null}}`, want: `{"o":{"b":2}}`},
+		{name: "null for missing member", doc: `{"a":1}`, patch: `{"b":null}`, want: `{"a":1}`},
+		{name: "objects are merged", doc: `{"o":{"a":1}}`, patch: `{"o":{"b":2}}`, want: `{"o":{"a":1,"b":2}}`},
+		{name: "arrays are replaced", doc: `{"tags":[1,2]}`, patch: `{"tags":[3]}`, want: `{"tags":[3]}`},
+		{name: "object replaces scalar", doc: `{"a":1}`, patch: `{"a":{"b":null,"c":2}}`, want: `{"a":{"c":2}}`},
+		{name: "non-object patch replaces document", doc: `{"a":1}`, patch: `[1]`, want: `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := jsonpatch.MergePatch(tt.patch).Apply([]byte(tt.doc))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(doc))
+		})
+	}
+
+	_, err := jsonpatch.MergePatch(`{`).Apply([]byte(`{}`))
+	assert.ErrorIs(t, err, jsonpatch.ErrInvalidPatch)
+}
+
+// TestPatchPet: проваленная операция test и изменение после чтения через PATCH.
+func TestPatchPet(t *testing.T) {
+	bd := newTestDataBase(t)
+	repo := petRepository.NewPetRepository(bd.DB)
+
+	added, err := repo.AddPet(context.Background(), models.Pet{Name: "Rex", Category: models.Category{ID: 1}, Status: models.PetStatusAvailable})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pet, err := repo.GetPetById(context.Background(), added.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRouter := func(repo petRepository.PetRepositoryer) http.Handler {
+		c := petController.NewPetController(newTestPetService(t, repo), responder.NewResponder(), 1<<20)
+		r := chi.NewRouter()
+		r.Patch("/pet/{petId}", c.PatchPet)
+		return r
+	}
+	router := newRouter(repo)
+
+	patch := func(router http.Handler, contentType, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/pet/%d", pet.ID), strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	etag := func(version int) string {
+		return fmt.Sprintf(`"%d"`, version)
+	}
+
+	w := patch(router, jsonpatch.JSONPatchType, "", `[{"op":"test","path":"/name","value":"Tom"},{"op":"replace","path":"/name","value":"Max"}]`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = patch(router, jsonpatch.MergePatchType, etag(pet.Version+1), `{"name":"Max"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = patch(router, "application/json", "", `{"name":"Max"}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	current, err := repo.GetPetById(context.Background(), pet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Rex", current.Name)
+	assert.Equal(t, pet.Version, current.Version)
+
+	w = patch(router, jsonpatch.JSONPatchType, etag(pet.Version), `[{"op":"test","path":"/name","value":"Rex"},{"op":"replace","path":"/name","value":"Max"}]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag(pet.Version+1), w.Header().Get("ETag"))
+
+	// сервис прочитал питомца до предыдущего изменения
+	stale := newRouter(staleReadPetRepository{PetRepositoryer: repo, stale: pet})
+
+	w = patch(stale, jsonpatch.MergePatchType, "", `{"name":"Tom"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = patch(stale, jsonpatch.MergePatchType, etag(pet.Version), `{"name":"Tom"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	current, err = repo.GetPetById(context.Background(), pet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Max", current.Name)
+}
+
+// TestFindPetsByTags: match=any находит питомцев хотя бы с одним тегом, match=all -
+// со всеми; имена и ID тегов можно смешивать, удаленные питомцы не находятся.
+func TestFindPetsByTags(t *testing.T) {
+	bd := newTestDataBase(t)
+	service := newTestPetService(t, petRepository.NewPetRepository(bd.DB))
+	ctx := context.Background()
+
+	exec := func(query string, args ...interface{}) {
+		if _, err := bd.DB.Exec(query, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exec("INSERT INTO tags (id, name) VALUES (10, 'fluffy'), (11, 'calm'), (12, 'small')")
+	pets := []struct {
+		name   string
+		status string
+		tags   []int
+	}{
+		{"Rex", "available", []int{10}},
+		{"Tom", "available", []int{10, 11}},
+		{"Max", "sold", []int{11, 12}},
+		{"Old", "deleted", []int{10, 11}},
+	}
+	for i, pet := range pets {
+		id := 100 + i
+		exec("INSERT INTO pets (id, category_id, name, status) VALUES (?, 1, ?, ?)", id, pet.name, pet.status)
+		for _, tag := range pet.tags {
+			exec("INSERT INTO tag_pets (tag_id, pet_id) VALUES (?, ?)", tag, id)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		filter  models.PetTagFilter
+		want    []string
+		wantErr bool
+	}{
+		{name: "any by name", filter: models.PetTagFilter{Names: []string{"fluffy"}, Match: models.TagMatchAny}, want: []string{"Rex", "Tom"}},
+		{name: "any of two", filter: models.PetTagFilter{Names: []string{"fluffy", "small"}, Match: models.TagMatchAny}, want: []string{"Rex", "Tom", "Max"}},
+		{name: "any by default", filter: models.PetTagFilter{Names: []string{"fluffy", "small"}}, want: []string{"Rex", "Tom", "Max"}},
+		{name: "any with unknown tag", filter: models.PetTagFilter{Names: []string{"fluffy", "nope"}, Match: models.TagMatchAny}, want: []string{"Rex", "Tom"}},
+		{name: "any by id", filter: models.PetTagFilter{IDs: []int{10, 12}, Match: models.TagMatchAny}, want: []string{"Rex", "Tom", "Max"}},
+		{name: "all", filter: models.PetTagFilter{Names: []string{"fluffy", "calm"}, Match: models.TagMatchAll}, want: []string{"Tom"}},
+		{name: "all by name and id", filter: models.PetTagFilter{Names: []string{"calm"}, IDs: []int{12}, Match: models.TagMatchAll}, want: []string{"Max"}},
+		{name: "all without common pet", filter: models.PetTagFilter{Names: []string{"fluffy", "small"}, Match: models.TagMatchAll}, want: []string{}},
+		{name: "all with unknown tag", filter: models.PetTagFilter{Names: []string{"fluffy", "nope"}, Match: models.TagMatchAll}, want: []string{}},
+		{name: "invalid match", filter: models.PetTagFilter{Names: []string{"fluffy"}, Match: "some"}, wantErr: true},
+		{name: "no tags", filter: models.PetTagFilter{Match: models.TagMatchAll}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := service.FindPetsByTags(ctx, tt.filter)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, pet := range found {
+				names = append(names, pet.Name)
+			}
+			assert.ElementsMatch(t, tt.want, names)
+		})
+	}
+}
+
+// TestLocalBlobStore: содержимое читается по ключу тем же, что было записано,
+// удаление повторяемо, а ключ не выводит за корень хранилища.
+func TestLocalBlobStore(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "blobs")
+	blobStore, err := storage.NewLocalBlobStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	key := storage.ContentKey("abcdef0123")
+	assert.Equal(t, "sha256/ab/cd/abcdef0123", key)
+	assert.NoError(t, blobStore.Put(ctx, key, strings.NewReader("first")))
+	assert.NoError(t, blobStore.Put(ctx, key, strings.NewReader("photo")))
+
+	blob, err := blobStore.Open(ctx, key)
+	if assert.NoError(t, err) {
+		data, err := io.ReadAll(blob)
+		blob.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, "photo", string(data))
+		assert.Equal(t, int64(5), blob.Size)
+	}
+
+	assert.NoError(t, blobStore.Delete(ctx, key))
+	assert.NoError(t, blobStore.Delete(ctx, key))
+	_, err = blobStore.Open(ctx, key)
+	assert.True(t, errors.Is(err, storage.ErrBlobNotFound), "unexpected error: %v", err)
+
+	for _, key := range []string{"", ".", "../outside", "sha256/../../outside", "/../outside"} {
+		t.Run(key, func(t *testing.T) {
+			assert.Error(t, blobStore.Put(ctx, key, strings.NewReader("escape")))
+			_, err := blobStore.Open(ctx, key)
+			assert.Error(t, err)
+			assert.False(t, errors.Is(err, storage.ErrBlobNotFound))
+			assert.Error(t, blobStore.Delete(ctx, key))
+		})
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "outside"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+// testApp - приложение с маршрутами как в NewServer на временной базе.
+type testApp struct {
+	db       *db.DataBaseSqlite
+	blobs    storage.BlobStore
+	handler  http.Handler
+	notifier *mockNotifier
+}
+
+const testSignKey = "test-sign-key"
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	t.Setenv("SIGN_KEY", testSignKey)
+
+	bd := newTestDataBase(t)
+
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &mockNotifier{}
+	services := modules.NewService(modules.NewRepository(bd.DB), blobStore, []int{64}, notifier)
+	c := modules.NewController(services, responder.NewResponder(), idempotency.NewSQLStore(bd.DB, time.Hour))
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Route("/v2", func(r chi.Router) {
+		r.Mount("/user", c.InitRoutesUser())
+		r.Mount("/pet", c.InitRoutesPet())
+		r.Mount("/store", c.InitRoutesStore())
+		r.Mount("/category", c.InitRoutesCategory())
+		r.Mount("/tag", c.InitRoutesTag())
+		r.Mount("/adoption", c.InitRoutesAdoption())
+	})
+
+	return &testApp{db: bd, blobs: blobStore, handler: r, notifier: notifier}
+}
+
+// token выпускает JWT пользователя userID с ролью role (пустая - обычный пользователь).
+func (a *testApp) token(t *testing.T, userID int, role string) string {
+	t.Helper()
+
+	return a.userToken(t, userID, "", role)
+}
+
+// userToken - то же с именем пользователя, которое попадает в историю изменений.
+func (a *testApp) userToken(t *testing.T, userID int, username string, role string) string {
+	t.Helper()
+
+	claims := map[string]interface{}{"sub": fmt.Sprint(userID)}
+	if username != "" {
+		claims["username"] = username
+	}
+	if role != "" {
+		claims["role"] = role
+	}
+
+	_, token, err := jwtauth.New("HS256", []byte(testSignKey), nil).Encode(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+// do выполняет запрос к приложению с JWT token, если он задан.
+func (a *testApp) do(t *testing.T, req *http.Request, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	if token != "" {
+		req.Header.Set("Authorization", "BEARER "+token)
+	}
+
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, req)
+
+	return w
+}
+
+// addPet добавляет питомца в продаже и возвращает его ID.
+func (a *testApp) addPet(t *testing.T, name string) int {
+	t.Helper()
+
+	res, err := a.db.DB.Exec("INSERT INTO pets (category_id, name, status) VALUES (1, ?, 'available')", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+
+	return int(id)
+}
+
+// addUser добавляет пользователя и возвращает его ID.
+func (a *testApp) addUser(t *testing.T, username string) int {
+	t.Helper()
+
+	res, err := a.db.DB.Exec("INSERT INTO users (username, first_name, last_name, email, password, phone, user_status) VALUES (?, '', '', '', '', '', 1)", username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+
+	return int(id)
+}
+
+// uploadPhoto загружает фото питомцу petID и возвращает ответ.
+func (a *testApp) uploadPhoto(t *testing.T, token string, petID int, name string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v2/pet/%d/uploadImage", petID), &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	return a.do(t, req, token)
+}
+
+// petPhotos возвращает фото питомца petID в порядке загрузки.
+func (a *testApp) petPhotos(t *testing.T, token string, petID int) []models.PetPhoto {
+	t.Helper()
+
+	w := a.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/pet/%d", petID), nil), token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get pet: %d %s", w.Code, w.Body)
+	}
+
+	var pet models.Pet
+	if err := json.Unmarshal(w.Body.Bytes(), &pet); err != nil {
+		t.Fatal(err)
+	}
+
+	return pet.Photos
+}
+
+// testPNG возвращает PNG width x height.
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// testPNGBomb возвращает маленький PNG, в заголовке которого указаны размеры width x height:
+// декодировать такой файл целиком значит выделить память под все пиксели.
+func testPNGBomb(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+
+	data := testPNG(t, 1, 1)
+
+	// IHDR идет сразу после сигнатуры: длина, тип, ширина, высота, ..., CRC
+	ihdr := data[12:29]
+	binary.BigEndian.PutUint32(ihdr[4:8], width)
+	binary.BigEndian.PutUint32(ihdr[8:12], height)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(ihdr))
+
+	return data
+}
+
+// TestParseSizes: размеры копий из IMAGE_VARIANT_SIZES сортируются и не повторяются,
+// пустое значение дает размеры по умолчанию, а неположительный размер - ошибку.
+func TestParseSizes(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []int
+		err   bool
+	}{
+		{value: "", want: imaging.DefaultVariantSizes()},
+		{value: "  ", want: imaging.DefaultVariantSizes()},
+		{value: "128", want: []int{128}},
+		{value: "512, 128", want: []int{128, 512}},
+		{value: "512,128,512,64,128", want: []int{64, 128, 512}},
+		{value: "256,256", want: []int{256}},
+		{value: "128,0", err: true},
+		{value: "128,-64", err: true},
+		{value: "128,,512", err: true},
+		{value: "small", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			sizes, err := imaging.ParseSizes(tt.value)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sizes)
+		})
+	}
+}
+
+// TestPetPhotoSize: копия размера size отдается, если она есть; фото, которое не
+// больше настроенного размера, отдается оригиналом, а ненастроенный размер - 404.
+func TestPetPhotoSize(t *testing.T) {
+	app := newTestApp(t)
+	token := app.token(t, 1, models.UserRoleAdmin)
+	petID := app.addPet(t, "Rex")
+
+	upload := func(width, height int) (models.PetPhoto, []byte) {
+		data := testPNG(t, width, height)
+		w := app.uploadPhoto(t, token, petID, "rex.png", data)
+		if w.Code != http.StatusOK {
+			t.Fatalf("upload: %d %s", w.Code, w.Body)
+		}
+
+		photos := app.petPhotos(t, token, petID)
+		return photos[len(photos)-1], data
+	}
+	get := func(photo models.PetPhoto, size int) *httptest.ResponseRecorder {
+		return app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s?size=%d", photo.URL, size), nil), token)
+	}
+
+	small, original := upload(16, 16)
+	assert.Empty(t, small.Variants)
+
+	w := get(small, 64)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, original, w.Body.Bytes())
+
+	assert.Equal(t, http.StatusNotFound, get(small, 32).Code)
+
+	large, original := upload(128, 96)
+	if assert.Len(t, large.Variants, 1) {
+		assert.Equal(t, 64, large.Variants[0].Width)
+		assert.Equal(t, 48, large.Variants[0].Height)
+	}
+
+	w = get(large, 64)
+	assert.Equal(t, http.StatusOK, w.Code)
+	variant, err := png.Decode(w.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 48), variant.Bounds())
+
+	w = app.do(t, httptest.NewRequest(http.MethodGet, large.URL, nil), token)
+	assert.Equal(t, original, w.Body.Bytes())
+}
+
+// failingVariantsPetRepository не может записать уменьшенные копии фото.
+type failingVariantsPetRepository struct {
+	petRepository.PetRepositoryer
+}
+
+func (r failingVariantsPetRepository) AddPhotoVariants(ctx context.Context, photoID int, variants []models.PetPhotoVariant) error {
+	return errors.New("variants are unavailable")
+}
+
+// TestUploadFileCleanup: если фото не удалось записать, его файл удаляется из
+// хранилища; если не удалось записать только копии, фото загружено без них,
+// а файлы копий удаляются.
+func TestUploadFileCleanup(t *testing.T) {
+	bd := newTestDataBase(t)
+	repo := petRepository.NewPetRepository(bd.DB)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	blobStore, err := storage.NewLocalBlobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs := func() int {
+		var n int
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() {
+				n++
+			}
+			return err
+		})
+		assert.NoError(t, err)
+		return n
+	}
+
+	pet, err := repo.AddPet(ctx, models.Pet{Name: "Rex", Status: models.PetStatusAvailable, Category: models.Category{ID: 1}})
+	assert.NoError(t, err)
+
+	upload := func(repo petService.PetRepositoryer) (models.PetPhoto, error) {
+		path := filepath.Join(t.TempDir(), "rex.png")
+		if err := os.WriteFile(path, testPNG(t, 128, 128), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		service := petService.NewPetService(repo, blobStore, []int{64}, &mockNotifier{})
+		return service.UploadFile(ctx, pet.ID, file, &multipart.FileHeader{Filename: "rex.png"})
+	}
+
+	_, err = upload(failingHistoryPetRepository{PetRepositoryer: repo})
+	assert.Error(t, err)
+	assert.Zero(t, blobs())
+
+	photo, err := upload(failingVariantsPetRepository{PetRepositoryer: repo})
+	assert.NoError(t, err)
+	assert.NotZero(t, photo.ID)
+	assert.Empty(t, photo.Variants)
+	assert.Equal(t, 1, blobs())
+}
+
+// TestImportPetsReport: отчет импорта считает все строки, а перечисляет только
+// отклоненные, не больше MaxPetImportErrors.
+func TestImportPetsReport(t *testing.T) {
+	bd := newTestDataBase(t)
+	service := newTestPetService(t, petRepository.NewPetRepository(bd.DB))
+	ctx := context.Background()
+
+	csv := "name,status,category_id\nRex,available,1\n"
+	for i := 0; i < 150; i++ {
+		csv += fmt.Sprintf("Bad%d,lost,1\n", i)
+	}
+	csv += "Tom,pending,1\n"
+
+	report, err := service.ImportPets(ctx, models.PetFormatCSV, strings.NewReader(csv), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 152, report.Total)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 150, report.Failed)
+	assert.True(t, report.Truncated)
+	if assert.Len(t, report.Errors, models.MaxPetImportErrors) {
+		assert.Equal(t, 2, report.Errors[0].Row)
+		assert.Equal(t, "Bad0", report.Errors[0].Name)
+		assert.NotEmpty(t, report.Errors[0].Error)
+		assert.Equal(t, models.MaxPetImportErrors+1, report.Errors[models.MaxPetImportErrors-1].Row)
+	}
+
+	// ParseFloat принимает NaN и Inf, но такой вес не проходит проверку профиля
+	csv = "name,category_id,weight\nRex,1,NaN\nTom,1,+Inf\nMax,1,-Inf\nBob,1,4.5\n"
+	report, err = service.ImportPets(ctx, models.PetFormatCSV, strings.NewReader(csv), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	if assert.Len(t, report.Errors, 3) {
+		assert.Equal(t, "Rex", report.Errors[0].Name)
+		assert.Contains(t, report.Errors[0].Error, "weight")
+	}
+
+	report, err = service.ImportPets(ctx, models.PetFormatCSV, strings.NewReader("name\nRex\nTom\n"), true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.False(t, report.Truncated)
+
+	data, err := json.Marshal(report)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"errors":[]`)
+}
+
+// TestImportPetsAdminOnly: импортировать питомцев может только администратор.
+func TestImportPetsAdminOnly(t *testing.T) {
+	app := newTestApp(t)
+
+	importPets := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet/import", strings.NewReader("name,category_id\nRex,1\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		return app.do(t, req, token)
+	}
+
+	assert.Equal(t, http.StatusForbidden, importPets(app.token(t, 2, "")).Code)
+
+	w := importPets(app.token(t, 1, models.UserRoleAdmin))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var report models.PetImportReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Imported)
+}
+
+// testJPEGWithExif возвращает JPEG width x height (левая половина красная, правая
+// синяя) с EXIF, в котором есть ориентация и описание description.
+func testJPEGWithExif(t *testing.T, width, height int, orientation uint16, description string) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// TIFF little-endian: IFD0 из ориентации и описания, строка описания - после IFD
+	var tiff bytes.Buffer
+	le := func(v interface{}) { binary.Write(&tiff, binary.LittleEndian, v) }
+	tiff.WriteString("II")
+	le(uint16(42))
+	le(uint32(8))
+	le(uint16(2))
+	le([]uint16{0x0112, 3})
+	le(uint32(1))
+	le([]uint16{orientation, 0})
+	le([]uint16{0x010E, 2})
+	le(uint32(len(description) + 1))
+	le(uint32(8 + 2 + 2*12 + 4))
+	le(uint32(0))
+	tiff.WriteString(description + "\x00")
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	data := append([]byte{0xFF, 0xD8}, app1...)
+	data = append(data, segment...)
+	return append(data, encoded.Bytes()[2:]...)
+}
+
+// TestPetPhotoOrientation: из EXIF загруженного JPEG остается только ориентация, а
+// уменьшенная копия, которая хранится без EXIF, повернута по ней.
+func TestPetPhotoOrientation(t *testing.T) {
+	app := newTestApp(t)
+	token := app.token(t, 1, "")
+	petID := app.addPet(t, "Rex")
+
+	// 6 - показывать с поворотом на 90 градусов по часовой стрелке
+	data := testJPEGWithExif(t, 128, 96, 6, "GPS 55.75N 37.62E")
+	if w := app.uploadPhoto(t, token, petID, "rex.jpg", data); w.Code != http.StatusOK {
+		t.Fatalf("upload: %d %s", w.Code, w.Body)
+	}
+	photo := app.petPhotos(t, token, petID)[0]
+
+	original := app.do(t, httptest.NewRequest(http.MethodGet, photo.URL, nil), token).Body.Bytes()
+	assert.NotContains(t, string(original), "GPS")
+	assert.Contains(t, string(original), "Exif")
+	config, err := jpeg.DecodeConfig(bytes.NewReader(original))
+	assert.NoError(t, err)
+	assert.Equal(t, 128, config.Width)
+
+	if assert.Len(t, photo.Variants, 1) {
+		assert.Equal(t, 48, photo.Variants[0].Width)
+		assert.Equal(t, 64, photo.Variants[0].Height)
+	}
+
+	w := app.do(t, httptest.NewRequest(http.MethodGet, photo.URL+"?size=64", nil), token)
+	variant, err := jpeg.Decode(w.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 48, 64), variant.Bounds())
+
+	// левая половина оригинала после поворота сверху
+	top := color.RGBAModel.Convert(variant.At(24, 4)).(color.RGBA)
+	bottom := color.RGBAModel.Convert(variant.At(24, 60)).(color.RGBA)
+	assert.Greater(t, top.R, top.B)
+	assert.Greater(t, bottom.B, bottom.R)
+
+	// без поворота EXIF не нужен вовсе
+	data = testJPEGWithExif(t, 16, 16, 1, "GPS 55.75N 37.62E")
+	if w := app.uploadPhoto(t, token, petID, "tom.jpg", data); w.Code != http.StatusOK {
+		t.Fatalf("upload: %d %s", w.Code, w.Body)
+	}
+	photo = app.petPhotos(t, token, petID)[1]
+	original = app.do(t, httptest.NewRequest(http.MethodGet, photo.URL, nil), token).Body.Bytes()
+	assert.NotContains(t, string(original), "Exif")
+}
+
+// TestPetPhotoRange: фото отдается частями по Range, ETag - контрольная сумма
+// содержимого, по совпавшему If-None-Match отдается 304, а If-Range с чужим ETag
+// возвращает файл целиком. Фото отдается без авторизации.
+func TestPetPhotoRange(t *testing.T) {
+	app := newTestApp(t)
+	token := app.token(t, 1, "")
+	petID := app.addPet(t, "Rex")
+
+	data := testPNG(t, 128, 128)
+	if w := app.uploadPhoto(t, token, petID, "rex.png", data); w.Code != http.StatusOK {
+		t.Fatalf("upload: %d %s", w.Code, w.Body)
+	}
+	photo := app.petPhotos(t, token, petID)[0]
+	etag := `"` + photo.Checksum + `"`
+
+	// фото отдается и без JWT
+	assert.Equal(t, data, app.do(t, httptest.NewRequest(http.MethodGet, photo.URL, nil), "").Body.Bytes())
+
+	get := func(url string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		return app.do(t, req, token)
+	}
+
+	tests := []struct {
+		name         string
+		header       map[string]string
+		code         int
+		body         []byte
+		contentRange string
+	}{
+		{name: "whole file", code: http.StatusOK, body: data},
+		{name: "first bytes", header: map[string]string{"Range": "bytes=0-9"}, code: http.StatusPartialContent, body: data[:10], contentRange: fmt.Sprintf("bytes 0-9/%d", len(data))},
+		{name: "middle", header: map[string]string{"Range": "bytes=10-19"}, code: http.StatusPartialContent, body: data[10:20], contentRange: fmt.Sprintf("bytes 10-19/%d", len(data))},
+		{name: "suffix", header: map[string]string{"Range": "bytes=-5"}, code: http.StatusPartialContent, body: data[len(data)-5:], contentRange: fmt.Sprintf("bytes %d-%d/%d", len(data)-5, len(data)-1, len(data))},
+		{name: "past the end", header: map[string]string{"Range": fmt.Sprintf("bytes=%d-", len(data))}, code: http.StatusRequestedRangeNotSatisfiable},
+		{name: "not modified", header: map[string]string{"If-None-Match": etag}, code: http.StatusNotModified},
+		{name: "other etag", header: map[string]string{"If-None-Match": `"other"`}, code: http.StatusOK, body: data},
+		{name: "if-range matches", header: map[string]string{"Range": "bytes=0-9", "If-Range": etag}, code: http.StatusPartialContent, body: data[:10]},
+		{name: "if-range changed", header: map[string]string{"Range": "bytes=0-9", "If-Range": `"other"`}, code: http.StatusOK, body: data},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(photo.URL, tt.header)
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+
+			if tt.body != nil {
+				assert.Equal(t, tt.body, w.Body.Bytes())
+			}
+			if tt.code == http.StatusNotModified {
+				assert.Zero(t, w.Body.Len())
+			}
+			if tt.contentRange != "" {
+				assert.Equal(t, tt.contentRange, w.Header().Get("Content-Range"))
+			}
+		})
+	}
+
+	assert.Equal(t, http.StatusNotFound, get(fmt.Sprintf("/v2/pet/%d/photos/%d", petID+1, photo.ID), nil).Code)
+
+	// у копии свое содержимое и свой ETag
+	if assert.Len(t, photo.Variants, 1) {
+		w := get(photo.URL+"?size=64", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"`+photo.Variants[0].Checksum+`"`, w.Header().Get("ETag"))
+
+		variant, err := png.Decode(w.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 64, 64), variant.Bounds())
+	}
+}
+
+// TestPetPhotoUploadRejected: файл больше MAX_UPLOAD_SIZE отклоняется с 413, а файл,
+// который по содержимому не JPEG, PNG или GIF, - с 415, как бы он ни назывался, а
+// картинка больше imaging.MaxPixels - с 400. Отклоненные файлы к питомцу не добавляются.
+func TestPetPhotoUploadRejected(t *testing.T) {
+	t.Setenv("MAX_UPLOAD_SIZE", "4096")
+	app := newTestApp(t)
+	token := app.token(t, 1, "")
+	petID := app.addPet(t, "Rex")
+
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		code int
+	}{
+		{name: "too large", file: "rex.png", data: append(testPNG(t, 4, 4), make([]byte, 8192)...), code: http.StatusRequestEntityTooLarge},
+		{name: "text", file: "notes.txt", data: []byte("just text"), code: http.StatusUnsupportedMediaType},
+		{name: "text named as png", file: "rex.png", data: []byte("just text"), code: http.StatusUnsupportedMediaType},
+		{name: "pdf", file: "rex.jpg", data: []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"), code: http.StatusUnsupportedMediaType},
+		{name: "empty", file: "rex.png", data: []byte{}, code: http.StatusUnsupportedMediaType},
+		{name: "too many pixels", file: "rex.png", data: testPNGBomb(t, 100000, 100000), code: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := app.uploadPhoto(t, token, petID, tt.file, tt.data)
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+		})
+	}
+
+	assert.Empty(t, app.petPhotos(t, token, petID))
+
+	w := app.uploadPhoto(t, token, petID, "rex.txt", testPNG(t, 4, 4))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	photos := app.petPhotos(t, token, petID)
+	if assert.Len(t, photos, 1) {
+		assert.Equal(t, "image/png", photos[0].MimeType)
+	}
+}
+
+// TestCategories: категории создаются, переименовываются и удаляются по ID; имя
+// уникально, питомец видит новое имя категории (и его версия растет), а категорию
+// с питомцами удалить нельзя. Изменять категории может только администратор.
+func TestCategories(t *testing.T) {
+	app := newTestApp(t)
+	token := app.token(t, 1, models.UserRoleAdmin)
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		return app.do(t, httptest.NewRequest(method, url, strings.NewReader(body)), token)
+	}
+	decode := func(w *httptest.ResponseRecorder) models.Category {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("%d %s", w.Code, w.Body)
+		}
+
+		var category models.Category
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &category))
+		return category
+	}
+
+	user := app.token(t, 2, "")
+	w := app.do(t, httptest.NewRequest(http.MethodPost, "/v2/category", strings.NewReader(`{"name":"Hamster"}`)), user)
+	assert.Equal(t, http.StatusForbidden, w.Code, "creating is admin only")
+
+	hamster := decode(send(http.MethodPost, "/v2/category", `{"id":1,"name":" Hamster "}`))
+	assert.NotEqual(t, 1, hamster.ID, "id is ignored")
+	assert.Equal(t, "Hamster", hamster.Name)
+	assert.Equal(t, hamster, decode(send(http.MethodGet, fmt.Sprintf("/v2/category/%d", hamster.ID), "")))
+
+	assert.Equal(t, http.StatusConflict, send(http.MethodPost, "/v2/category", `{"name":"Hamster"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/v2/category", `{"name":"  "}`).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/v2/category/9999", "").Code)
+
+	var categories []models.Category
+	w = app.do(t, httptest.NewRequest(http.MethodGet, "/v2/category", nil), user)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &categories))
+	assert.Contains(t, categories, hamster)
+
+	// питомец ссылается на категорию по ID, имя из запроса не важно
+	w = send(http.MethodPost, "/v2/pet", fmt.Sprintf(`{"name":"Rex","status":"available","category":{"id":%d,"name":"Other"}}`, hamster.ID))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var pet models.Pet
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &pet))
+	assert.Equal(t, hamster, pet.Category)
+
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/v2/pet", `{"name":"Tom","status":"available","category":{"id":9999}}`).Code)
+
+	url := fmt.Sprintf("/v2/category/%d", hamster.ID)
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		w = app.do(t, httptest.NewRequest(method, url, strings.NewReader(`{"name":"Rodent"}`)), user)
+		assert.Equal(t, http.StatusForbidden, w.Code, "%s is admin only", method)
+	}
+
+	renamed := decode(send(http.MethodPut, url, `{"name":"Rodent"}`))
+	assert.Equal(t, models.Category{ID: hamster.ID, Name: "Rodent"}, renamed)
+	assert.Equal(t, renamed, decode(send(http.MethodPut, url, `{"name":"Rodent"}`)), "same name")
+	assert.Equal(t, http.StatusNotFound, send(http.MethodPut, "/v2/category/9999", `{"name":"Mouse"}`).Code)
+
+	version := pet.Version
+	w = send(http.MethodGet, fmt.Sprintf("/v2/pet/%d", pet.ID), "")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &pet))
+	assert.Equal(t, renamed, pet.Category)
+	assert.Greater(t, pet.Version, version, "renaming the category changes the pet")
+
+	// категория занята питомцем
+	assert.Equal(t, http.StatusConflict, send(http.MethodDelete, url, "").Code)
+	assert.Equal(t, renamed, decode(send(http.MethodGet, url, "")))
+
+	empty := decode(send(http.MethodPost, "/v2/category", `{"name":"Empty"}`))
+	assert.Equal(t, http.StatusConflict, send(http.MethodPut, fmt.Sprintf("/v2/category/%d", empty.ID), `{"name":"Rodent"}`).Code)
+
+	url = fmt.Sprintf("/v2/category/%d", empty.ID)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, url, "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, url, "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, url, "").Code)
+}
+
+// TestDeleteCategoryInUse: репозиторий сам не удаляет категорию, на которую ссылается
+// питомец, даже если ее занятость перед удалением никто не проверял.
+func TestDeleteCategoryInUse(t *testing.T) {
+	bd := newTestDataBase(t)
+	repo := categoryRepository.NewCategoryRepository(bd.DB)
+	ctx := context.Background()
+
+	category, err := repo.CreateCategory(ctx, models.Category{Name: "Hamster"})
+	assert.NoError(t, err)
+	_, err = bd.DB.Exec("INSERT INTO pets (name, status, category_id) VALUES ('Rex', 'available', ?)", category.ID)
+	assert.NoError(t, err)
+
+	err = repo.DeleteCategory(ctx, category.ID)
+	var conflict *models.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+
+	_, err = repo.GetCategoryById(ctx, category.ID)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, repo.DeleteCategory(ctx, 9999), sql.ErrNoRows)
+}
+
+// TestMergeTags: питомцы исходного тега переходят на целевой без дублей, исходный
+// тег удаляется, версии затронутых питомцев растут (их ETag меняется). Сливать
+// теги может только администратор.
+func TestMergeTags(t *testing.T) {
+	app := newTestApp(t)
+	token := app.token(t, 1, models.UserRoleAdmin)
+
+	exec := func(query string, args ...interface{}) {
+		if _, err := app.db.DB.Exec(query, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	version := func(petID int) int {
+		var version int
+		assert.NoError(t, app.db.DB.QueryRow("SELECT version FROM pets WHERE id = ?", petID).Scan(&version))
+		return version
+	}
+	post := func(url string) *httptest.ResponseRecorder {
+		return app.do(t, httptest.NewRequest(http.MethodPost, url, nil), token)
+	}
+
+	exec("INSERT INTO tags (id, name) VALUES (10, 'fluffy'), (11, 'soft')")
+	onlySource, both, onlyTarget := app.addPet(t, "Rex"), app.addPet(t, "Tom"), app.addPet(t, "Max")
+	exec("INSERT INTO tag_pets (tag_id, pet_id) VALUES (10, ?), (10, ?), (11, ?), (11, ?)", onlySource, both, both, onlyTarget)
+
+	forbidden := app.do(t, httptest.NewRequest(http.MethodPost, "/v2/tag/10/merge/11", nil), app.token(t, 2, ""))
+	assert.Equal(t, http.StatusForbidden, forbidden.Code, "merging is admin only")
+	assert.Equal(t, http.StatusBadRequest, post("/v2/tag/10/merge/10").Code)
+	assert.Equal(t, http.StatusNotFound, post("/v2/tag/10/merge/99").Code)
+	assert.Equal(t, http.StatusNotFound, post("/v2/tag/99/merge/10").Code)
+
+	w := post("/v2/tag/10/merge/11")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var usage models.TagUsage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, models.TagUsage{ID: 11, Name: "soft", PetCount: 3}, usage)
+
+	w = app.do(t, httptest.NewRequest(http.MethodGet, "/v2/tag/10", nil), token)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	for _, petID := range []int{onlySource, both, onlyTarget} {
+		var links int
+		assert.NoError(t, app.db.DB.QueryRow("SELECT COUNT(*) FROM tag_pets WHERE pet_id = ?", petID).Scan(&links))
+		assert.Equal(t, 1, links, "pet %d", petID)
+	}
+
+	assert.Equal(t, 2, version(onlySource))
+	assert.Equal(t, 2, version(both))
+	assert.Equal(t, 1, version(onlyTarget))
+}
+
+// TestRenameTag: переименование тега меняет теги питомцев, поэтому их версия растет,
+// и сохраненный ETag питомца больше не подходит. Переименовать тег может только
+// администратор.
+func TestRenameTag(t *testing.T) {
+	app := newTestApp(t)
+	token := app.token(t, 1, models.UserRoleAdmin)
+
+	_, err := app.db.DB.Exec("INSERT INTO tags (id, name) VALUES (10, 'fluffy'), (11, 'soft')")
+	assert.NoError(t, err)
+	tagged, other := app.addPet(t, "Rex"), app.addPet(t, "Tom")
+	_, err = app.db.DB.Exec("INSERT INTO tag_pets (tag_id, pet_id) VALUES (10, ?), (11, ?)", tagged, other)
+	assert.NoError(t, err)
+
+	get := func(petID int) *httptest.ResponseRecorder {
+		return app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/pet/%d", petID), nil), token)
+	}
+	etag, otherETag := get(tagged).Header().Get("ETag"), get(other).Header().Get("ETag")
+
+	w := app.do(t, httptest.NewRequest(http.MethodPut, "/v2/tag/10", strings.NewReader(`{"name":"furry"}`)), app.token(t, 2, ""))
+	assert.Equal(t, http.StatusForbidden, w.Code, "renaming is admin only")
+	assert.Equal(t, etag, get(tagged).Header().Get("ETag"))
+
+	w = app.do(t, httptest.NewRequest(http.MethodPut, "/v2/tag/10", strings.NewReader(`{"name":"furry"}`)), token)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = get(tagged)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	var pet models.Pet
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &pet))
+	assert.Equal(t, []models.Tag{{ID: 10, Name: "furry"}}, pet.Tags)
+
+	assert.Equal(t, otherETag, get(other).Header().Get("ETag"))
+}
+
+// TestListPetsCursor: обход страниц по nextCursor выдает каждого питомца ровно один
+// раз в порядке сортировки, в том числе при совпадающих значениях поля сортировки
+// и при добавлении питомца во время обхода.
+func TestListPetsCursor(t *testing.T) {
+	bd := newTestDataBase(t)
+	service := newTestPetService(t, petRepository.NewPetRepository(bd.DB))
+	ctx := context.Background()
+
+	const categoryID = 50
+	if _, err := bd.DB.Exec("INSERT INTO categories (id, name) VALUES (?, 'paging')", categoryID); err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		id     int
+		name   string
+		status string
+	}
+	var rows []row
+	addPet := func(name, status string) {
+		res, err := bd.DB.Exec("INSERT INTO pets (category_id, name, status) VALUES (?, ?, ?)", categoryID, name, status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := res.LastInsertId()
+		rows = append(rows, row{int(id), name, status})
+	}
+
+	for i, name := range []string{"Bella", "Alfa", "Bella", "Coco", "Alfa", "Dino", "Bella"} {
+		status := []string{"available", "pending", "sold"}[i%3]
+		addPet(name, status)
+	}
+
+	expected := func(sortField string, desc bool) []int {
+		sorted := append([]row(nil), rows...)
+		key := func(r row) string {
+			switch sortField {
+			case models.PetSortName:
+				return r.name
+			case models.PetSortStatus:
+				return r.status
+			}
+			return ""
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			a, b := sorted[i], sorted[j]
+			if desc {
+				a, b = b, a
+			}
+			if key(a) != key(b) {
+				return key(a) < key(b)
+			}
+			return a.id < b.id
+		})
+
+		ids := make([]int, len(sorted))
+		for i, r := range sorted {
+			ids[i] = r.id
+		}
+		return ids
+	}
+
+	walk := func(query models.PetListQuery, between func()) []int {
+		var ids []int
+		for pages := 0; ; pages++ {
+			if pages > len(rows) {
+				t.Fatal("pagination does not end")
+			}
+
+			page, err := service.ListPets(ctx, query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.LessOrEqual(t, len(page.Items), query.Limit)
+
+			for _, pet := range page.Items {
+				ids = append(ids, pet.ID)
+			}
+
+			if !page.Pagination.HasMore {
+				assert.Empty(t, page.Pagination.NextCursor)
+				return ids
+			}
+			query.Cursor = page.Pagination.NextCursor
+
+			if between != nil {
+				between()
+				between = nil
+			}
+		}
+	}
+
+	tests := []struct {
+		sort  string
+		desc  bool
+		limit int
+	}{
+		{sort: models.PetSortID, limit: 3},
+		{sort: models.PetSortID, desc: true, limit: 2},
+		{sort: models.PetSortName, limit: 2},
+		{sort: models.PetSortName, desc: true, limit: 3},
+		{sort: models.PetSortStatus, limit: 1},
+		{sort: models.PetSortStatus, desc: true, limit: 4},
+		{sort: models.PetSortName, limit: 7},
+		{sort: models.PetSortName, limit: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s desc=%v limit=%d", tt.sort, tt.desc, tt.limit), func(t *testing.T) {
+			query := models.PetListQuery{CategoryID: categoryID, Sort: tt.sort, Desc: tt.desc, Limit: tt.limit}
+			assert.Equal(t, expected(tt.sort, tt.desc), walk(query, nil))
+		})
+	}
+
+	// питомец, добавленный после первой страницы перед курсором, не сдвигает остальные
+	before := expected(models.PetSortName, false)
+	ids := walk(models.PetListQuery{CategoryID: categoryID, Sort: models.PetSortName, Limit: 2}, func() {
+		addPet("Aaron", "available")
+	})
+	assert.Equal(t, before, ids)
+
+	page, err := service.ListPets(ctx, models.PetListQuery{CategoryID: categoryID, Sort: models.PetSortName, Limit: 2})
+	assert.NoError(t, err)
+
+	_, err = service.ListPets(ctx, models.PetListQuery{CategoryID: categoryID, Sort: models.PetSortName, Desc: true, Limit: 2, Cursor: page.Pagination.NextCursor})
+	assert.Error(t, err, "cursor of another sort")
+
+	_, err = service.ListPets(ctx, models.PetListQuery{CategoryID: categoryID, Cursor: "not a cursor"})
+	assert.Error(t, err)
+
+	_, err = service.ListPets(ctx, models.PetListQuery{CategoryID: categoryID, Limit: 101})
+	assert.Error(t, err)
+
+	page, err = service.ListPets(ctx, models.PetListQuery{CategoryID: categoryID})
+	assert.NoError(t, err)
+	assert.Equal(t, 20, page.Pagination.Limit)
+	assert.Len(t, page.Items, len(rows))
+}
+
+// TestWithoutFTS5: в сборке без тега sqlite_fts5 поиск отвечает ErrSearchUnavailable,
+// а база, в которой индекс уже создан, не открывается: его триггеры не дали бы
+// записать питомца. Поиск с FTS5 проверяет TestSearchPets (search_test.go).
+func TestWithoutFTS5(t *testing.T) {
+	bd := newTestDataBase(t)
+	service := newTestPetService(t, petRepository.NewPetRepository(bd.DB))
+
+	enabled, err := bd.FTS5Enabled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enabled {
+		t.Skip("sqlite3 is built with FTS5")
+	}
+
+	_, err = service.SearchPets(context.Background(), "gold", 0)
+	assert.ErrorIs(t, err, models.ErrSearchUnavailable)
+
+	// так выглядит база, которую раньше открывала сборка с FTS5
+	_, err = bd.DB.Exec("CREATE TABLE schema_migrations_fts5 (version INTEGER, dirty BOOLEAN)")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, bd.Migrate(), db.ErrFTS5Required)
+}
+
+// TestPetHistory: каждое изменение питомца попадает в его историю со старыми и новыми
+// значениями полей, именем пользователя из JWT и ID запроса; запрос без изменений
+// истории не добавляет.
+func TestPetHistory(t *testing.T) {
+	app := newTestApp(t)
+	token := app.userToken(t, 1, "anna", "")
+
+	send := func(method, url, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		return app.do(t, req, token)
+	}
+
+	w := send(http.MethodPost, "/v2/pet", "application/json", `{"name":"Rex","status":"available","category":{"id":1}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("add pet: %d %s", w.Code, w.Body)
+	}
+	var pet models.Pet
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &pet))
+	url := fmt.Sprintf("/v2/pet/%d", pet.ID)
+
+	const form = "application/x-www-form-urlencoded"
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, url, form, "name=Max").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, url, form, "name=Max").Code)
+	assert.Equal(t, http.StatusOK, app.uploadPhoto(t, token, pet.ID, "max.png", testPNG(t, 4, 4)).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, url, "", "").Code)
+
+	w = send(http.MethodGet, url+"/history", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history []models.PetHistoryEntry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+
+	if !assert.Len(t, history, 4) {
+		return
+	}
+
+	actions := []string{models.PetActionCreate, models.PetActionUpdate, models.PetActionUploadPhoto, models.PetActionDelete}
+	for i, entry := range history {
+		assert.Equal(t, actions[i], entry.Action)
+		assert.Equal(t, pet.ID, entry.PetID)
+		assert.Equal(t, "anna", entry.UserName)
+		assert.NotEmpty(t, entry.RequestID)
+		assert.False(t, entry.CreatedAt.IsZero())
+	}
+
+	assert.Equal(t, models.PetFieldChange{New: "Rex"}, history[0].Changes["name"])
+	assert.Equal(t, models.PetFieldChange{New: "available"}, history[0].Changes["status"])
+	assert.Equal(t, map[string]models.PetFieldChange{"name": {Old: "Rex", New: "Max"}}, history[1].Changes)
+	assert.Contains(t, history[2].Changes, "photos")
+	assert.Equal(t, map[string]models.PetFieldChange{"status": {Old: "available", New: "deleted"}}, history[3].Changes)
+	assert.NotEqual(t, history[0].RequestID, history[1].RequestID)
+
+	assert.NotEqual(t, http.StatusOK, send(http.MethodGet, "/v2/pet/9999/history", "", "").Code)
+}
+
+// TestPetHistoryRelatedChanges: в историю питомца попадают и изменения, сделанные не
+// через сам питомец: импорт, слияние и удаление его тегов, записи медицинской карты.
+func TestPetHistoryRelatedChanges(t *testing.T) {
+	app := newTestApp(t)
+	token := app.userToken(t, 1, "anna", models.UserRoleAdmin)
+
+	send := func(method, url, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		return app.do(t, req, token)
+	}
+	history := func(petID int) []models.PetHistoryEntry {
+		t.Helper()
+		w := send(http.MethodGet, fmt.Sprintf("/v2/pet/%d/history", petID), "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("history: %d %s", w.Code, w.Body)
+		}
+
+		var entries []models.PetHistoryEntry
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+		return entries
+	}
+	changes := func(entry models.PetHistoryEntry) string {
+		data, err := json.Marshal(entry.Changes)
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	w := send(http.MethodPost, "/v2/pet/import", "application/x-ndjson", `{"name":"Rex","category":{"id":1}}`+"\n")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rex int
+	assert.NoError(t, app.db.DB.QueryRow("SELECT id FROM pets WHERE name = 'Rex'").Scan(&rex))
+
+	_, err := app.db.DB.Exec("INSERT INTO tags (id, name) VALUES (10, 'fluffy'), (11, 'soft'), (12, 'old')")
+	assert.NoError(t, err)
+	_, err = app.db.DB.Exec("INSERT INTO tag_pets (tag_id, pet_id) VALUES (10, ?), (12, ?)", rex, rex)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/v2/tag/10/merge/11", "", "").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, "/v2/tag/12", "", "").Code)
+
+	medical := fmt.Sprintf("/v2/pet/%d/medical", rex)
+	w = send(http.MethodPost, medical, "application/json", `{"kind":"vetVisit","title":"Checkup","date":"2024-01-10"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var record models.PetMedicalRecord
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &record))
+	recordURL := fmt.Sprintf("%s/%d", medical, record.ID)
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, recordURL, "application/json", `{"kind":"vetVisit","title":"Dental","date":"2024-01-10"}`).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, recordURL, "", "").Code)
+
+	entries := history(rex)
+	if !assert.Len(t, entries, 6) {
+		return
+	}
+
+	actions := []string{
+		models.PetActionImport, models.PetActionMergeTag, models.PetActionDeleteTag,
+		models.PetActionAddMedicalRecord, models.PetActionUpdateMedicalRecord, models.PetActionDeleteMedicalRecord,
+	}
+	for i, entry := range entries {
+		assert.Equal(t, actions[i], entry.Action)
+		assert.Equal(t, "anna", entry.UserName)
+	}
+
+	assert.Equal(t, models.PetFieldChange{New: "Rex"}, entries[0].Changes["name"])
+	assert.JSONEq(t, `{"tags":{"old":[{"id":10,"name":"fluffy"},{"id":12,"name":"old"}],"new":[{"id":11,"name":"soft"},{"id":12,"name":"old"}]}}`, changes(entries[1]))
+	assert.JSONEq(t, `{"tags":{"old":[{"id":11,"name":"soft"},{"id":12,"name":"old"}],"new":[{"id":11,"name":"soft"}]}}`, changes(entries[2]))
+	assert.Contains(t, changes(entries[3]), `"old":null`)
+	assert.Contains(t, changes(entries[3]), `"title":"Checkup"`)
+	assert.Contains(t, changes(entries[4]), `"title":"Checkup"`)
+	assert.Contains(t, changes(entries[4]), `"title":"Dental"`)
+	assert.Contains(t, changes(entries[5]), `"new":null`)
+}
+
+// failingHistoryPetRepository не может записать историю.
+type failingHistoryPetRepository struct {
+	petRepository.PetRepositoryer
+}
+
+func (r failingHistoryPetRepository) AddPetHistory(ctx context.Context, entry models.PetHistoryEntry) error {
+	return errors.New("history is unavailable")
+}
+
+// TestPetHistoryInTransaction: изменение питомца и его запись в истории фиксируются
+// вместе - если историю записать не удалось, изменение откатывается. Так же и для
+// брони питомца заказом.
+func TestPetHistoryInTransaction(t *testing.T) {
+	bd := newTestDataBase(t)
+	repo := petRepository.NewPetRepository(bd.DB)
+	service := newTestPetService(t, failingHistoryPetRepository{PetRepositoryer: repo})
+	ctx := context.Background()
+
+	count := func(query string, args ...interface{}) int {
+		var n int
+		assert.NoError(t, bd.DB.QueryRow(query, args...).Scan(&n))
+		return n
+	}
+
+	pets := count("SELECT COUNT(*) FROM pets")
+	_, err := service.AddPet(ctx, models.Pet{Name: "Rex", Category: models.Category{ID: 1}})
+	assert.Error(t, err)
+	assert.Equal(t, pets, count("SELECT COUNT(*) FROM pets"))
+
+	pet, err := repo.AddPet(ctx, models.Pet{Name: "Rex", Status: models.PetStatusAvailable, Category: models.Category{ID: 1}})
+	assert.NoError(t, err)
+	pet, err = repo.GetPetById(ctx, pet.ID)
+	assert.NoError(t, err)
+
+	assert.Error(t, service.UpdatePetWithForm(ctx, pet.ID, "Max", "", 0))
+	assert.Error(t, service.DeletePet(ctx, pet.ID))
+	_, err = service.AddMedicalRecord(ctx, models.PetMedicalRecord{PetID: pet.ID, Kind: models.MedicalKindVetVisit, Title: "Checkup", Date: "2024-01-10"})
+	assert.Error(t, err)
+
+	current, err := repo.GetPetById(ctx, pet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Rex", current.Name)
+	assert.Equal(t, models.PetStatusAvailable, current.Status)
+	assert.Equal(t, pet.Version, current.Version)
+	assert.Zero(t, count("SELECT COUNT(*) FROM pet_medical_records WHERE pet_id = ?", pet.ID))
+
+	petService := &mockPetStatusService{getPetById: repo.GetPetById, recordErr: errors.New("history is unavailable")}
+	store := storeService.NewStoreService(storeRepository.NewStoreRepository(bd.DB), petService)
+	_, err = store.PlaceOrder(userContext(t, 1), models.Order{PetID: pet.ID, Quantity: 1, ShipDate: "2024-01-01"})
+	assert.Error(t, err)
+	assert.Zero(t, count("SELECT COUNT(*) FROM orders WHERE pet_id = ?", pet.ID))
+	assert.Empty(t, petService.changes)
+
+	current, err = repo.GetPetById(ctx, pet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PetStatusAvailable, current.Status)
+}
+
+// TestRestorePet: восстановленный питомец получает статус, который был у него до
+// удаления, а без записи об удалении в истории - available. Бронь заказа при удалении
+// снимается, поэтому забронированный питомец возвращается в продажу. Восстановить
+// можно только удаленного питомца.
+func TestRestorePet(t *testing.T) {
+	app := newTestApp(t)
+	token := app.userToken(t, 1, "anna", "")
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return app.do(t, req, token)
+	}
+	restore := func(petID int) *httptest.ResponseRecorder {
+		return send(http.MethodPost, fmt.Sprintf("/v2/pet/%d/restore", petID), "")
+	}
+	decode := func(w *httptest.ResponseRecorder) models.Pet {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("%d %s", w.Code, w.Body)
+		}
+
+		var pet models.Pet
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &pet))
+		return pet
+	}
+
+	petID := app.addPet(t, "Rex")
+	url := fmt.Sprintf("/v2/pet/%d", petID)
+	assert.Equal(t, http.StatusConflict, restore(petID).Code, "pet is not deleted")
+
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, url, "status=pending").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, url, "status=sold").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, url, "").Code)
+
+	restored := decode(restore(petID))
+	assert.Equal(t, models.PetStatusSold, restored.Status)
+	assert.Equal(t, restored, decode(send(http.MethodGet, url, "")))
+	assert.Equal(t, http.StatusConflict, restore(petID).Code)
+
+	var history []models.PetHistoryEntry
+	assert.NoError(t, json.Unmarshal(send(http.MethodGet, url+"/history", "").Body.Bytes(), &history))
+	if assert.NotEmpty(t, history) {
+		last := history[len(history)-1]
+		assert.Equal(t, models.PetActionRestore, last.Action)
+		assert.Equal(t, models.PetFieldChange{Old: models.PetStatusDeleted, New: models.PetStatusSold}, last.Changes["status"])
+	}
+
+	// бронь заказа снимается при удалении, поэтому забронированный питомец возвращается в продажу
+	reservedID := app.addPet(t, "Max")
+	w := send(http.MethodPost, "/v2/store/order", fmt.Sprintf(`{"petId":%d,"quantity":1,"shipDate":"2024-01-01"}`, reservedID))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, fmt.Sprintf("/v2/pet/%d", reservedID), "").Code)
+
+	assert.Equal(t, models.PetStatusAvailable, decode(restore(reservedID)).Status)
+	var reservedBy sql.NullInt64
+	assert.NoError(t, app.db.DB.QueryRow("SELECT reserved_by_order FROM pets WHERE id = ?", reservedID).Scan(&reservedBy))
+	assert.False(t, reservedBy.Valid)
+
+	// питомец удален в обход истории
+	res, err := app.db.DB.Exec("INSERT INTO pets (category_id, name, status) VALUES (1, 'Old', 'deleted')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldID, _ := res.LastInsertId()
+	assert.Equal(t, models.PetStatusAvailable, decode(restore(int(oldID))).Status)
+
+	assert.NotEqual(t, http.StatusOK, restore(9999).Code)
+}
+
+// TestRestorePetConcurrent: если питомца изменили между чтением и восстановлением,
+// ответ - 409, как при любой записи без If-Match, а не 412.
+func TestRestorePetConcurrent(t *testing.T) {
+	bd := newTestDataBase(t)
+	repo := petRepository.NewPetRepository(bd.DB)
+	ctx := context.Background()
+
+	added, err := repo.AddPet(ctx, models.Pet{Name: "Rex", Category: models.Category{ID: 1}, Status: models.PetStatusAvailable})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, repo.DeletePet(ctx, added.ID, 0))
+
+	stale, err := repo.GetPetById(ctx, added.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = bd.DB.Exec("UPDATE pets SET version = version + 1 WHERE id = ?", added.ID)
+	assert.NoError(t, err)
+
+	service := newTestPetService(t, staleReadPetRepository{PetRepositoryer: repo, stale: stale})
+	_, err = service.RestorePet(ctx, added.ID)
+
+	var conflictErr *models.ConflictError
+	assert.True(t, errors.As(err, &conflictErr), "unexpected error: %v", err)
+	assert.False(t, errors.Is(err, models.ErrVersionMismatch))
+}
+
+// TestPurgePetFiles: удалить навсегда можно только удаленного питомца и только
+// администратору; файл фото удаляется, когда на него больше не ссылается ни один
+// питомец. История и заявки на питомца остаются.
+func TestPurgePetFiles(t *testing.T) {
+	app := newTestApp(t)
+	admin := app.token(t, 1, models.UserRoleAdmin)
+	user := app.token(t, 2, "")
+	ctx := context.Background()
+
+	purge := func(token string, petID int) *httptest.ResponseRecorder {
+		return app.do(t, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/v2/pet/%d/purge", petID), nil), token)
+	}
+	remove := func(petID int) {
+		w := app.do(t, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/v2/pet/%d", petID), nil), admin)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	blobExists := func(key string) bool {
+		blob, err := app.blobs.Open(ctx, key)
+		if err != nil {
+			return false
+		}
+		blob.Close()
+		return true
+	}
+
+	// одно и то же фото загружено двум питомцам и хранится один раз
+	data := testPNG(t, 8, 8)
+	rex, tom := app.addPet(t, "Rex"), app.addPet(t, "Tom")
+	for _, petID := range []int{rex, tom} {
+		assert.Equal(t, http.StatusOK, app.uploadPhoto(t, admin, petID, "photo.png", data).Code)
+	}
+	key := storage.ContentKey(app.petPhotos(t, admin, rex)[0].Checksum)
+	assert.True(t, blobExists(key))
+
+	assert.Equal(t, http.StatusConflict, purge(admin, rex).Code, "pet is not deleted")
+
+	annaID := app.addUser(t, "anna")
+	anna := app.userToken(t, annaID, "anna", "")
+	w := app.do(t, httptest.NewRequest(http.MethodPost, "/v2/adoption", strings.NewReader(fmt.Sprintf(`{"petId":%d}`, rex))), anna)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var application models.AdoptionApplication
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &application))
+
+	remove(rex)
+	assert.Equal(t, http.StatusForbidden, purge(user, rex).Code)
+	assert.Equal(t, http.StatusOK, purge(admin, rex).Code)
+	assert.NotEqual(t, http.StatusOK, app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/pet/%d", rex), nil), admin).Code)
+	assert.True(t, blobExists(key), "photo is still used by another pet")
+
+	// заявка на питомца остается у заявителя, но уже отклонена
+	w = app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/adoption/%d", application.ID), nil), anna)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &application))
+	assert.Equal(t, rex, application.PetID)
+	assert.Equal(t, models.AdoptionStatusRejected, application.Status)
+
+	remove(tom)
+	assert.Equal(t, http.StatusOK, purge(admin, tom).Code)
+	assert.False(t, blobExists(key))
+
+	// история остается, читается через API и заканчивается окончательным удалением
+	for _, petID := range []int{rex, tom} {
+		w := app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/pet/%d/history", petID), nil), admin)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var history []models.PetHistoryEntry
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		if assert.NotEmpty(t, history) {
+			last := history[len(history)-1]
+			assert.Equal(t, models.PetActionPurge, last.Action)
+			assert.Equal(t, models.PetStatusDeleted, last.Changes["status"].Old)
+			assert.Nil(t, last.Changes["status"].New)
+		}
+	}
+	assert.NotEqual(t, http.StatusOK, app.do(t, httptest.NewRequest(http.MethodGet, "/v2/pet/9999/history", nil), admin).Code)
+}
+
+// reuploadPetRepository сразу после удаления питомца навсегда загружает те же файлы
+// питомцу petID, как параллельный запрос с тем же фото.
+type reuploadPetRepository struct {
+	petRepository.PetRepositoryer
+	petID int
+}
+
+func (r reuploadPetRepository) PurgePet(ctx context.Context, id int) ([]string, error) {
+	keys, err := r.PetRepositoryer.PurgePet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		_, err = r.UploadFile(ctx, models.PetPhoto{PetID: r.petID, FileName: "again.png", BlobKey: key, MimeType: "image/png"})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// TestPurgePetKeepsReuploadedFile: файл, который снова понадобился после удаления
+// питомца навсегда, но до удаления файла, остается в хранилище.
+func TestPurgePetKeepsReuploadedFile(t *testing.T) {
+	bd := newTestDataBase(t)
+	repo := petRepository.NewPetRepository(bd.DB)
+	ctx := context.Background()
+
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var petIDs []int
+	for _, name := range []string{"Rex", "Tom"} {
+		pet, err := repo.AddPet(ctx, models.Pet{Name: name, Category: models.Category{ID: 1}, Status: models.PetStatusAvailable})
+		if err != nil {
+			t.Fatal(err)
+		}
+		petIDs = append(petIDs, pet.ID)
+	}
+	rex, tom := petIDs[0], petIDs[1]
+
+	key := storage.ContentKey("reuploaded")
+	assert.NoError(t, blobStore.Put(ctx, key, bytes.NewReader(testPNG(t, 4, 4))))
+	_, err = repo.UploadFile(ctx, models.PetPhoto{PetID: rex, FileName: "rex.png", BlobKey: key, MimeType: "image/png"})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.DeletePet(ctx, rex, 0))
+
+	service := petService.NewPetService(reuploadPetRepository{PetRepositoryer: repo, petID: tom}, blobStore, []int{64}, &mockNotifier{})
+	assert.NoError(t, service.PurgePet(ctx, rex))
+
+	blob, err := blobStore.Open(ctx, key)
+	if assert.NoError(t, err) {
+		blob.Close()
+	}
+}
+
+// TestUpdatePetRemovesPhotos: фото, которого нет в photoUrls при замене питомца, удаляется
+// вместе с копиями, а его файлы - если на них больше не ссылается ни один питомец.
+func TestUpdatePetRemovesPhotos(t *testing.T) {
+	app := newTestApp(t)
+	admin := app.token(t, 1, models.UserRoleAdmin)
+	ctx := context.Background()
+
+	blobExists := func(key string) bool {
+		blob, err := app.blobs.Open(ctx, key)
+		if err != nil {
+			return false
+		}
+		blob.Close()
+		return true
+	}
+	keys := func(photo models.PetPhoto) []string {
+		keys := []string{storage.ContentKey(photo.Checksum)}
+		for _, variant := range photo.Variants {
+			keys = append(keys, storage.ContentKey(variant.Checksum))
+		}
+		return keys
+	}
+
+	// общее фото есть и у другого питомца, свое - только у Rex
+	shared := testPNG(t, 100, 100)
+	rex, tom := app.addPet(t, "Rex"), app.addPet(t, "Tom")
+	for _, petID := range []int{rex, tom} {
+		assert.Equal(t, http.StatusOK, app.uploadPhoto(t, admin, petID, "shared.png", shared).Code)
+	}
+	assert.Equal(t, http.StatusOK, app.uploadPhoto(t, admin, rex, "own.png", testPNG(t, 90, 90)).Code)
+
+	photos := app.petPhotos(t, admin, rex)
+	if !assert.Len(t, photos, 2) {
+		return
+	}
+	sharedPhoto, ownPhoto := photos[0], photos[1]
+	assert.Len(t, ownPhoto.Variants, 1)
+
+	w := app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/pet/%d", rex), nil), admin)
+	var pet models.Pet
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &pet))
+	pet.PhotoUrls = []string{sharedPhoto.URL}
+
+	body, err := json.Marshal(pet)
+	assert.NoError(t, err)
+	w = app.do(t, httptest.NewRequest(http.MethodPut, "/v2/pet", bytes.NewReader(body)), admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	photos = app.petPhotos(t, admin, rex)
+	if assert.Len(t, photos, 1) {
+		assert.Equal(t, sharedPhoto.ID, photos[0].ID)
+	}
+	for _, key := range keys(ownPhoto) {
+		assert.False(t, blobExists(key), key)
+	}
+	for _, key := range keys(sharedPhoto) {
+		assert.True(t, blobExists(key), key)
+	}
+
+	// общее фото убрано у Rex, но еще нужно Tom
+	pet.PhotoUrls = nil
+	pet.Version = 0
+	body, err = json.Marshal(pet)
+	assert.NoError(t, err)
+	w = app.do(t, httptest.NewRequest(http.MethodPut, "/v2/pet", bytes.NewReader(body)), admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Empty(t, app.petPhotos(t, admin, rex))
+	assert.Len(t, app.petPhotos(t, admin, tom), 1)
+	for _, key := range keys(sharedPhoto) {
+		assert.True(t, blobExists(key), key)
+	}
+
+	var orphans int
+	assert.NoError(t, app.db.DB.QueryRow("SELECT COUNT(*) FROM pet_photo_variants WHERE photo_id NOT IN (SELECT id FROM pet_photos)").Scan(&orphans))
+	assert.Zero(t, orphans)
+}
+
+// TestPetIfMatch: ETag питомца - его версия; изменение с If-Match проходит, только
+// пока версия не изменилась (иначе 412), без If-Match и с "*" - без проверки.
+func TestPetIfMatch(t *testing.T) {
+	app := newTestApp(t)
+	token := app.token(t, 1, "")
+	petID := app.addPet(t, "Rex")
+	if _, err := app.db.DB.Exec("INSERT INTO tag_pets (tag_id, pet_id) VALUES (1, ?)", petID); err != nil {
+		t.Fatal(err)
+	}
+	url := fmt.Sprintf("/v2/pet/%d", petID)
+
+	get := func() (models.Pet, string) {
+		w := app.do(t, httptest.NewRequest(http.MethodGet, url, nil), token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("get pet: %d %s", w.Code, w.Body)
+		}
+
+		var pet models.Pet
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &pet))
+		return pet, w.Header().Get("ETag")
+	}
+	put := func(pet models.Pet, ifMatch string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(pet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPut, "/v2/pet", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		return app.do(t, req, token)
+	}
+	form := func(name string, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader("name="+name))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		return app.do(t, req, token)
+	}
+
+	pet, etag := get()
+	assert.Equal(t, `"1"`, etag)
+	assert.Equal(t, 1, pet.Version)
+
+	pet.Name = "Max"
+	w := put(pet, etag)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	// второй клиент с той же устаревшей версией ничего не затирает
+	pet.Name = "Tom"
+	assert.Equal(t, http.StatusPreconditionFailed, put(pet, etag).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, form("Tom", etag).Code)
+
+	pet, etag = get()
+	assert.Equal(t, "Max", pet.Name)
+	assert.Equal(t, `"2"`, etag)
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		code    int
+	}{
+		{name: "current", ifMatch: `"2"`, code: http.StatusOK},
+		{name: "weak", ifMatch: `W/"3"`, code: http.StatusOK},
+		{name: "any", ifMatch: "*", code: http.StatusOK},
+		{name: "none", code: http.StatusOK},
+		{name: "stale", ifMatch: `"2"`, code: http.StatusPreconditionFailed},
+		{name: "future", ifMatch: `"100"`, code: http.StatusPreconditionFailed},
+		{name: "not a version", ifMatch: `"abc"`, code: http.StatusPreconditionFailed},
+		{name: "zero", ifMatch: `"0"`, code: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, _ := get()
+
+			w := form(tt.name, tt.ifMatch)
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+
+			after, etag := get()
+			assert.Equal(t, fmt.Sprintf(`"%d"`, after.Version), etag)
+
+			if tt.code == http.StatusOK {
+				assert.Equal(t, tt.name, after.Name)
+				assert.Equal(t, before.Version+1, after.Version)
+			} else {
+				assert.Equal(t, before, after)
+			}
+		})
+	}
+}
+
+// TestPetProfile: поля профиля сохраняются и читаются как есть, пустые поля не
+// проверяются, а недопустимые значения отклоняются и при создании, и при изменении.
+func TestPetProfile(t *testing.T) {
+	bd := newTestDataBase(t)
+	service := newTestPetService(t, petRepository.NewPetRepository(bd.DB))
+	ctx := context.Background()
+
+	weight, price := 12.5, int64(49900)
+	pet, err := service.AddPet(ctx, models.Pet{
+		Name:        "Daisy",
+		Status:      models.PetStatusAvailable,
+		Category:    models.Category{ID: 1},
+		Breed:       "beagle",
+		BirthDate:   "2021-05-14",
+		Sex:         models.PetSexFemale,
+		Weight:      &weight,
+		Description: "Loves long walks",
+		Price:       &price,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := service.GetPetById(ctx, pet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "beagle", stored.Breed)
+	assert.Equal(t, "2021-05-14", stored.BirthDate)
+	assert.Equal(t, models.PetSexFemale, stored.Sex)
+	assert.Equal(t, &weight, stored.Weight)
+	assert.Equal(t, "Loves long walks", stored.Description)
+	assert.Equal(t, &price, stored.Price)
+
+	empty, err := service.AddPet(ctx, models.Pet{Name: "Rex", Status: models.PetStatusAvailable, Category: models.Category{ID: 1}})
+	assert.NoError(t, err)
+	empty, err = service.GetPetById(ctx, empty.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, empty.Breed)
+	assert.Nil(t, empty.Weight)
+	assert.Nil(t, empty.Price)
+
+	zero, negative, free := 0.0, int64(-1), int64(0)
+	tests := []struct {
+		name    string
+		profile func(pet *models.Pet)
+		wantErr bool
+	}{
+		{name: "long breed", profile: func(pet *models.Pet) { pet.Breed = strings.Repeat("b", 101) }, wantErr: true},
+		{name: "breed of 100 letters", profile: func(pet *models.Pet) { pet.Breed = strings.Repeat("б", 100) }},
+		{name: "long description", profile: func(pet *models.Pet) { pet.Description = strings.Repeat("d", 4001) }, wantErr: true},
+		{name: "unknown sex", profile: func(pet *models.Pet) { pet.Sex = "unknown" }, wantErr: true},
+		{name: "birth date with time", profile: func(pet *models.Pet) { pet.BirthDate = "2021-05-14T10:00:00Z" }, wantErr: true},
+		{name: "birth date in the future", profile: func(pet *models.Pet) { pet.BirthDate = time.Now().AddDate(0, 0, 2).Format("2006-01-02") }, wantErr: true},
+		{name: "zero weight", profile: func(pet *models.Pet) { pet.Weight = &zero }, wantErr: true},
+		{name: "negative price", profile: func(pet *models.Pet) { pet.Price = &negative }, wantErr: true},
+		{name: "free", profile: func(pet *models.Pet) { pet.Price = &free }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pet := models.Pet{Name: "Tom", Status: models.PetStatusAvailable, Category: models.Category{ID: 1}}
+			tt.profile(&pet)
+
+			_, err := service.AddPet(ctx, pet)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			before, err := service.GetPetById(ctx, stored.ID)
+			assert.NoError(t, err)
+			update := before
+			tt.profile(&update)
+			update.Version = 0
+
+			_, err = service.UpdatePet(ctx, update)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+
+			after, err := service.GetPetById(ctx, stored.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, before, after)
+		})
+	}
+}
+
+// TestMedicalRecords: записи медицинской карты проверяются и принадлежат своему
+// питомцу; просроченной считается только последняя прививка с каждым названием,
+// удаленные питомцы в выборку не попадают, а их карту нельзя изменить.
+func TestMedicalRecords(t *testing.T) {
+	app := newTestApp(t)
+	token := app.userToken(t, 1, "anna", "")
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		return app.do(t, httptest.NewRequest(method, url, strings.NewReader(body)), token)
+	}
+	add := func(petID int, body string) models.PetMedicalRecord {
+		t.Helper()
+		w := send(http.MethodPost, fmt.Sprintf("/v2/pet/%d/medical", petID), body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("add record: %d %s", w.Code, w.Body)
+		}
+
+		var record models.PetMedicalRecord
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &record))
+		return record
+	}
+
+	rex, tom, old := app.addPet(t, "Rex"), app.addPet(t, "Tom"), app.addPet(t, "Old")
+
+	rabies := add(rex, `{"kind":"vaccination","title":"Rabies","date":"2024-01-10","dueDate":"2024-07-10"}`)
+	assert.Equal(t, rex, rabies.PetID)
+	assert.Equal(t, "anna", rabies.CreatedBy)
+	assert.False(t, rabies.CreatedAt.IsZero())
+
+	invalid := []string{
+		`{"kind":"surgery","title":"Spay","date":"2024-01-10"}`,
+		`{"kind":"vetVisit","date":"2024-01-10"}`,
+		`{"kind":"vetVisit","title":"Checkup","date":"2024-01-10","dueDate":"2024-07-10"}`,
+		`{"kind":"vaccination","title":"Rabies","date":"2024-01-10","dueDate":"2024-01-10"}`,
+		`{"kind":"vaccination","title":"Rabies","date":"10.01.2024"}`,
+		fmt.Sprintf(`{"kind":"treatment","title":"Pills","date":%q}`, time.Now().AddDate(0, 0, 2).Format("2006-01-02")),
+	}
+	for _, body := range invalid {
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, fmt.Sprintf("/v2/pet/%d/medical", rex), body).Code, body)
+	}
+
+	url := fmt.Sprintf("/v2/pet/%d/medical/%d", rex, rabies.ID)
+	w := send(http.MethodPut, url, `{"kind":"vaccination","title":"Rabies","notes":"Nobivac","date":"2024-01-10","dueDate":"2024-07-10"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rabies))
+	assert.Equal(t, "Nobivac", rabies.Notes)
+
+	// запись другого питомца по его пути не найти
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, fmt.Sprintf("/v2/pet/%d/medical/%d", tom, rabies.ID), "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, fmt.Sprintf("/v2/pet/%d/medical/%d", tom, rabies.ID), "").Code)
+
+	// повторная прививка закрывает старую, другая прививка просрочена
+	add(tom, `{"kind":"vaccination","title":"Rabies","date":"2023-01-01","dueDate":"2024-01-01"}`)
+	add(tom, `{"kind":"vaccination","title":"Rabies","date":"2024-01-05","dueDate":"2025-01-05"}`)
+	distemper := add(tom, `{"kind":"vaccination","title":"Distemper","date":"2023-06-01","dueDate":"2024-06-01"}`)
+	add(tom, `{"kind":"vetVisit","title":"Checkup","date":"2023-06-01"}`)
+	oldRecord := add(old, `{"kind":"vaccination","title":"Rabies","date":"2023-01-01","dueDate":"2024-01-01"}`)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, fmt.Sprintf("/v2/pet/%d", old), "").Code)
+
+	var records []models.PetMedicalRecord
+	assert.NoError(t, json.Unmarshal(send(http.MethodGet, fmt.Sprintf("/v2/pet/%d/medical", tom), "").Body.Bytes(), &records))
+	assert.Len(t, records, 4)
+
+	w = send(http.MethodGet, "/v2/pet/medical/overdue?date=2024-08-01", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var overdue []models.OverdueVaccination
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &overdue))
+	if assert.Len(t, overdue, 2) {
+		assert.Equal(t, models.OverdueVaccination{PetID: tom, PetName: "Tom", Record: distemper, DaysOverdue: 61}, overdue[0])
+		assert.Equal(t, rabies.ID, overdue[1].Record.ID)
+		assert.Equal(t, 22, overdue[1].DaysOverdue)
+	}
+
+	assert.NoError(t, json.Unmarshal(send(http.MethodGet, "/v2/pet/medical/overdue?date=2024-06-01", "").Body.Bytes(), &overdue))
+	assert.Empty(t, overdue)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/v2/pet/medical/overdue?date=soon", "").Code)
+
+	// карта удаленного питомца только для чтения
+	w = send(http.MethodPost, fmt.Sprintf("/v2/pet/%d/medical", old), `{"kind":"vetVisit","title":"Checkup","date":"2024-01-10"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	oldURL := fmt.Sprintf("/v2/pet/%d/medical/%d", old, oldRecord.ID)
+	w = send(http.MethodPut, oldURL, `{"kind":"vaccination","title":"Rabies","date":"2023-01-01","dueDate":"2025-01-01"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, http.StatusConflict, send(http.MethodDelete, oldURL, "").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, oldURL, "").Code)
+
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, url, "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, url, "").Code)
+}
+
+// TestAdoptionReview: одобрение заявки создает заказ заявителя, бронирует питомца и
+// отклоняет остальные заявки на него; отклонение закрывает только саму заявку.
+// Рассматривает заявки только администратор, закрытую заявку рассмотреть нельзя.
+func TestAdoptionReview(t *testing.T) {
+	app := newTestApp(t)
+	admin := app.userToken(t, 1, "admin", models.UserRoleAdmin)
+
+	applicant := func(name string) (int, string) {
+		id := app.addUser(t, name)
+		return id, app.userToken(t, id, name, "")
+	}
+	annaID, anna := applicant("anna")
+	_, bob := applicant("bob")
+	_, carl := applicant("carl")
+
+	apply := func(token string, petID int) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"petId":%d,"message":"We have a big garden"}`, petID)
+		return app.do(t, httptest.NewRequest(http.MethodPost, "/v2/adoption", strings.NewReader(body)), token)
+	}
+	review := func(token string, id int, decision string, notes string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"notes":%q}`, notes)
+		return app.do(t, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v2/adoption/%d/%s", id, decision), strings.NewReader(body)), token)
+	}
+	decode := func(w *httptest.ResponseRecorder) models.AdoptionApplication {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("%d %s", w.Code, w.Body)
+		}
+
+		var application models.AdoptionApplication
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &application))
+		return application
+	}
+	get := func(id int) models.AdoptionApplication {
+		return decode(app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/adoption/%d", id), nil), admin))
+	}
+	petStatus := func(id int) string {
+		var status string
+		assert.NoError(t, app.db.DB.QueryRow("SELECT status FROM pets WHERE id = ?", id).Scan(&status))
+		return status
+	}
+
+	petID := app.addPet(t, "Rex")
+
+	annaApp := decode(apply(anna, petID))
+	assert.Equal(t, models.AdoptionStatusSubmitted, annaApp.Status)
+	assert.Equal(t, "anna", annaApp.UserName)
+	bobApp := decode(apply(bob, petID))
+	carlApp := decode(apply(carl, petID))
+
+	assert.Equal(t, http.StatusConflict, apply(anna, petID).Code, "second submitted application")
+
+	// заявитель не может рассмотреть заявку, даже свою
+	assert.Equal(t, http.StatusForbidden, review(anna, annaApp.ID, "approve", "").Code)
+	assert.Equal(t, http.StatusForbidden, review(bob, annaApp.ID, "reject", "").Code)
+	assert.Equal(t, models.AdoptionStatusSubmitted, get(annaApp.ID).Status)
+
+	rejected := decode(review(admin, bobApp.ID, "reject", "No garden"))
+	assert.Equal(t, models.AdoptionStatusRejected, rejected.Status)
+	assert.Equal(t, "No garden", rejected.ReviewNotes)
+	assert.Equal(t, "admin", rejected.ReviewedBy)
+	assert.NotNil(t, rejected.ReviewedAt)
+	assert.Zero(t, rejected.OrderID)
+	assert.Equal(t, models.PetStatusAvailable, petStatus(petID))
+	assert.Equal(t, http.StatusConflict, review(admin, bobApp.ID, "approve", "").Code)
+
+	approved := decode(review(admin, annaApp.ID, "approve", "Home visit passed"))
+	assert.Equal(t, models.AdoptionStatusApproved, approved.Status)
+	assert.Equal(t, "Home visit passed", approved.ReviewNotes)
+	assert.NotZero(t, approved.OrderID)
+	assert.Equal(t, approved, get(annaApp.ID))
+	assert.Equal(t, models.PetStatusPending, petStatus(petID))
+
+	var order models.Order
+	assert.NoError(t, json.Unmarshal(app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/store/order/%d", approved.OrderID), nil), anna).Body.Bytes(), &order))
+	assert.Equal(t, annaID, order.UserID)
+	assert.Equal(t, petID, order.PetID)
+	assert.Equal(t, models.OrderStatusPlaced, order.Status)
+	assert.NotEmpty(t, order.ShipDate)
+
+	// остальные заявки на питомца закрыты, повторно рассмотреть нельзя
+	closed := get(carlApp.ID)
+	assert.Equal(t, models.AdoptionStatusRejected, closed.Status)
+	assert.NotEmpty(t, closed.ReviewNotes)
+	assert.Equal(t, http.StatusConflict, review(admin, carlApp.ID, "approve", "").Code)
+	assert.Equal(t, http.StatusConflict, review(admin, annaApp.ID, "approve", "").Code)
+	assert.Equal(t, http.StatusConflict, review(admin, annaApp.ID, "reject", "").Code)
+
+	assert.Equal(t, http.StatusConflict, apply(bob, petID).Code, "pet is reserved")
+	assert.Equal(t, http.StatusNotFound, review(admin, 9999, "approve", "").Code)
+
+	// питомца продали в обход заявки: одобрить нельзя, заявка остается открытой
+	soldID := app.addPet(t, "Tom")
+	bobApp = decode(apply(bob, soldID))
+	if _, err := app.db.DB.Exec("UPDATE pets SET status = 'sold' WHERE id = ?", soldID); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusConflict, review(admin, bobApp.ID, "approve", "").Code)
+	assert.Equal(t, models.AdoptionStatusSubmitted, get(bobApp.ID).Status)
+	assert.Equal(t, models.AdoptionStatusRejected, decode(review(admin, bobApp.ID, "reject", "Sold")).Status)
+}
+
+// failingRejectAdoptionRepository не может отклонить остальные заявки на питомца.
+type failingRejectAdoptionRepository struct {
+	adoptionRepository.AdoptionRepositoryer
+}
+
+func (r failingRejectAdoptionRepository) RejectPetApplications(ctx context.Context, petID int, notes string, reviewedBy string) error {
+	return errors.New("applications are unavailable")
+}
+
+// TestApproveAdoptionInTransaction: заказ, решение по заявке и отклонение остальных
+// заявок фиксируются вместе - если что-то не удалось, заказа нет, питомец в продаже,
+// а заявки остаются открытыми.
+func TestApproveAdoptionInTransaction(t *testing.T) {
+	app := newTestApp(t)
+	annaID := app.addUser(t, "anna")
+	petID := app.addPet(t, "Rex")
+
+	repos := modules.NewRepository(app.db.DB)
+	pets := petService.NewPetService(repos.Pet, app.blobs, nil, app.notifier)
+	adoption := adoptionService.NewAdoptionService(
+		failingRejectAdoptionRepository{AdoptionRepositoryer: repos.Adoption},
+		pets,
+		storeService.NewStoreService(repos.Store, pets),
+		userService.NewUserService(repos.User),
+	)
+
+	ctx := jwtContext(t, map[string]interface{}{"sub": fmt.Sprint(annaID), "username": "anna"})
+	application, err := adoption.Apply(ctx, models.AdoptionApplication{PetID: petID})
+	assert.NoError(t, err)
+
+	_, err = adoption.Approve(adminContext(t, 1), application.ID, models.AdoptionReview{})
+	assert.Error(t, err)
+
+	var orders int
+	assert.NoError(t, app.db.DB.QueryRow("SELECT COUNT(*) FROM orders WHERE pet_id = ?", petID).Scan(&orders))
+	assert.Zero(t, orders)
+
+	pet, err := repos.Pet.GetPetById(ctx, petID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PetStatusAvailable, pet.Status)
+
+	application, err = repos.Adoption.GetApplicationById(ctx, application.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AdoptionStatusSubmitted, application.Status)
+	assert.Zero(t, application.OrderID)
+}
+
+// TestPetWaitlist: в лист ожидания записываются только на забронированного питомца;
+// когда он возвращается в продажу, каждый ожидающий получает одно уведомление, а
+// повторная запись снова ждет уведомления.
+func TestPetWaitlist(t *testing.T) {
+	app := newTestApp(t)
+	admin := app.userToken(t, 1, "admin", models.UserRoleAdmin)
+
+	user := func(name string) string {
+		id := app.addUser(t, name)
+		if _, err := app.db.DB.Exec("UPDATE users SET email = ? WHERE id = ?", name+"@example.com", id); err != nil {
+			t.Fatal(err)
+		}
+		return app.userToken(t, id, name, "")
+	}
+	anna, bob := user("anna"), user("bob")
+
+	petID := app.addPet(t, "Rex")
+	url := fmt.Sprintf("/v2/pet/%d", petID)
+	send := func(token, method, url string) *httptest.ResponseRecorder {
+		return app.do(t, httptest.NewRequest(method, url, nil), token)
+	}
+	setStatus := func(status string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader("status="+status))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if w := app.do(t, req, admin); w.Code != http.StatusOK {
+			t.Fatalf("set status %s: %d %s", status, w.Code, w.Body)
+		}
+	}
+	waitlist := func(token string) []models.PetWaitlistEntry {
+		var entries []models.PetWaitlistEntry
+		assert.NoError(t, json.Unmarshal(send(token, http.MethodGet, url+"/waitlist").Body.Bytes(), &entries))
+		return entries
+	}
+	notified := func() []notify.Message {
+		app.notifier.mu.Lock()
+		defer app.notifier.mu.Unlock()
+
+		messages := app.notifier.messages
+		app.notifier.messages = nil
+		return messages
+	}
+
+	assert.Equal(t, http.StatusConflict, send(anna, http.MethodPost, url+"/waitlist").Code, "pet is available")
+
+	setStatus(models.PetStatusPending)
+	assert.Equal(t, http.StatusOK, send(anna, http.MethodPost, url+"/waitlist").Code)
+	assert.Equal(t, http.StatusOK, send(bob, http.MethodPost, url+"/waitlist").Code)
+	assert.Equal(t, http.StatusOK, send(anna, http.MethodPost, url+"/waitlist").Code, "joining twice")
+	assert.Equal(t, http.StatusForbidden, send(app.token(t, 9, ""), http.MethodPost, url+"/waitlist").Code, "no username")
+
+	if entries := waitlist(anna); assert.Len(t, entries, 1) {
+		assert.Equal(t, "anna", entries[0].UserName)
+	}
+	assert.Len(t, waitlist(admin), 2)
+
+	assert.Equal(t, http.StatusOK, send(bob, http.MethodDelete, url+"/waitlist").Code)
+	assert.Equal(t, http.StatusNotFound, send(bob, http.MethodDelete, url+"/waitlist").Code)
+
+	setStatus(models.PetStatusAvailable)
+	messages := notified()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, notify.EventPetAvailable, messages[0].Event)
+		assert.Equal(t, "anna", messages[0].UserName)
+		assert.Equal(t, "anna@example.com", messages[0].Email)
+		assert.Contains(t, messages[0].Subject, "Rex")
+	}
+
+	// уведомленный пользователь не получает второе уведомление, пока не запишется снова
+	setStatus(models.PetStatusPending)
+	setStatus(models.PetStatusAvailable)
+	assert.Empty(t, notified())
+
+	setStatus(models.PetStatusPending)
+	assert.Equal(t, http.StatusOK, send(anna, http.MethodPost, url+"/waitlist").Code)
+	setStatus(models.PetStatusSold)
+	assert.Empty(t, notified())
+}
+
+type recordSink struct {
+	name string
+	err  error
+
+	mu       sync.Mutex
+	messages []notify.Message
+}
+
+func (s *recordSink) Name() string {
+	return s.name
+}
+
+func (s *recordSink) Send(ctx context.Context, msg notify.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, msg)
+	return s.err
+}
+
+// TestNotifyDispatcher: каждое уведомление доставляется во все каналы, ошибка одного
+// канала не мешает остальным, Close дожидается отправки очереди.
+func TestNotifyDispatcher(t *testing.T) {
+	failing := &recordSink{name: "failing", err: errors.New("unavailable")}
+	working := &recordSink{name: "working"}
+	dispatcher := notify.NewDispatcher([]notify.Sink{failing, working}, 10)
+
+	for _, userName := range []string{"anna", "bob", "carl"} {
+		dispatcher.Notify(notify.Message{Event: notify.EventPetAvailable, UserName: userName})
+	}
+	dispatcher.Close()
+	dispatcher.Close()
+
+	for _, sink := range []*recordSink{failing, working} {
+		if assert.Len(t, sink.messages, 3, sink.name) {
+			assert.Equal(t, "anna", sink.messages[0].UserName)
+			assert.Equal(t, "carl", sink.messages[2].UserName)
+		}
+	}
+
+	_, err := notify.NewSinks(notify.Config{Sinks: "log,webhook"})
+	assert.Error(t, err, "webhook without URL")
+	_, err = notify.NewSinks(notify.Config{Sinks: "pigeon"})
+	assert.Error(t, err)
+
+	sinks, err := notify.NewSinks(notify.Config{})
+	assert.NoError(t, err)
+	if assert.Len(t, sinks, 1) {
+		assert.Equal(t, "log", sinks[0].Name())
+	}
+}
+
+// TestOrderStatusTransitions: заказ проходит жизненный цикл только по разрешенным
+// переходам (иначе 409), а одобрение, отправку и доставку выполняет только
+// администратор (иначе 403).
+func TestOrderStatusTransitions(t *testing.T) {
+	app := newTestApp(t)
+	admin := app.token(t, 1, models.UserRoleAdmin)
+	user := app.token(t, app.addUser(t, "buyer"), "")
+
+	action := map[string]string{
+		models.OrderStatusApproved:  "approve",
+		models.OrderStatusShipped:   "ship",
+		models.OrderStatusDelivered: "deliver",
+		models.OrderStatusCancelled: "cancel",
+	}
+
+	tests := []struct {
+		name string
+		// path - разрешенные переходы администратора до проверяемого
+		path      []string
+		to        string
+		byUser    bool
+		code      int
+		petStatus string
+	}{
+		{name: "approve placed", to: models.OrderStatusApproved, code: http.StatusOK, petStatus: models.PetStatusPending},
+		{name: "ship approved", path: []string{models.OrderStatusApproved}, to: models.OrderStatusShipped, code: http.StatusOK, petStatus: models.PetStatusPending},
+		{name: "deliver shipped", path: []string{models.OrderStatusApproved, models.OrderStatusShipped}, to: models.OrderStatusDelivered, code: http.StatusOK, petStatus: models.PetStatusSold},
+		{name: "user cancels placed", to: models.OrderStatusCancelled, byUser: true, code: http.StatusOK, petStatus: models.PetStatusAvailable},
+		{name: "cancel approved", path: []string{models.OrderStatusApproved}, to: models.OrderStatusCancelled, code: http.StatusOK, petStatus: models.PetStatusAvailable},
+		{name: "ship placed", to: models.OrderStatusShipped, code: http.StatusConflict, petStatus: models.PetStatusPending},
+		{name: "deliver approved", path: []string{models.OrderStatusApproved}, to: models.OrderStatusDelivered, code: http.StatusConflict, petStatus: models.PetStatusPending},
+		{name: "approve twice", path: []string{models.OrderStatusApproved}, to: models.OrderStatusApproved, code: http.StatusConflict, petStatus: models.PetStatusPending},
+		{name: "cancel shipped", path: []string{models.OrderStatusApproved, models.OrderStatusShipped}, to: models.OrderStatusCancelled, code: http.StatusConflict, petStatus: models.PetStatusPending},
+		{name: "cancel delivered", path: []string{models.OrderStatusApproved, models.OrderStatusShipped, models.OrderStatusDelivered}, to: models.OrderStatusCancelled, code: http.StatusConflict, petStatus: models.PetStatusSold},
+		{name: "approve cancelled", path: []string{models.OrderStatusCancelled}, to: models.OrderStatusApproved, code: http.StatusConflict, petStatus: models.PetStatusAvailable},
+		{name: "user approves", to: models.OrderStatusApproved, byUser: true, code: http.StatusForbidden, petStatus: models.PetStatusPending},
+		{name: "user ships", path: []string{models.OrderStatusApproved}, to: models.OrderStatusShipped, byUser: true, code: http.StatusForbidden, petStatus: models.PetStatusPending},
+		{name: "user delivers", path: []string{models.OrderStatusApproved, models.OrderStatusShipped}, to: models.OrderStatusDelivered, byUser: true, code: http.StatusForbidden, petStatus: models.PetStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			petID := app.addPet(t, tt.name)
+
+			body := fmt.Sprintf(`{"petId":%d,"quantity":1,"shipDate":"2024-01-01"}`, petID)
+			w := app.do(t, httptest.NewRequest(http.MethodPost, "/v2/store/order", strings.NewReader(body)), user)
+			if w.Code != http.StatusOK {
+				t.Fatalf("place order: %d %s", w.Code, w.Body)
+			}
+
+			var order models.Order
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+			assert.Equal(t, models.OrderStatusPlaced, order.Status)
+
+			transition := func(to string, token string) *httptest.ResponseRecorder {
+				url := fmt.Sprintf("/v2/store/order/%d/%s", order.ID, action[to])
+				return app.do(t, httptest.NewRequest(http.MethodPost, url, nil), token)
+			}
+
+			want := models.OrderStatusPlaced
+			for _, to := range tt.path {
+				if w := transition(to, admin); w.Code != http.StatusOK {
+					t.Fatalf("%s: %d %s", to, w.Code, w.Body)
+				}
+				want = to
+			}
+
+			token := admin
+			if tt.byUser {
+				token = user
+			}
+
+			w = transition(tt.to, token)
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+
+			if tt.code == http.StatusOK {
+				want = tt.to
+			}
+
+			w = app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/store/order/%d", order.ID), nil), user)
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+			assert.Equal(t, want, order.Status)
+			assert.Equal(t, want == models.OrderStatusDelivered, order.Complete)
+
+			var petStatus string
+			assert.NoError(t, app.db.DB.QueryRow("SELECT status FROM pets WHERE id = ?", petID).Scan(&petStatus))
+			assert.Equal(t, tt.petStatus, petStatus)
+		})
+	}
+
+	// чужой заказ отменить нельзя
+	other := app.token(t, app.addUser(t, "stranger"), "")
+	petID := app.addPet(t, "Rex")
+	w := app.do(t, httptest.NewRequest(http.MethodPost, "/v2/store/order", strings.NewReader(fmt.Sprintf(`{"petId":%d,"shipDate":"2024-01-01"}`, petID))), user)
+	var order models.Order
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+
+	w = app.do(t, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v2/store/order/%d/cancel", order.ID), nil), other)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestMyOrders: пользователь видит в списке только свои заказы с фильтрами по статусу
+// и дате отправки, страницы связаны заголовком Link, а администратор видит заказы всех.
+func TestMyOrders(t *testing.T) {
+	app := newTestApp(t)
+	admin := app.token(t, 1, models.UserRoleAdmin)
+	aliceID := app.addUser(t, "alice")
+	alice := app.token(t, aliceID, "")
+	bobID := app.addUser(t, "bob")
+	bob := app.token(t, bobID, "")
+
+	place := func(token string, name string, shipDate string) models.Order {
+		t.Helper()
+
+		body := fmt.Sprintf(`{"petId":%d,"quantity":1,"shipDate":%q}`, app.addPet(t, name), shipDate)
+		w := app.do(t, httptest.NewRequest(http.MethodPost, "/v2/store/order", strings.NewReader(body)), token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("place order: %d %s", w.Code, w.Body)
+		}
+
+		var order models.Order
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+
+		return order
+	}
+
+	first := place(alice, "Bella", "2024-01-01")
+	second := place(alice, "Max", "2024-02-01")
+	third := place(alice, "Luna", "2024-03-01")
+	bobs := place(bob, "Rocky", "2024-02-15")
+
+	w := app.do(t, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v2/store/order/%d/cancel", second.ID), nil), alice)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	list := func(token string, url string) (models.OrderPage, *httptest.ResponseRecorder) {
+		t.Helper()
+
+		w := app.do(t, httptest.NewRequest(http.MethodGet, url, nil), token)
+
+		var page models.OrderPage
+		if w.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		}
+
+		return page, w
+	}
+
+	ids := func(page models.OrderPage) []int {
+		var ids []int
+		for _, order := range page.Items {
+			ids = append(ids, order.ID)
+		}
+
+		return ids
+	}
+
+	nextLink := regexp.MustCompile(`<([^>]+)>; rel="next"`)
+
+	t.Run("pages", func(t *testing.T) {
+		page, w := list(alice, "/v2/store/orders?limit=2")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int{third.ID, second.ID}, ids(page))
+		assert.True(t, page.Pagination.HasMore)
+		for _, order := range page.Items {
+			assert.Equal(t, aliceID, order.UserID)
+		}
+
+		link := w.Header().Get("Link")
+		assert.Contains(t, link, `</v2/store/orders?limit=2>; rel="first"`)
+
+		next := nextLink.FindStringSubmatch(link)
+		if next == nil {
+			t.Fatalf("no next link in %q", link)
+		}
+
+		page, w = list(alice, next[1])
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int{first.ID}, ids(page))
+		assert.False(t, page.Pagination.HasMore)
+		assert.NotRegexp(t, nextLink, w.Header().Get("Link"))
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		page, w := list(alice, "/v2/store/orders?status=cancelled")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int{second.ID}, ids(page))
+
+		page, w = list(alice, "/v2/store/orders?status=placed,cancelled&shipFrom=2024-02-01")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int{third.ID, second.ID}, ids(page))
+
+		page, w = list(alice, "/v2/store/orders?shipFrom=2024-01-15&shipTo=2024-02-01")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int{second.ID}, ids(page))
+
+		_, w = list(alice, "/v2/store/orders?status=lost")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		_, w = list(alice, "/v2/store/orders?shipFrom=01.02.2024")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("ownership", func(t *testing.T) {
+		page, w := list(bob, "/v2/store/orders")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int{bobs.ID}, ids(page))
+
+		_, w = list(alice, fmt.Sprintf("/v2/store/orders?userId=%d", bobID))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/store/order/%d", bobs.ID), nil), alice)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		// в сиде есть и свои заказы, поэтому смотрим только первую страницу
+		page, w := list(admin, "/v2/store/orders?limit=4")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.True(t, page.Pagination.HasMore)
+		assert.Equal(t, []int{bobs.ID, third.ID, second.ID, first.ID}, ids(page))
+
+		page, w = list(admin, fmt.Sprintf("/v2/store/orders?userId=%d", bobID))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int{bobs.ID}, ids(page))
+
+		w = app.do(t, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/store/order/%d", bobs.ID), nil), admin)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+}
@@ -0,0 +1,78 @@
+//go:build sqlite_fts5
+
+// Поиск работает только в сборке с FTS5: go test -tags sqlite_fts5 ./...
+
+package main
+
+import (
+	"app/internal/models"
+	petRepository "app/internal/modules/pet/repository"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSearchPets: слова ищутся как префиксы в имени, категории и тегах; питомцы с
+// большим числом совпадений и совпадениями в имени выше, совпадения подсвечены, а
+// индекс следует за изменениями питомцев и тегов.
+func TestSearchPets(t *testing.T) {
+	bd := newTestDataBase(t)
+	service := newTestPetService(t, petRepository.NewPetRepository(bd.DB))
+	ctx := context.Background()
+
+	enabled, err := bd.FTS5Enabled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled {
+		t.Fatal("sqlite3 is built with -tags sqlite_fts5, but FTS5 is not available")
+	}
+
+	exec := func(query string, args ...interface{}) {
+		if _, err := bd.DB.Exec(query, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exec("INSERT INTO categories (id, name) VALUES (60, 'Retriever')")
+	exec("INSERT INTO tags (id, name) VALUES (60, 'puppy')")
+	exec("INSERT INTO pets (id, category_id, name, status) VALUES (600, 60, 'Golden Boy', 'available'), (601, 1, 'Goldie', 'available'), (602, 1, 'Rex', 'sold'), (603, 60, 'Gold Old', 'deleted')")
+	exec("INSERT INTO tag_pets (tag_id, pet_id) VALUES (60, 600), (60, 602), (60, 603)")
+
+	search := func(text string) []models.PetSearchResult {
+		t.Helper()
+		results, err := service.SearchPets(ctx, text, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+	names := func(results []models.PetSearchResult) []string {
+		names := []string{}
+		for _, result := range results {
+			names = append(names, result.Pet.Name)
+		}
+		return names
+	}
+
+	results := search("gold puppy")
+	assert.Equal(t, []string{"Golden Boy", "Goldie", "Rex"}, names(results))
+	assert.Equal(t, "<mark>Golden</mark> Boy", results[0].Highlight)
+	assert.Equal(t, "Retriever", results[0].Pet.Category.Name)
+	assert.Contains(t, results[2].Snippet, "<mark>puppy</mark>")
+	assert.LessOrEqual(t, results[0].Rank, results[1].Rank)
+
+	assert.Equal(t, []string{"Golden Boy"}, names(search("RETRIEV")))
+	assert.Equal(t, []string{"Golden Boy"}, names(search(`golden" OR NEAR(*`)), "FTS5 syntax is ignored")
+	assert.Empty(t, search("nothing"))
+
+	_, err = service.SearchPets(ctx, "* - ()", 0)
+	assert.Error(t, err)
+
+	// индекс обновляется триггерами
+	exec("UPDATE pets SET name = 'Goldfish' WHERE id = 602")
+	exec("UPDATE tags SET name = 'kitten' WHERE id = 60")
+	assert.Equal(t, []string{"Goldfish"}, names(search("goldf")))
+	assert.ElementsMatch(t, []string{"Golden Boy", "Goldfish"}, names(search("kitten")))
+	assert.Empty(t, search("puppy"))
+}
@@ -12,7 +12,11 @@ import (
 	"time"
 
 	_ "app/docs"
+	"app/internal/infrastructure/idempotency"
+	"app/internal/infrastructure/imaging"
+	"app/internal/infrastructure/notify"
 	"app/internal/infrastructure/responder"
+	"app/internal/infrastructure/storage"
 	"app/internal/modules"
 
 	sq "github.com/Masterminds/squirrel"
@@ -25,10 +29,17 @@ import (
 
 var pathDB = "./petstore.db"
 
+// notifyQueueSize - сколько уведомлений может ждать отправки.
+const notifyQueueSize = 1000
+
+// idempotencyTTL - сколько хранится ответ на запрос с Idempotency-Key.
+const idempotencyTTL = 24 * time.Hour
+
 type Server struct {
-	srv     *http.Server
-	users   map[string]string
-	sigChan chan os.Signal
+	srv      *http.Server
+	users    map[string]string
+	sigChan  chan os.Signal
+	notifier *notify.Dispatcher
 }
 
 func NewServer(addr string) *Server {
@@ -65,22 +76,61 @@ func NewServer(addr string) *Server {
 		FillFakeData(*bd)
 	}
 
+	blobPath := os.Getenv("BLOB_STORAGE_PATH")
+	if blobPath == "" {
+		blobPath = "./uploads"
+	}
+
+	blobStore, err := storage.NewLocalBlobStore(blobPath)
+	if err != nil {
+		log.Fatal(err)
+		return nil
+	}
+	log.Println("initialize blob storage")
+
+	variantSizes, err := imaging.ParseSizes(os.Getenv("IMAGE_VARIANT_SIZES"))
+	if err != nil {
+		log.Fatal(err)
+		return nil
+	}
+
+	sinks, err := notify.NewSinks(notify.Config{
+		Sinks:      os.Getenv("NOTIFY_SINKS"),
+		WebhookURL: os.Getenv("NOTIFY_WEBHOOK_URL"),
+		SMTPAddr:   os.Getenv("SMTP_ADDR"),
+		SMTPFrom:   os.Getenv("SMTP_FROM"),
+	})
+	if err != nil {
+		log.Fatal(err)
+		return nil
+	}
+
+	server.notifier = notify.NewDispatcher(sinks, notifyQueueSize)
+	log.Println("initialize notifications")
+
 	repositories := modules.NewRepository(bd.DB)
-	services := modules.NewService(repositories)
+	services := modules.NewService(repositories, blobStore, variantSizes, server.notifier)
 	respond := responder.NewResponder()
 
-	c := modules.NewController(services, respond)
+	// повторы POST с одним Idempotency-Key получают сохраненный ответ
+	idempotencyKeys := idempotency.NewSQLStore(bd.DB, idempotencyTTL)
+
+	c := modules.NewController(services, respond, idempotencyKeys)
 
 	log.Println("initialize controllers")
 
 	// Инициализируем маршруты
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
 
 	r.Route("/v2", func(r chi.Router) {
 		r.Mount("/user", c.InitRoutesUser())
 		r.Mount("/pet", c.InitRoutesPet())
 		r.Mount("/store", c.InitRoutesStore())
+		r.Mount("/category", c.InitRoutesCategory())
+		r.Mount("/tag", c.InitRoutesTag())
+		r.Mount("/adoption", c.InitRoutesAdoption())
 	})
 
 	r.Get("/swagger/*", httpSwagger.Handler(
@@ -118,6 +168,9 @@ func (s *Server) Serve() {
 		log.Fatal("Server Shutdown:", err)
 	}
 
+	// отправляем уведомления, которые уже стоят в очереди
+	s.notifier.Close()
+
 	log.Println("Server stopped gracefully")
 }
 